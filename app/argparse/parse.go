@@ -31,6 +31,7 @@ import (
 //   - key:[]float64=1.1,2.2        (float64 slice)
 //   - key:[]bool=true,false        (bool slice)
 //   - key:json={"foo":"bar"}       (JSON value)
+//   - key:json=[{"foo":"bar"}]     (JSON list of objects, e.g. for object loops)
 //
 // For slices, commas can be escaped with backslash: key:[]string=has\,comma,normal
 func Parse(args []string) (map[string]any, error) {
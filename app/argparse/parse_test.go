@@ -568,6 +568,16 @@ func Test_Parse_JSONValues(t *testing.T) {
 				"data": []interface{}{float64(1), float64(2), float64(3), float64(4), float64(5)},
 			},
 		},
+		{
+			name: "json_array_of_objects",
+			args: []string{`models:json=[{"name":"user","timestamps":true},{"name":"post"}]`},
+			want: map[string]any{
+				"models": []interface{}{
+					map[string]interface{}{"name": "user", "timestamps": true},
+					map[string]interface{}{"name": "post"},
+				},
+			},
+		},
 		{
 			name: "json_string",
 			args: []string{`message:json="Hello, World!"`},
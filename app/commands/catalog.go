@@ -0,0 +1,139 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/app/scaffold/pkgs"
+	"github.com/rs/zerolog/log"
+)
+
+// catalogEntry is a scaffold offered by `scaffold list` and the scaffold
+// picker.
+type catalogEntry struct {
+	// Source is local or system.
+	Source string
+	// Ref is the name the scaffold is run with, root#sub for system
+	// sub-packages.
+	Ref string
+	// Root and Sub are the repository and sub-package of system scaffolds.
+	Root string
+	Sub  string
+	Dir  string
+	Meta scaffold.Metadata
+}
+
+// summary returns the display name, category, description and tags of the
+// scaffold on one line, or an empty string when it has no metadata.
+func (e catalogEntry) summary() string {
+	parts := []string{}
+
+	if e.Meta.Name != "" {
+		parts = append(parts, e.Meta.Name)
+	}
+
+	if e.Meta.Category != "" {
+		parts = append(parts, e.Meta.Category)
+	}
+
+	if e.Meta.Description != "" {
+		parts = append(parts, e.Meta.Description)
+	}
+
+	if len(e.Meta.Tags) > 0 {
+		parts = append(parts, "#"+strings.Join(e.Meta.Tags, " #"))
+	}
+
+	return strings.Join(parts, " · ")
+}
+
+// loadLocalCatalog loads scaffolds from all configured scaffold directories.
+// Missing directories and empty directories are silently skipped (debug logged)
+// since the default .scaffold directory won't exist until `scaffold init` is run.
+func (ctrl *Controller) loadLocalCatalog() ([]catalogEntry, error) {
+	entries := []catalogEntry{}
+
+	for _, dir := range ctrl.Flags.ScaffoldDirs {
+		_, err := os.Stat(dir)
+		if os.IsNotExist(err) {
+			log.Debug().Str("dir", dir).Msg("scaffold directory not found, skipping")
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to check directory %s: %w", dir, err)
+		}
+
+		scaffolds, err := pkgs.ListFromFS(os.DirFS(dir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to list scaffolds from %s: %w", dir, err)
+		}
+
+		if len(scaffolds) == 0 {
+			log.Debug().Str("dir", dir).Msg("no scaffolds found in directory, skipping")
+			continue
+		}
+
+		for _, name := range scaffolds {
+			entries = append(entries, newCatalogEntry("local", name, filepath.Join(dir, name)))
+		}
+	}
+
+	return entries, nil
+}
+
+// systemCatalog returns the cached system scaffolds with their metadata.
+func (ctrl *Controller) systemCatalog(systemScaffolds []pkgs.PackageList) []catalogEntry {
+	entries := []catalogEntry{}
+
+	for _, s := range systemScaffolds {
+		if len(s.SubPackages) == 0 {
+			e := newCatalogEntry("system", s.Root, filepath.Join(ctrl.Flags.Cache, s.Root))
+			e.Root = s.Root
+			entries = append(entries, e)
+			continue
+		}
+
+		for _, sub := range s.SubPackages {
+			e := newCatalogEntry("system", fmt.Sprintf("%s#%s", s.Root, sub), filepath.Join(ctrl.Flags.Cache, s.Root, sub))
+			e.Root, e.Sub = s.Root, sub
+			entries = append(entries, e)
+		}
+	}
+
+	return entries
+}
+
+// loadCatalog returns the local and system scaffolds tagged with every tag
+// of tags.
+func (ctrl *Controller) loadCatalog(tags []string) ([]catalogEntry, error) {
+	systemScaffolds, err := pkgs.ListSystem(os.DirFS(ctrl.Flags.Cache))
+	if err != nil {
+		return nil, fmt.Errorf("listing system scaffolds: %w", err)
+	}
+
+	local, err := ctrl.loadLocalCatalog()
+	if err != nil {
+		return nil, fmt.Errorf("listing local scaffolds: %w", err)
+	}
+
+	entries := []catalogEntry{}
+	for _, e := range append(local, ctrl.systemCatalog(systemScaffolds)...) {
+		if e.Meta.HasTags(tags...) {
+			entries = append(entries, e)
+		}
+	}
+
+	return entries, nil
+}
+
+func newCatalogEntry(source, ref, dir string) catalogEntry {
+	meta, err := scaffold.ReadMetadata(os.DirFS(dir))
+	if err != nil {
+		log.Debug().Err(err).Str("dir", dir).Msg("failed to read scaffold metadata")
+	}
+
+	return catalogEntry{Source: source, Ref: ref, Dir: dir, Meta: meta}
+}
@@ -0,0 +1,81 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold/extract"
+)
+
+type FlagsExtract struct {
+	// Source is the path to the project to extract a scaffold from.
+	Source string
+	// Dest is the directory the scaffold is written to.
+	Dest string
+	// Mappings are literal=variable arguments.
+	Mappings []string
+	// Ignore contains additional globs to exclude.
+	Ignore []string
+}
+
+// Extract creates a scaffold from an existing project directory.
+func (ctrl *Controller) Extract(flags FlagsExtract) error {
+	ctrl.ready()
+
+	mappings, err := extract.ParseMappings(flags.Mappings)
+	if err != nil {
+		return err
+	}
+
+	info, err := os.Stat(flags.Source)
+	if err != nil {
+		return err
+	}
+
+	if !info.IsDir() {
+		return fmt.Errorf("%s is not a directory", flags.Source)
+	}
+
+	_, err = os.Stat(filepath.Join(flags.Dest, "scaffold.yaml"))
+	if err == nil {
+		return fmt.Errorf("%s already contains a scaffold.yaml", flags.Dest)
+	}
+
+	err = os.MkdirAll(flags.Dest, 0o755)
+	if err != nil {
+		return err
+	}
+
+	// A destination inside the source is excluded so the scaffold being
+	// written isn't extracted again.
+	ignore, err := extract.DestIgnore(flags.Source, flags.Dest)
+	if err != nil {
+		return err
+	}
+
+	res, err := extract.Extract(ctrl.engine, os.DirFS(flags.Source), rwfs.NewOsWFS(flags.Dest), extract.Options{
+		Mappings: mappings,
+		Ignore:   append(ignore, flags.Ignore...),
+	})
+	if err != nil {
+		return err
+	}
+
+	ctrl.printer.LineBreak()
+
+	items := make([]string, 0, len(res.Replacements))
+	for _, r := range res.Replacements {
+		items = append(items, fmt.Sprintf("%s → %s (%d)", r.Literal, r.Template, r.Count))
+	}
+	ctrl.printer.List(fmt.Sprintf("Extracted %d files into %s", res.Files, filepath.Join(flags.Dest, res.NameTemplate)), items)
+
+	if len(res.Warnings) > 0 {
+		ctrl.printer.LineBreak()
+		ctrl.printer.List("Warnings", res.Warnings)
+	}
+
+	ctrl.printer.LineBreak()
+	return nil
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold/cookiecutter"
+)
+
+type FlagsImport struct {
+	// Source is a path or remote reference to the template to import.
+	Source string
+	// Dest is the directory the converted scaffold is written to.
+	Dest string
+}
+
+// ImportCookiecutter converts a cookiecutter template into a native scaffold.
+func (ctrl *Controller) ImportCookiecutter(flags FlagsImport) error {
+	ctrl.ready()
+
+	src, err := ctrl.resolve(flags.Source, ".", true, true)
+	if err != nil {
+		return err
+	}
+
+	if !cookiecutter.Detect(os.DirFS(src)) {
+		return fmt.Errorf("%s is not a cookiecutter template", flags.Source)
+	}
+
+	_, err = os.Stat(filepath.Join(flags.Dest, "scaffold.yaml"))
+	if err == nil {
+		return fmt.Errorf("%s already contains a scaffold.yaml", flags.Dest)
+	}
+
+	err = os.MkdirAll(flags.Dest, 0o755)
+	if err != nil {
+		return err
+	}
+
+	res, err := cookiecutter.Convert(os.DirFS(src), rwfs.NewOsWFS(flags.Dest))
+	if err != nil {
+		return err
+	}
+
+	ctrl.printer.LineBreak()
+
+	items := []string{
+		fmt.Sprintf("source: %s", src),
+		fmt.Sprintf("output: %s", flags.Dest),
+		fmt.Sprintf("{{cookiecutter.%s}} → {{ .Project }}", res.SlugVar),
+	}
+	ctrl.printer.List("Imported Cookiecutter Template", items)
+
+	if len(res.Warnings) > 0 {
+		ctrl.printer.LineBreak()
+		ctrl.printer.Warning(fmt.Sprintf("%d construct(s) require manual review:", len(res.Warnings)))
+		ctrl.printer.List("Warnings", res.Warnings)
+	}
+
+	ctrl.printer.LineBreak()
+	return nil
+}
@@ -2,22 +2,44 @@ package commands
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"os"
+	"reflect"
 
+	"github.com/hay-kot/scaffold/app/core/rwfs"
 	"github.com/hay-kot/scaffold/app/scaffold"
 )
 
 type FlagsInspect struct {
-	Path string
+	Path   string
+	Plan   bool
+	Preset string
 }
 
 // InspectOutput is the JSON output format for the inspect command.
 type InspectOutput struct {
+	Metadata  *InspectMetadata          `json:"metadata,omitempty"`
 	Questions []InspectQuestion         `json:"questions"`
 	Presets   map[string]map[string]any `json:"presets,omitempty"`
 	Computed  map[string]string         `json:"computed,omitempty"`
 	Features  []InspectFeature          `json:"features,omitempty"`
 	Messages  *InspectMessages          `json:"messages,omitempty"`
+	Plan      *InspectPlan              `json:"plan,omitempty"`
+}
+
+// InspectMetadata describes the scaffold.
+type InspectMetadata struct {
+	Name           string   `json:"name,omitempty"`
+	Description    string   `json:"description,omitempty"`
+	Category       string   `json:"category,omitempty"`
+	Tags           []string `json:"tags,omitempty"`
+	Maintainers    []string `json:"maintainers,omitempty"`
+	Homepage       string   `json:"homepage,omitempty"`
+	MinimumVersion string   `json:"minimum_version,omitempty"`
+	MaximumVersion string   `json:"maximum_version,omitempty"`
+	Constraints    string   `json:"constraints,omitempty"`
+	Requires       []string `json:"requires,omitempty"`
 }
 
 // InspectQuestion describes a scaffold variable/question.
@@ -30,6 +52,8 @@ type InspectQuestion struct {
 	Default     any      `json:"default,omitempty"`
 	Options     []string `json:"options,omitempty"`
 	Group       string   `json:"group,omitempty"`
+	// Fields describes the shape of each item for "[]object" questions.
+	Fields []InspectQuestion `json:"fields,omitempty"`
 }
 
 // InspectFeature describes a scaffold feature toggle.
@@ -44,15 +68,55 @@ type InspectMessages struct {
 	Post string `json:"post,omitempty"`
 }
 
+// InspectPlan describes the output of the scaffold for the answers of a
+// preset, or the question defaults.
+type InspectPlan struct {
+	Preset     string              `json:"preset,omitempty"`
+	Vars       map[string]any      `json:"vars"`
+	Files      []string            `json:"files"`
+	Dropped    []string            `json:"dropped,omitempty"`
+	Injections []InspectInjection  `json:"injections,omitempty"`
+	Hooks      []string            `json:"hooks,omitempty"`
+	Partials   []string            `json:"partials,omitempty"`
+	Delimiters []InspectDelimiters `json:"delimiters,omitempty"`
+	Each       []InspectEach       `json:"each,omitempty"`
+}
+
+// InspectInjection describes an injection into an existing file.
+type InspectInjection struct {
+	Name    string `json:"name"`
+	Path    string `json:"path"`
+	At      string `json:"at"`
+	Mode    string `json:"mode,omitempty"`
+	Skipped bool   `json:"skipped,omitempty"`
+}
+
+// InspectDelimiters describes a delimiter override.
+type InspectDelimiters struct {
+	Glob  string `json:"glob"`
+	Left  string `json:"left"`
+	Right string `json:"right"`
+}
+
+// InspectEach describes a variable expanded into multiple files.
+type InspectEach struct {
+	Var string `json:"var"`
+	As  string `json:"as,omitempty"`
+}
+
 func (ctrl *Controller) Inspect(flags FlagsInspect) error {
 	ctrl.ready()
 
+	if flags.Preset != "" && !flags.Plan {
+		return errors.New("--preset requires --plan")
+	}
+
 	path, err := ctrl.resolve(flags.Path, ".", true, true)
 	if err != nil {
 		return err
 	}
 
-	project, err := scaffold.LoadProject(os.DirFS(path), scaffold.Options{})
+	project, err := scaffold.LoadProject(rwfs.NewOsRFS(path), scaffold.Options{})
 	if err != nil {
 		return err
 	}
@@ -67,6 +131,21 @@ func (ctrl *Controller) Inspect(flags FlagsInspect) error {
 		output.Questions[i] = questionToInspect(q)
 	}
 
+	if meta := project.Conf.Metadata; !reflect.ValueOf(meta).IsZero() {
+		output.Metadata = &InspectMetadata{
+			Name:           meta.Name,
+			Description:    meta.Description,
+			Category:       meta.Category,
+			Tags:           meta.Tags,
+			Maintainers:    meta.Maintainers,
+			Homepage:       meta.Homepage,
+			MinimumVersion: meta.MinimumVersion,
+			MaximumVersion: meta.MaximumVersion,
+			Constraints:    meta.Constraints,
+			Requires:       meta.Requires,
+		}
+	}
+
 	if len(project.Conf.Features) > 0 {
 		output.Features = make([]InspectFeature, len(project.Conf.Features))
 		for i, f := range project.Conf.Features {
@@ -84,11 +163,66 @@ func (ctrl *Controller) Inspect(flags FlagsInspect) error {
 		}
 	}
 
+	if flags.Plan {
+		output.Plan, err = ctrl.inspectPlan(project, flags.Preset)
+		if err != nil {
+			return err
+		}
+	}
+
 	encoder := json.NewEncoder(os.Stdout)
 	encoder.SetIndent("", "  ")
 	return encoder.Encode(output)
 }
 
+// inspectPlan renders the project into memory with the answers of the preset,
+// or the defaults of the questions when preset is empty.
+func (ctrl *Controller) inspectPlan(project *scaffold.Project, preset string) (*InspectPlan, error) {
+	vars := scaffold.DefaultAnswers(ctrl.engine, project.Conf)
+	if preset != "" {
+		presetVars, ok := project.Conf.Presets[preset]
+		if !ok {
+			return nil, fmt.Errorf("preset '%s' not found", preset)
+		}
+
+		vars = scaffold.MergeMaps(vars, presetVars)
+	}
+
+	plan, err := scaffold.BuildPlan(ctrl.engine, project, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	out := &InspectPlan{
+		Preset:   preset,
+		Vars:     vars,
+		Files:    plan.Files,
+		Dropped:  plan.Dropped,
+		Hooks:    plan.Hooks,
+		Partials: plan.Partials,
+	}
+
+	for _, inj := range plan.Injections {
+		out.Injections = append(out.Injections, InspectInjection{
+			Name:    inj.Name,
+			Path:    inj.Path,
+			At:      inj.At,
+			Mode:    string(inj.Mode),
+			Skipped: inj.Skipped,
+		})
+	}
+
+	for _, d := range project.Conf.Delimiters {
+		out.Delimiters = append(out.Delimiters, InspectDelimiters{Glob: d.Glob, Left: d.Left, Right: d.Right})
+	}
+
+	for _, e := range project.Conf.Each {
+		out.Each = append(out.Each, InspectEach{Var: e.Var, As: e.As})
+	}
+
+	return out, nil
+}
+
 func questionToInspect(q scaffold.Question) InspectQuestion {
 	iq := InspectQuestion{
 		Name:     q.Name,
@@ -119,6 +253,16 @@ func questionToInspect(q scaffold.Question) InspectQuestion {
 		if q.Prompt.Options != nil {
 			iq.Options = *q.Prompt.Options
 		}
+	case q.Prompt.IsObjectLoop():
+		iq.Type = "[]object"
+		if q.Prompt.Message != nil {
+			iq.Message = *q.Prompt.Message
+		}
+
+		iq.Fields = make([]InspectQuestion, len(q.Prompt.Fields))
+		for i, f := range q.Prompt.Fields {
+			iq.Fields[i] = questionToInspect(f)
+		}
 	case q.Prompt.IsInputLoop():
 		iq.Type = "[]string"
 		if q.Prompt.Message != nil {
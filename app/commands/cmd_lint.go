@@ -1,121 +1,401 @@
 package commands
 
 import (
+	"bytes"
 	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
+	"github.com/hashicorp/go-version"
 	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/formatters"
 	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/app/scaffold/pkgs"
 )
 
-type ErrList []error
+// lintQuestion validates the name and prompt type of a question at pos,
+// prefix is prepended to the name in messages.
+func lintQuestion(file string, pos scaffold.Position, q scaffold.Question, prefix string) []scaffold.Diagnostic {
+	var diags []scaffold.Diagnostic
 
-func (e ErrList) Error() string {
-	var s string
-	for _, err := range e {
-		s += err.Error() + "\n"
+	// Template variables only allow alphanumeric characters and underscores.
+	if !engine.IsValidIdentifier(q.Name) {
+		d := scaffold.NewDiagnostic(scaffold.RuleInvalidName, file, fmt.Sprintf("invalid template variable name: %s%s (only alphanumeric and underscore characters are supported)", prefix, q.Name))
+		diags = append(diags, d.At(pos))
 	}
-	return s
+
+	types := [...]bool{
+		q.Prompt.IsInput(),
+		q.Prompt.IsConfirm(),
+		q.Prompt.IsSelect(),
+		q.Prompt.IsMultiSelect(),
+		q.Prompt.IsInputLoop(),
+		q.Prompt.IsObjectLoop(),
+		q.Prompt.IsTextInput(),
+	}
+
+	isAny := false
+	for _, t := range types {
+		if t {
+			isAny = true
+			break
+		}
+	}
+
+	if !isAny {
+		d := scaffold.NewDiagnostic(scaffold.RuleInvalidQuestion, file, fmt.Sprintf("unknown prompt type for question %s%s", prefix, q.Name))
+		diags = append(diags, d.At(pos))
+	}
+
+	return diags
 }
 
-func (ctrl *Controller) Lint(pfpath string) error {
-	file, err := os.OpenFile(pfpath, os.O_RDONLY, 0)
+// ErrLinterErrors is returned by Lint when a diagnostic with error severity
+// was reported.
+var ErrLinterErrors = errors.New("scaffold errors found")
+
+type FlagsLint struct {
+	// Format is the output format, one of text, json, sarif or github.
+	Format string
+	// All lints every local and cached scaffold.
+	All bool
+}
+
+// Lint validates a scaffold file and the templates of its scaffold, and
+// writes the diagnostics in the requested format. The argument is a scaffold
+// file, a scaffold directory or any reference accepted by `new`, and defaults
+// to the current directory.
+func (ctrl *Controller) Lint(args []string, flags FlagsLint) error {
+	ctrl.ready()
+
+	write, err := lintWriter(flags.Format)
 	if err != nil {
 		return err
 	}
 
-	pf, err := scaffold.ReadScaffoldFile(file)
+	var files []string
+	switch {
+	case flags.All:
+		files, err = ctrl.allScaffoldFiles()
+		if err != nil {
+			return err
+		}
+
+		if len(files) == 0 {
+			return fmt.Errorf("no scaffolds found")
+		}
+	default:
+		arg := "."
+		if len(args) > 0 {
+			arg = args[0]
+		}
+
+		file, err := ctrl.lintTarget(arg)
+		if err != nil {
+			return err
+		}
+		files = []string{file}
+	}
+
+	diags := make([]scaffold.Diagnostic, 0)
+	for _, file := range files {
+		d, err := ctrl.lintFile(file)
+		if err != nil {
+			// A broken scaffold shouldn't stop the others from being linted.
+			if !flags.All {
+				return err
+			}
+
+			d = []scaffold.Diagnostic{scaffold.NewDiagnostic(scaffold.RuleInvalidConfig, file, err.Error())}
+		}
+
+		diags = append(diags, d...)
+	}
+
+	err = write(ctrl, os.Stdout, diags)
 	if err != nil {
 		return err
 	}
 
-	errs := make([]error, 0)
+	for _, d := range diags {
+		if d.Severity == scaffold.SeverityError {
+			return ErrLinterErrors
+		}
+	}
+
+	return nil
+}
 
-	for _, q := range pf.Questions {
-		// Template variables only allow alphanumeric characters and underscores.
-		if !engine.IsValidIdentifier(q.Name) {
-			errs = append(errs, fmt.Errorf("invalid template variable name: %s (only alphanumeric and underscore characters are supported)", q.Name))
+// lintTarget returns the scaffold file for arg. Files and local directories
+// are used as is, everything else is resolved like the argument of `new`.
+func (ctrl *Controller) lintTarget(arg string) (string, error) {
+	info, err := os.Stat(arg)
+	switch {
+	case err == nil && !info.IsDir():
+		return arg, nil
+	case err == nil:
+		return scaffoldFile(arg)
+	}
+
+	dir, err := ctrl.resolve(arg, ".", true, true)
+	if err != nil {
+		return "", err
+	}
+
+	return scaffoldFile(dir)
+}
+
+// allScaffoldFiles returns the scaffold files of every scaffold in the
+// scaffold directories and in the cache.
+func (ctrl *Controller) allScaffoldFiles() ([]string, error) {
+	files := []string{}
+	seen := map[string]bool{}
+
+	add := func(dir string) {
+		file, err := scaffoldFile(dir)
+		if err != nil || seen[file] {
+			return
 		}
 
-		types := [...]bool{
-			q.Prompt.IsInput(),
-			q.Prompt.IsConfirm(),
-			q.Prompt.IsSelect(),
-			q.Prompt.IsMultiSelect(),
-			q.Prompt.IsInputLoop(),
-			q.Prompt.IsTextInput(),
+		seen[file] = true
+		files = append(files, file)
+	}
+
+	local, err := ctrl.loadLocalScaffolds()
+	if err != nil {
+		return nil, err
+	}
+
+	for _, name := range local {
+		dir, err := ctrl.resolve(name, ".", true, true)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s: %w", name, err)
+		}
+		add(dir)
+	}
+
+	system, err := pkgs.ListSystem(os.DirFS(ctrl.Flags.Cache))
+	if err != nil {
+		return nil, err
+	}
+
+	for _, pkg := range system {
+		root := filepath.Join(ctrl.Flags.Cache, pkg.Root)
+		add(root)
+
+		for _, sub := range pkg.SubPackages {
+			add(filepath.Join(root, sub))
 		}
+	}
 
-		isAny := false
-		for _, t := range types {
-			if t {
-				isAny = true
-				break
+	return files, nil
+}
+
+// scaffoldFile returns the path of the scaffold file in dir.
+func scaffoldFile(dir string) (string, error) {
+	for _, name := range []string{"scaffold.yaml", "scaffold.yml"} {
+		path := filepath.Join(dir, name)
+		if _, err := os.Stat(path); err == nil {
+			return path, nil
+		}
+	}
+
+	return "", fmt.Errorf("%s: scaffold.{yml,yaml} does not exist", dir)
+}
+
+// lintFile validates the scaffold file at pfpath and the templates of its
+// scaffold. Diagnostic paths are relative to the working directory.
+func (ctrl *Controller) lintFile(pfpath string) ([]scaffold.Diagnostic, error) {
+	file, err := os.OpenFile(pfpath, os.O_RDONLY, 0)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
+
+	pf, err := scaffold.ReadScaffoldFile(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	// The file decoded, so it's valid YAML and the positions parse as well.
+	pos, _ := scaffold.ReadConfPositions(data)
+
+	diags := make([]scaffold.Diagnostic, 0)
+
+	add := func(at scaffold.Position, rule string, format string, args ...any) {
+		d := scaffold.NewDiagnostic(rule, pfpath, fmt.Sprintf(format, args...))
+		diags = append(diags, d.At(at))
+	}
+
+	for i, q := range pf.Questions {
+		diags = append(diags, lintQuestion(pfpath, pos.Of("questions", i), q, "")...)
+
+		if !q.Prompt.IsObjectLoop() {
+			if len(q.Prompt.Fields) > 0 {
+				add(pos.Of("questions", i, "prompt", "fields"), scaffold.RuleInvalidQuestion, "question %s has fields but is not a loop with a message", q.Name)
 			}
+			continue
 		}
 
-		if !isAny {
-			errs = append(errs, fmt.Errorf("unknown prompt type for question %s", q.Name))
+		seen := map[string]bool{}
+		for j, f := range q.Prompt.Fields {
+			at := pos.Of("questions", i, "prompt", "fields", j)
+			diags = append(diags, lintQuestion(pfpath, at, f, q.Name+".")...)
+
+			if seen[f.Name] {
+				add(at, scaffold.RuleInvalidQuestion, "duplicate field %s in question %s", f.Name, q.Name)
+			}
+			seen[f.Name] = true
+
+			if len(f.Prompt.Fields) > 0 {
+				add(pos.Of("questions", i, "prompt", "fields", j, "prompt", "fields"), scaffold.RuleInvalidQuestion, "field %s.%s: nested object loops are not supported", q.Name, f.Name)
+			}
+
+			// Fields of an item are asked in a single group, there is no way
+			// to hide some of them.
+			if f.When != "" {
+				add(pos.Of("questions", i, "prompt", "fields", j, "when"), scaffold.RuleInvalidQuestion, "field %s.%s: when is not supported on loop fields", q.Name, f.Name)
+			}
 		}
 	}
 
 	// Check Computed variable names are valid identifiers.
 	for k := range pf.Computed {
 		if !engine.IsValidIdentifier(k) {
-			errs = append(errs, fmt.Errorf("invalid computed variable name: %s (only alphanumeric and underscore characters are supported)", k))
+			add(pos.Of("computed", k), scaffold.RuleInvalidName, "invalid computed variable name: %s (only alphanumeric and underscore characters are supported)", k)
+		}
+	}
+
+	// Validate versions and required capabilities
+	if v := pf.Metadata.MinimumVersion; v != "" && v != "*" {
+		if _, err := version.NewVersion(v); err != nil {
+			add(pos.Of("metadata", "minimum_version"), scaffold.RuleInvalidConfig, "invalid metadata.minimum_version: %s", v)
+		}
+	}
+
+	if v := pf.Metadata.MaximumVersion; v != "" {
+		if _, err := version.NewVersion(v); err != nil {
+			add(pos.Of("metadata", "maximum_version"), scaffold.RuleInvalidConfig, "invalid metadata.maximum_version: %s", v)
+		}
+	}
+
+	if pf.Metadata.Constraints != "" {
+		if _, err := version.NewConstraint(pf.Metadata.Constraints); err != nil {
+			add(pos.Of("metadata", "constraints"), scaffold.RuleInvalidConfig, "invalid metadata.constraints: %s", pf.Metadata.Constraints)
+		}
+	}
+
+	for i, id := range pf.Metadata.Requires {
+		if _, ok := scaffold.LookupCapability(id); !ok {
+			add(pos.Of("metadata", "requires", i), scaffold.RuleInvalidConfig, "unknown capability %q in metadata.requires, it may need a newer release of scaffold", id)
 		}
 	}
 
 	// Validate skip patterns
-	for _, skip := range pf.Skip {
+	for i, skip := range pf.Skip {
 		ok := doublestar.ValidatePathPattern(skip)
 		if !ok {
-			errs = append(errs, fmt.Errorf("invalid skip pattern: %s", skip))
+			add(pos.Of("skip", i), scaffold.RuleInvalidGlob, "invalid skip pattern: %s", skip)
+		}
+	}
+
+	// Validate empty settings
+	switch pf.Empty {
+	case "", scaffold.EmptyDelete, scaffold.EmptyKeep:
+	default:
+		add(pos.Of("empty"), scaffold.RuleInvalidConfig, "invalid empty mode: %s (must be one of delete, keep)", pf.Empty)
+	}
+
+	for i, glob := range pf.KeepEmpty {
+		ok := doublestar.ValidatePathPattern(glob)
+		if !ok {
+			add(pos.Of("keep_empty", i), scaffold.RuleInvalidGlob, "invalid keep_empty pattern: %s", glob)
 		}
 	}
 
 	// Validate rewrites from fields exist
 	scaffolddir := filepath.Dir(pfpath)
-	for _, rewrite := range pf.Rewrites {
+	for i, rewrite := range pf.Rewrites {
 		abs, _ := filepath.Abs(filepath.Join(scaffolddir, rewrite.From))
 
 		_, err := os.Stat(abs)
 		if err != nil {
-			errs = append(errs, fmt.Errorf("rewrite from path does not exist: %s", rewrite.From))
+			add(pos.Of("rewrites", i, "from"), scaffold.RuleInvalidConfig, "rewrite from path does not exist: %s", rewrite.From)
 		}
 	}
 
 	// Validate injectjons
-	for _, injection := range pf.Inject {
+	for i, injection := range pf.Inject {
 		if injection.Mode != "" {
 			if injection.Mode != "before" && injection.Mode != "after" {
-				errs = append(errs, fmt.Errorf("invalid injection mode: %s", injection.Mode))
+				add(pos.Of("inject", i, "mode"), scaffold.RuleInvalidConfig, "invalid injection mode: %s", injection.Mode)
 			}
 		}
 	}
 
 	// Validate delim patterns
-	for _, delim := range pf.Delimiters {
+	for i, delim := range pf.Delimiters {
 		ok := doublestar.ValidatePathPattern(delim.Glob)
 		if !ok {
-			errs = append(errs, fmt.Errorf("invalid delim glob pattern: %s", delim))
+			add(pos.Of("delimiters", i, "glob"), scaffold.RuleInvalidGlob, "invalid delim glob pattern: %s", delim)
 		}
 
 		if delim.Left == "" {
-			errs = append(errs, errors.New("invalid left delimiter"))
+			add(pos.Of("delimiters", i, "left"), scaffold.RuleInvalidConfig, "invalid left delimiter")
 		}
 
 		if delim.Right == "" {
-			errs = append(errs, errors.New("invalid right delimiter"))
+			add(pos.Of("delimiters", i, "right"), scaffold.RuleInvalidConfig, "invalid right delimiter")
 		}
 	}
 
-	if len(errs) == 0 {
-		return nil
+	// Validate formatters
+	for i, f := range pf.Format {
+		ok := doublestar.ValidatePathPattern(f.Glob)
+		if !ok {
+			add(pos.Of("format", i, "glob"), scaffold.RuleInvalidGlob, "invalid format glob pattern: %s", f.Glob)
+		}
+
+		if _, ok := formatters.Get(f.Formatter); !ok {
+			add(pos.Of("format", i, "formatter"), scaffold.RuleInvalidConfig, "unknown formatter %q: must be one of %s", f.Formatter, strings.Join(formatters.Names(), ", "))
+		}
+	}
+
+	// Validate whitespace patterns
+	for i, ws := range pf.Whitespace {
+		if ws.Glob != "" && !doublestar.ValidatePathPattern(ws.Glob) {
+			add(pos.Of("whitespace", i, "glob"), scaffold.RuleInvalidGlob, "invalid whitespace glob pattern: %s", ws.Glob)
+		}
+	}
+
+	// Validate checks
+	for i, check := range pf.Checks {
+		err := check.Validate()
+		if err != nil {
+			add(pos.Of("checks", i), scaffold.RuleInvalidConfig, "invalid check %d: %s", i, err)
+		}
+	}
+
+	// Parse and walk the templates
+	p, err := scaffold.LoadProject(os.DirFS(scaffolddir), scaffold.Options{})
+	if err != nil {
+		add(scaffold.Position{}, scaffold.RuleInvalidConfig, "%s", err)
+	} else {
+		p.Conf = pf
+		for _, d := range scaffold.LintProject(ctrl.engine, p) {
+			d.File = filepath.ToSlash(filepath.Join(scaffolddir, d.File))
+			diags = append(diags, d)
+		}
 	}
 
-	return ErrList(errs)
+	return scaffold.FilterDiagnostics(diags, pf.Lint, pfpath, pos), nil
 }
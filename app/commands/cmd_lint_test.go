@@ -0,0 +1,154 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/scaffold/scaffoldrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintFixture creates a scaffold directory with the cli and yml scaffolds and
+// a cache with the acme/templates repository, which has the api sub-package,
+// and the solo/app repository.
+func lintFixture(t *testing.T) (ctrl *Controller, local, repo string) {
+	t.Helper()
+
+	tmp := t.TempDir()
+	local = filepath.Join(tmp, "local")
+	cache := filepath.Join(tmp, "cache")
+	repo = filepath.Join(cache, "github.com", "acme", "templates")
+
+	for _, dir := range []string{
+		filepath.Join(local, "cli"),
+		filepath.Join(local, "yml"),
+		filepath.Join(repo, ".git"),
+		filepath.Join(repo, "api"),
+		filepath.Join(cache, "github.com", "solo", "app", ".git"),
+	} {
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+	}
+
+	for _, file := range []string{
+		filepath.Join(local, "cli", "scaffold.yaml"),
+		filepath.Join(local, "yml", "scaffold.yml"),
+		filepath.Join(repo, "scaffold.yaml"),
+		filepath.Join(repo, "api", "scaffold.yaml"),
+		filepath.Join(cache, "github.com", "solo", "app", "scaffold.yaml"),
+	} {
+		require.NoError(t, os.WriteFile(file, []byte("questions: []\n"), 0o644))
+	}
+
+	ctrl = &Controller{Flags: Flags{Cache: cache, ScaffoldDirs: []string{local}}}
+	ctrl.Prepare(engine.New(), scaffoldrc.Default())
+
+	return ctrl, local, repo
+}
+
+func TestController_lintTarget(t *testing.T) {
+	ctrl, local, repo := lintFixture(t)
+
+	tests := []struct {
+		name    string
+		arg     string
+		want    string
+		wantErr bool
+	}{
+		{name: "file", arg: filepath.Join(local, "cli", "scaffold.yaml"), want: filepath.Join(local, "cli", "scaffold.yaml")},
+		{name: "directory", arg: filepath.Join(local, "cli"), want: filepath.Join(local, "cli", "scaffold.yaml")},
+		{name: "directory with scaffold.yml", arg: filepath.Join(local, "yml"), want: filepath.Join(local, "yml", "scaffold.yml")},
+		{name: "scaffold name", arg: "cli", want: filepath.Join(local, "cli", "scaffold.yaml")},
+		{name: "remote ref", arg: "https://github.com/acme/templates", want: filepath.Join(repo, "scaffold.yaml")},
+		{name: "remote sub-package", arg: "https://github.com/acme/templates#api", want: filepath.Join(repo, "api", "scaffold.yaml")},
+		{name: "directory without scaffold file", arg: local, wantErr: true},
+		{name: "unknown scaffold", arg: "missing", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ctrl.lintTarget(tt.arg)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestController_allScaffoldFiles(t *testing.T) {
+	ctrl, local, repo := lintFixture(t)
+
+	// The local directory is listed twice and the solo/app repository is
+	// both a local and a cached scaffold, every scaffold file is linted once.
+	solo := filepath.Join(ctrl.Flags.Cache, "github.com", "solo")
+	ctrl.Flags.ScaffoldDirs = []string{local, local, solo}
+
+	got, err := ctrl.allScaffoldFiles()
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{
+		filepath.Join(local, "cli", "scaffold.yaml"),
+		filepath.Join(local, "yml", "scaffold.yml"),
+		filepath.Join(solo, "app", "scaffold.yaml"),
+		filepath.Join(repo, "scaffold.yaml"),
+		filepath.Join(repo, "api", "scaffold.yaml"),
+	}, got)
+}
+
+func TestController_lintFile_Positions(t *testing.T) {
+	ctrl, local, _ := lintFixture(t)
+
+	pfpath := filepath.Join(local, "cli", "scaffold.yaml")
+	require.NoError(t, os.MkdirAll(filepath.Join(local, "cli", "{{ .Project }}"), 0o755))
+	require.NoError(t, os.WriteFile(pfpath, []byte(`metadata:
+  minimum_version: "not a version"
+  requires: [each, nope]
+questions:
+  - name: bad-name
+    prompt:
+      message: Name
+computed:
+  bad-key: "x"
+skip:
+  - "[*.go"
+delimiters:
+  - glob: "*.tpl"
+    left: "[["
+format:
+  - glob: "*.go"
+    formatter: nope
+checks:
+  - {}
+lint:
+  disable: [nope, minimum-version]
+`), 0o644))
+
+	diags, err := ctrl.lintFile(pfpath)
+	require.NoError(t, err)
+
+	got := []string{}
+	for _, d := range diags {
+		assert.Equal(t, pfpath, d.File)
+		got = append(got, fmt.Sprintf("%d:%d %s", d.Line, d.Column, d.Rule))
+	}
+
+	assert.ElementsMatch(t, []string{
+		"2:3 invalid-config",  // metadata.minimum_version
+		"3:20 invalid-config", // metadata.requires[1]
+		"5:5 invalid-name",    // questions[0]
+		"5:5 unused-question", // questions[0].name
+		"9:3 invalid-name",    // computed.bad-key
+		"11:5 invalid-glob",   // skip[0]
+		"13:5 invalid-config", // delimiters[0], right is missing
+		"17:5 invalid-config", // format[0].formatter
+		"19:5 invalid-config", // checks[0]
+		"21:13 unknown-rule",  // lint.disable[0]
+	}, got)
+}
@@ -6,20 +6,23 @@ import (
 	"os"
 	"sort"
 
-	"github.com/hay-kot/scaffold/app/scaffold/pkgs"
 	"github.com/hay-kot/scaffold/internal/printer"
+	"github.com/hay-kot/scaffold/internal/styles"
 )
 
 type FlagsList struct {
 	OutputDir string
 	JSON      bool
+	// Tags limits the scaffolds to those tagged with every tag.
+	Tags []string
 }
 
 // ListOutput is the JSON output format for the list command.
 type ListOutput struct {
-	Aliases map[string]string  `json:"aliases,omitempty"`
-	Local   []string           `json:"local"`
-	System  []ListSystemOutput `json:"system"`
+	Aliases   map[string]string  `json:"aliases,omitempty"`
+	Local     []string           `json:"local"`
+	System    []ListSystemOutput `json:"system"`
+	Scaffolds []ListScaffold     `json:"scaffolds"`
 }
 
 // ListSystemOutput represents a system scaffold with its subpackages.
@@ -28,59 +31,84 @@ type ListSystemOutput struct {
 	SubPackages []string `json:"subpackages,omitempty"`
 }
 
+// ListScaffold describes a local or system scaffold with its metadata.
+type ListScaffold struct {
+	Ref         string   `json:"ref"`
+	Source      string   `json:"source"`
+	Path        string   `json:"path"`
+	Name        string   `json:"name,omitempty"`
+	Description string   `json:"description,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Tags        []string `json:"tags,omitempty"`
+	Maintainers []string `json:"maintainers,omitempty"`
+	Homepage    string   `json:"homepage,omitempty"`
+}
+
 func (ctrl *Controller) List(flags FlagsList) error {
-	systemScaffolds, err := pkgs.ListSystem(os.DirFS(ctrl.Flags.Cache))
+	entries, err := ctrl.loadCatalog(flags.Tags)
 	if err != nil {
 		return err
 	}
 
-	// Collect scaffolds from all configured scaffold directories
-	localScaffolds, err := ctrl.loadLocalScaffolds()
-	if err != nil {
-		return err
+	// Aliases have no metadata, they're only listed when not filtering.
+	aliases := ctrl.rc.Aliases
+	if len(flags.Tags) > 0 {
+		aliases = nil
 	}
 
 	if flags.JSON {
-		return ctrl.listJSON(localScaffolds, systemScaffolds)
+		return ctrl.listJSON(aliases, entries)
 	}
 
 	ctrl.printer.LineBreak()
 
-	if len(ctrl.rc.Aliases) > 0 {
-		names := make([]string, 0, len(ctrl.rc.Aliases))
-		for name := range ctrl.rc.Aliases {
+	if len(aliases) > 0 {
+		names := make([]string, 0, len(aliases))
+		for name := range aliases {
 			names = append(names, name)
 		}
 		sort.Strings(names)
 
 		items := make([]string, 0, len(names))
 		for _, name := range names {
-			items = append(items, fmt.Sprintf("%s → %s", name, ctrl.rc.Aliases[name]))
+			items = append(items, fmt.Sprintf("%s → %s", name, aliases[name]))
 		}
 		ctrl.printer.List("Aliases", items)
 	}
 
-	if len(localScaffolds) > 0 {
-		ctrl.printer.List("Local Scaffolds", localScaffolds)
-	}
+	local := []string{}
+	treelist := []printer.ListTree{}
+
+	for _, e := range entries {
+		text := e.Ref
+		if e.Sub != "" {
+			text = e.Sub
+		}
 
-	if len(systemScaffolds) > 0 {
-		treelist := []printer.ListTree{}
+		if summary := e.summary(); summary != "" {
+			text += styles.Subtle(summary)
+		}
 
-		for _, s := range systemScaffolds {
-			subs := make([]printer.ListTree, len(s.SubPackages))
-			for i := range s.SubPackages {
-				subs[i] = printer.ListTree{
-					Text: s.SubPackages[i],
-				}
+		switch {
+		case e.Source == "local":
+			local = append(local, text)
+		case e.Sub == "":
+			treelist = append(treelist, printer.ListTree{Text: text})
+		default:
+			if len(treelist) == 0 || treelist[len(treelist)-1].Text != e.Root {
+				treelist = append(treelist, printer.ListTree{Text: e.Root})
 			}
 
-			treelist = append(treelist, printer.ListTree{
-				Text:     s.Root,
-				Children: subs,
-			})
+			last := &treelist[len(treelist)-1]
+			last.Children = append(last.Children, printer.ListTree{Text: text})
 		}
+	}
+
+	if len(local) > 0 {
+		ctrl.printer.List("Local Scaffolds", local)
+	}
 
+	if len(treelist) > 0 {
 		ctrl.printer.ListTree("System Scaffolds", treelist)
 	}
 
@@ -88,21 +116,39 @@ func (ctrl *Controller) List(flags FlagsList) error {
 	return nil
 }
 
-func (ctrl *Controller) listJSON(localScaffolds []string, systemScaffolds []pkgs.PackageList) error {
+func (ctrl *Controller) listJSON(aliases map[string]string, entries []catalogEntry) error {
 	output := ListOutput{
-		Aliases: ctrl.rc.Aliases,
-		Local:   localScaffolds,
-		System:  make([]ListSystemOutput, len(systemScaffolds)),
+		Aliases:   aliases,
+		Local:     []string{},
+		System:    []ListSystemOutput{},
+		Scaffolds: make([]ListScaffold, len(entries)),
 	}
 
-	if output.Local == nil {
-		output.Local = []string{}
-	}
+	for i, e := range entries {
+		switch {
+		case e.Source == "local":
+			output.Local = append(output.Local, e.Ref)
+		case len(output.System) > 0 && output.System[len(output.System)-1].Root == e.Root:
+			last := &output.System[len(output.System)-1]
+			last.SubPackages = append(last.SubPackages, e.Sub)
+		default:
+			s := ListSystemOutput{Root: e.Root}
+			if e.Sub != "" {
+				s.SubPackages = []string{e.Sub}
+			}
+			output.System = append(output.System, s)
+		}
 
-	for i, s := range systemScaffolds {
-		output.System[i] = ListSystemOutput{
-			Root:        s.Root,
-			SubPackages: s.SubPackages,
+		output.Scaffolds[i] = ListScaffold{
+			Ref:         e.Ref,
+			Source:      e.Source,
+			Path:        e.Dir,
+			Name:        e.Meta.Name,
+			Description: e.Meta.Description,
+			Category:    e.Meta.Category,
+			Tags:        e.Meta.Tags,
+			Maintainers: e.Meta.Maintainers,
+			Homepage:    e.Meta.Homepage,
 		}
 	}
 
@@ -0,0 +1,14 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/hay-kot/scaffold/app/lsp"
+)
+
+// LSP runs the language server over stdin and stdout until the client exits.
+func (ctrl *Controller) LSP() error {
+	ctrl.ready()
+
+	return lsp.New(ctrl.engine, ctrl.Version).Run(os.Stdin, os.Stdout)
+}
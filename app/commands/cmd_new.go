@@ -37,9 +37,15 @@ type FlagsNew struct {
 	Preset     string
 	Snapshot   string
 	Overwrite  bool
+	OnConflict string
 	ForceApply bool
 	OutputDir  string
 	DryRun     bool
+	Verify     bool
+	Watch      bool
+	// Tags limits the scaffolds offered by the picker to those tagged with
+	// every tag.
+	Tags []string
 }
 
 // OutputFS returns a WriteFS based on the OutputDir flag.
@@ -58,17 +64,17 @@ func (ctrl *Controller) New(args []string, flags FlagsNew) error {
 			return fmt.Errorf("scaffold path is required, see 'scaffold list' for available scaffolds")
 		}
 
-		systemScaffolds, err := pkgs.ListSystem(os.DirFS(ctrl.Flags.Cache))
+		entries, err := ctrl.loadCatalog(flags.Tags)
 		if err != nil {
-			return fmt.Errorf("listing system scaffolds: %w", err)
+			return err
 		}
 
-		localScaffolds, err := ctrl.loadLocalScaffolds()
-		if err != nil {
-			return fmt.Errorf("listing local scaffolds: %w", err)
+		aliases := ctrl.rc.Aliases
+		if len(flags.Tags) > 0 {
+			aliases = nil
 		}
 
-		selected, err := scaffoldPickerPrompt(ctrl.rc.Aliases, localScaffolds, flattenSystemScaffolds(systemScaffolds), ctrl.rc.Settings.Theme)
+		selected, err := scaffoldPickerPrompt(aliases, entries, ctrl.rc.Settings.Theme)
 		if err != nil {
 			return err
 		}
@@ -86,6 +92,13 @@ func (ctrl *Controller) New(args []string, flags FlagsNew) error {
 		return fmt.Errorf("missing scaffold path")
 	}
 
+	if flags.Watch {
+		err = checkWatchOutput(flags)
+		if err != nil {
+			return err
+		}
+	}
+
 	rest := args[1:]
 	argvars, err := argparse.Parse(rest)
 	if err != nil {
@@ -136,21 +149,57 @@ func (ctrl *Controller) New(args []string, flags FlagsNew) error {
 		}
 	}
 
+	// Keep the answers of the first run to render again on changes.
+	var answers watchAnswers
+	if flags.Watch {
+		varfunc = answers.capture(varfunc)
+	}
+
 	outfs := flags.OutputFS()
+	report := &scaffold.Report{}
+
+	options := scaffold.Options{
+		NoClobber: !flags.Overwrite,
+	}
+
+	if flags.OnConflict != "" {
+		options.OnConflict, err = scaffold.ParseConflictAction(flags.OnConflict)
+		if err != nil {
+			return err
+		}
+	}
+
+	if !flags.NoPrompt {
+		options.ResolveConflict = func(c scaffold.Conflict) (scaffold.ConflictAction, bool, error) {
+			return conflictPrompt(c, ctrl.rc.Settings.Theme)
+		}
+	} else if options.OnConflict == scaffold.ConflictPrompt {
+		return fmt.Errorf("--on-conflict=prompt can't be used with --no-prompt")
+	}
 
 	err = ctrl.runscaffold(runconf{
 		scaffolddir: path,
 		noPrompt:    flags.NoPrompt,
 		varfunc:     varfunc,
 		outputfs:    outfs,
-		options: scaffold.Options{
-			NoClobber: !flags.Overwrite,
-		},
+		options:     options,
+		report:      report,
+		verify:      flags.Verify,
 	})
 	if err != nil {
 		return err
 	}
 
+	if len(report.Conflicts) > 0 && !flags.DryRun {
+		items := make([]string, len(report.Conflicts))
+		for i, c := range report.Conflicts {
+			items[i] = fmt.Sprintf("%s: %s", c.Path, c.Action)
+		}
+
+		ctrl.printer.LineBreak()
+		ctrl.printer.List("Existing Files", items)
+	}
+
 	if flags.DryRun {
 		output := DryRunOutput{
 			Files:    []DryRunFile{},
@@ -175,12 +224,20 @@ func (ctrl *Controller) New(args []string, flags FlagsNew) error {
 			output.Errors = append(output.Errors, err.Error())
 		}
 
+		for _, path := range report.DroppedEmpty {
+			output.Files = append(output.Files, DryRunFile{
+				Path:   path,
+				Action: "drop-empty",
+			})
+		}
+
 		enc := json.NewEncoder(os.Stdout)
 		enc.SetIndent("", "  ")
-		return enc.Encode(output)
-	}
-
-	if flags.Snapshot != "" {
+		err = enc.Encode(output)
+		if err != nil {
+			return err
+		}
+	} else if flags.Snapshot != "" {
 		ast, err := fsast.New(outfs)
 		if err != nil {
 			return err
@@ -203,6 +260,10 @@ func (ctrl *Controller) New(args []string, flags FlagsNew) error {
 		}
 	}
 
+	if flags.Watch {
+		return ctrl.watchNew(path, answers, flags)
+	}
+
 	return nil
 }
 
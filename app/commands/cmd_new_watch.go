@@ -0,0 +1,277 @@
+package commands
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/internal/printer"
+	"github.com/hay-kot/scaffold/internal/styles"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+// watchAnswers holds the answers and project name of the first run of a
+// watched scaffold.
+type watchAnswers struct {
+	vars map[string]any
+	name string
+}
+
+// capture wraps varfunc to record the answers it returns.
+func (w *watchAnswers) capture(varfunc func(*scaffold.Project) (map[string]any, error)) func(*scaffold.Project) (map[string]any, error) {
+	return func(p *scaffold.Project) (map[string]any, error) {
+		vars, err := varfunc(p)
+		if err != nil {
+			return nil, err
+		}
+
+		w.vars = scaffold.MergeMaps(vars)
+		w.name = p.Name
+		return vars, nil
+	}
+}
+
+// fileChange is a file that differs between two renders of a scaffold.
+type fileChange struct {
+	path   string
+	action string // added, modified or removed
+	// prev is the contents of the previous render, nil for added files.
+	prev []byte
+	data []byte
+	mode fs.FileMode
+	diff string
+}
+
+// errOutputEdited is returned by writeChange for files that were changed in
+// the output directory since they were rendered.
+var errOutputEdited = errors.New("changed in the output directory, not overwritten")
+
+// checkWatchOutput ensures watched renders written to disk go to a scratch
+// directory. Every change is written without resolving conflicts, so the
+// output directory must be empty or not exist before the first run.
+func checkWatchOutput(flags FlagsNew) error {
+	if flags.OutputDir == ":memory:" || flags.DryRun {
+		return nil
+	}
+
+	entries, err := os.ReadDir(flags.OutputDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if len(entries) > 0 {
+		return fmt.Errorf("--watch writes every change to the output directory, use an empty directory or :memory: instead of %s", flags.OutputDir)
+	}
+
+	return nil
+}
+
+// watchNew renders the scaffold again with the answers of the first run each
+// time the scaffold directory changes. Output rendered to memory is printed
+// as a diff against the previous render, otherwise changed files are written
+// to the output directory, which checkWatchOutput ensures is a scratch
+// directory. Files edited in the output directory are never overwritten.
+func (ctrl *Controller) watchNew(scaffolddir string, answers watchAnswers, flags FlagsNew) error {
+	render := func() (*rwfs.MemoryWFS, error) {
+		outfs := rwfs.NewMemoryWFS()
+
+		err := ctrl.runscaffold(runconf{
+			scaffolddir: scaffolddir,
+			noPrompt:    true,
+			varfunc: func(p *scaffold.Project) (map[string]any, error) {
+				p.Name = answers.name
+				return scaffold.MergeMaps(answers.vars), nil
+			},
+			outputfs: outfs,
+			verify:   flags.Verify,
+		})
+
+		return outfs, err
+	}
+
+	var target rwfs.WriteFS
+	if flags.OutputDir != ":memory:" && !flags.DryRun {
+		target = rwfs.NewOsWFS(flags.OutputDir)
+	}
+
+	prev, err := render()
+	if err != nil {
+		return err
+	}
+
+	ctrl.printer.LineBreak()
+	fmt.Println(styles.Subtle(fmt.Sprintf("watching %s for changes (ctrl+c to exit)", scaffolddir)))
+
+	rerender := func() {
+		ctrl.printer.LineBreak()
+		ctrl.printer.Title(fmt.Sprintf("Rendered at %s", time.Now().Format(time.TimeOnly)))
+
+		current, err := render()
+
+		var checksErr *scaffold.ChecksError
+		if err != nil && !errors.As(err, &checksErr) {
+			ctrl.printer.FatalError(err)
+			return
+		}
+
+		changes, diffErr := diffRenders(prev, current)
+		if diffErr != nil {
+			ctrl.printer.FatalError(diffErr)
+			return
+		}
+
+		prev = current
+
+		if len(changes) == 0 {
+			fmt.Println(styles.Subtle("no changes"))
+		}
+
+		items := make([]printer.StatusListItem, 0, len(changes))
+		for _, c := range changes {
+			item := printer.StatusListItem{Ok: true, Status: fmt.Sprintf("%s (%s)", c.path, c.action)}
+
+			switch {
+			case target == nil:
+			case c.action == "removed":
+				item.Status += ", not deleted from the output directory"
+			default:
+				err := writeChange(target, c)
+				if err != nil {
+					item.Ok = false
+					item.Status += ": " + err.Error()
+				}
+			}
+
+			items = append(items, item)
+		}
+
+		if len(items) > 0 {
+			ctrl.printer.StatusList("Changes", items)
+		}
+
+		if target == nil {
+			for _, c := range changes {
+				if c.diff != "" {
+					fmt.Print(c.diff)
+				}
+			}
+		}
+
+		if checksErr != nil {
+			ctrl.printer.FatalError(checksErr)
+		}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	return watch(ctx, []string{scaffolddir}, rerender)
+}
+
+// diffRenders returns the files added, modified or removed between two
+// renders, sorted by path.
+func diffRenders(prev, current fs.FS) ([]fileChange, error) {
+	before, err := regularFiles(prev)
+	if err != nil {
+		return nil, err
+	}
+
+	after, err := regularFiles(current)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := []fileChange{}
+	for path, data := range after {
+		info, err := fs.Stat(current, path)
+		if err != nil {
+			return nil, err
+		}
+
+		old, ok := before[path]
+		switch {
+		case !ok:
+			changes = append(changes, fileChange{path: path, action: "added", data: data, mode: info.Mode()})
+		case !bytes.Equal(old, data):
+			diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+				A:        difflib.SplitLines(string(old)),
+				B:        difflib.SplitLines(string(data)),
+				FromFile: "a/" + path,
+				ToFile:   "b/" + path,
+				Context:  3,
+			})
+			if err != nil {
+				return nil, err
+			}
+
+			changes = append(changes, fileChange{path: path, action: "modified", prev: old, data: data, mode: info.Mode(), diff: diff})
+		}
+	}
+
+	for path := range before {
+		if _, ok := after[path]; !ok {
+			changes = append(changes, fileChange{path: path, action: "removed"})
+		}
+	}
+
+	sort.Slice(changes, func(i, j int) bool { return changes[i].path < changes[j].path })
+	return changes, nil
+}
+
+// regularFiles returns the contents of the regular files in fsys by path.
+func regularFiles(fsys fs.FS) (map[string][]byte, error) {
+	files := map[string][]byte{}
+
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.Type().IsRegular() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		if err != nil {
+			return err
+		}
+
+		files[path] = data
+		return nil
+	})
+
+	return files, err
+}
+
+// writeChange writes an added or modified file to the output directory. It
+// returns errOutputEdited when the file in the output directory is neither
+// the previous nor the new render.
+func writeChange(target rwfs.WriteFS, c fileChange) error {
+	existing, err := fs.ReadFile(target, c.path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case !bytes.Equal(existing, c.prev) && !bytes.Equal(existing, c.data):
+		return errOutputEdited
+	}
+
+	err = target.MkdirAll(filepath.Dir(c.path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	return target.WriteFile(c.path, c.data, c.mode.Perm())
+}
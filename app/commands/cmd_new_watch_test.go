@@ -0,0 +1,131 @@
+package commands
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_regularFiles(t *testing.T) {
+	fsys := fstest.MapFS{
+		"README.md":      {Data: []byte("# app\n")},
+		"cmd/main.go":    {Data: []byte("package main\n")},
+		"docs":           {Mode: fs.ModeDir},
+		"current":        {Data: []byte("cmd"), Mode: fs.ModeSymlink},
+		"docs/.gitkeep":  {Data: []byte{}},
+		"bin/run.sh":     {Data: []byte("echo\n"), Mode: 0o755},
+		"bin/tool/empty": {Mode: fs.ModeDir},
+	}
+
+	got, err := regularFiles(fsys)
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string][]byte{
+		"README.md":     []byte("# app\n"),
+		"cmd/main.go":   []byte("package main\n"),
+		"docs/.gitkeep": {},
+		"bin/run.sh":    []byte("echo\n"),
+	}, got, "directories and links are left out")
+}
+
+func Test_diffRenders(t *testing.T) {
+	prev := fstest.MapFS{
+		"README.md":   {Data: []byte("# app\n")},
+		"cmd/main.go": {Data: []byte("package main\n")},
+		"old.txt":     {Data: []byte("old\n")},
+	}
+
+	current := fstest.MapFS{
+		"README.md":   {Data: []byte("# app\n")},
+		"cmd/main.go": {Data: []byte("package main\n\nfunc main() {}\n"), Mode: 0o644},
+		"run.sh":      {Data: []byte("echo\n"), Mode: 0o755},
+	}
+
+	changes, err := diffRenders(prev, current)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+
+	modified := changes[0]
+	assert.Equal(t, "cmd/main.go", modified.path)
+	assert.Equal(t, "modified", modified.action)
+	assert.Equal(t, []byte("package main\n"), modified.prev)
+	assert.Equal(t, []byte("package main\n\nfunc main() {}\n"), modified.data)
+	assert.Equal(t, `--- a/cmd/main.go
++++ b/cmd/main.go
+@@ -1,2 +1,4 @@
+ package main
+ 
++func main() {}
++
+`, modified.diff)
+
+	assert.Equal(t, fileChange{path: "old.txt", action: "removed"}, changes[1])
+	assert.Equal(t, fileChange{path: "run.sh", action: "added", data: []byte("echo\n"), mode: 0o755}, changes[2])
+
+	changes, err = diffRenders(current, current)
+	require.NoError(t, err)
+	assert.Empty(t, changes)
+}
+
+func Test_writeChange(t *testing.T) {
+	target := rwfs.NewMemoryWFS()
+	require.NoError(t, target.WriteFile("rendered.go", []byte("v1"), 0o644))
+	require.NoError(t, target.WriteFile("edited.go", []byte("edited"), 0o644))
+
+	tests := []struct {
+		name    string
+		change  fileChange
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "added",
+			change: fileChange{path: "cmd/new.go", action: "added", data: []byte("new"), mode: 0o644},
+			want:   "new",
+		},
+		{
+			name:   "modified",
+			change: fileChange{path: "rendered.go", action: "modified", prev: []byte("v1"), data: []byte("v2"), mode: 0o644},
+			want:   "v2",
+		},
+		{
+			name:    "edited in the output directory",
+			change:  fileChange{path: "edited.go", action: "modified", prev: []byte("v1"), data: []byte("v2"), mode: 0o644},
+			want:    "edited",
+			wantErr: errOutputEdited,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := writeChange(target, tt.change)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			data, err := fs.ReadFile(target, tt.change.path)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(data))
+		})
+	}
+}
+
+func Test_checkWatchOutput(t *testing.T) {
+	dir := t.TempDir()
+
+	require.NoError(t, checkWatchOutput(FlagsNew{OutputDir: dir}), "empty directory")
+	require.NoError(t, checkWatchOutput(FlagsNew{OutputDir: filepath.Join(dir, "missing")}), "missing directory")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module x\n"), 0o644))
+	require.Error(t, checkWatchOutput(FlagsNew{OutputDir: dir}), "directory with files")
+	require.NoError(t, checkWatchOutput(FlagsNew{OutputDir: dir, DryRun: true}), "dry run")
+	require.NoError(t, checkWatchOutput(FlagsNew{OutputDir: ":memory:"}), "memory")
+}
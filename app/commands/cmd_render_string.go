@@ -0,0 +1,192 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/hay-kot/scaffold/app/argparse"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/internal/styles"
+)
+
+type FlagsRenderString struct {
+	File     string
+	VarsFile string
+	Scaffold string
+	Preset   string
+	Watch    bool
+}
+
+// RenderString renders a template string, or the template file, with
+// variables from a scaffold preset, a JSON file and key=value arguments, in
+// that order of precedence.
+func (ctrl *Controller) RenderString(ctx context.Context, args []string, flags FlagsRenderString) error {
+	ctrl.ready()
+
+	var tmpl string
+	if flags.File == "" {
+		if len(args) == 0 {
+			return errors.New("template string or --file is required")
+		}
+
+		tmpl, args = args[0], args[1:]
+	}
+
+	argvars, err := argparse.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	if flags.Preset != "" && flags.Scaffold == "" {
+		return errors.New("--preset requires --scaffold")
+	}
+
+	var scaffolddir string
+	if flags.Scaffold != "" {
+		scaffolddir, err = ctrl.resolve(flags.Scaffold, ".", true, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	render := func() (string, error) {
+		name := "<string>"
+		if flags.File != "" {
+			data, err := os.ReadFile(flags.File)
+			if err != nil {
+				return "", err
+			}
+
+			name, tmpl = flags.File, string(data)
+		}
+
+		p, vars, err := ctrl.playgroundVars(scaffolddir, flags, argvars)
+		if err != nil {
+			return "", err
+		}
+
+		built, err := scaffold.BuildVars(ctrl.engine, p, vars)
+		if err != nil {
+			return "", err
+		}
+
+		return scaffold.RenderString(ctrl.engine, name, tmpl, built)
+	}
+
+	if !flags.Watch {
+		out, err := render()
+		if err != nil {
+			return err
+		}
+
+		fmt.Print(out)
+		return nil
+	}
+
+	paths := []string{}
+	for _, p := range []string{flags.File, flags.VarsFile, scaffolddir} {
+		if p != "" {
+			paths = append(paths, p)
+		}
+	}
+
+	if len(paths) == 0 {
+		return errors.New("--watch requires --file, --vars or --scaffold")
+	}
+
+	rerender := func() {
+		// Clear the screen so only the latest output is shown.
+		fmt.Print("\033[H\033[2J")
+
+		out, err := render()
+		if err != nil {
+			ctrl.printer.FatalError(err)
+		} else {
+			fmt.Print(out)
+		}
+
+		fmt.Fprintln(os.Stderr)
+		fmt.Fprintln(os.Stderr, styles.Subtle(fmt.Sprintf("rendered at %s, watching for changes (ctrl+c to exit)", time.Now().Format(time.TimeOnly))))
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt)
+	defer stop()
+
+	rerender()
+	return watch(ctx, paths, rerender)
+}
+
+// playgroundVars returns the project and the scaffold variables templates are
+// rendered with. Variables of the preset are overridden by the vars file, and
+// both by argvars. A Project variable sets the project name and is removed
+// from the scaffold variables, BuildVars exposes it as .Project. The project
+// is loaded from scaffolddir when set, registering its partials, otherwise an
+// empty project is used.
+func (ctrl *Controller) playgroundVars(scaffolddir string, flags FlagsRenderString, argvars map[string]any) (*scaffold.Project, map[string]any, error) {
+	p := &scaffold.Project{
+		Name: scaffold.DefaultTestProject,
+		Conf: &scaffold.ProjectScaffoldFile{},
+	}
+
+	vars := map[string]any{}
+
+	if scaffolddir != "" {
+		scaffoldFS := os.DirFS(scaffolddir)
+
+		loaded, err := scaffold.LoadProject(scaffoldFS, scaffold.Options{})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		loaded.Name = p.Name
+		p = loaded
+
+		if flags.Preset != "" {
+			preset, ok := p.Conf.Presets[flags.Preset]
+			if !ok {
+				return nil, nil, fmt.Errorf("preset '%s' not found", flags.Preset)
+			}
+
+			vars = scaffold.MergeMaps(vars, preset)
+		}
+
+		partialsFS, err := fs.Sub(scaffoldFS, "partials")
+		if err != nil {
+			return nil, nil, err
+		}
+
+		err = ctrl.engine.RegisterPartialsFS(partialsFS, ".")
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return nil, nil, err
+		}
+	}
+
+	if flags.VarsFile != "" {
+		data, err := os.ReadFile(flags.VarsFile)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var filevars map[string]any
+		err = json.Unmarshal(data, &filevars)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid vars file %s: %w", flags.VarsFile, err)
+		}
+
+		vars = scaffold.MergeMaps(vars, filevars)
+	}
+
+	vars = scaffold.MergeMaps(vars, argvars)
+	if project, ok := vars["Project"].(string); ok && project != "" {
+		p.Name = project
+	}
+	delete(vars, "Project")
+
+	return p, vars, nil
+}
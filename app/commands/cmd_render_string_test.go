@@ -0,0 +1,94 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/app/scaffold/scaffoldrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_playgroundVars(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "{{ .Project }}"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "scaffold.yaml"), []byte(`presets:
+  default:
+    Project: preset-app
+    a: preset
+    b: preset
+    c: preset
+`), 0o644))
+
+	varsFile := filepath.Join(dir, "vars.json")
+	require.NoError(t, os.WriteFile(varsFile, []byte(`{"b": "file", "c": "file", "Project": "file-app"}`), 0o644))
+
+	ctrl := &Controller{}
+	ctrl.Prepare(engine.New(), scaffoldrc.Default())
+
+	tests := []struct {
+		name     string
+		flags    FlagsRenderString
+		argvars  map[string]any
+		wantName string
+		want     map[string]any
+	}{
+		{
+			name:     "no scaffold",
+			flags:    FlagsRenderString{},
+			wantName: scaffold.DefaultTestProject,
+			want:     map[string]any{},
+		},
+		{
+			name:     "preset",
+			flags:    FlagsRenderString{Preset: "default"},
+			wantName: "preset-app",
+			want:     map[string]any{"a": "preset", "b": "preset", "c": "preset"},
+		},
+		{
+			name:     "vars file overrides preset",
+			flags:    FlagsRenderString{Preset: "default", VarsFile: varsFile},
+			wantName: "file-app",
+			want:     map[string]any{"a": "preset", "b": "file", "c": "file"},
+		},
+		{
+			name:     "arguments override vars file",
+			flags:    FlagsRenderString{Preset: "default", VarsFile: varsFile},
+			argvars:  map[string]any{"c": "arg", "Project": "arg-app"},
+			wantName: "arg-app",
+			want:     map[string]any{"a": "preset", "b": "file", "c": "arg"},
+		},
+		{
+			name:     "empty project uses the default name",
+			flags:    FlagsRenderString{Preset: "default"},
+			argvars:  map[string]any{"Project": ""},
+			wantName: scaffold.DefaultTestProject,
+			want:     map[string]any{"a": "preset", "b": "preset", "c": "preset"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scaffolddir := dir
+			if tt.flags.Preset == "" {
+				scaffolddir = ""
+			}
+
+			p, vars, err := ctrl.playgroundVars(scaffolddir, tt.flags, tt.argvars)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantName, p.Name)
+			assert.Equal(t, tt.want, vars, "Project is only available as .Project")
+
+			built, err := scaffold.BuildVars(ctrl.engine, p, vars)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantName, built["Project"])
+		})
+	}
+
+	_, _, err := ctrl.playgroundVars(dir, FlagsRenderString{Preset: "missing"}, nil)
+	require.Error(t, err)
+}
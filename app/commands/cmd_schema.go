@@ -1,38 +1,109 @@
 package commands
 
 import (
-	"context"
+	"bytes"
 	_ "embed"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"regexp"
 
-	"github.com/urfave/cli/v3"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/internal/styles"
 )
 
-//go:embed schema/scaffold.schema.json
-var scaffoldSchema []byte
-
 //go:embed schema/scaffoldrc.schema.json
 var scaffoldrcSchema []byte
 
+// ErrSchemaValidation is returned by Schema when the validated file doesn't
+// match the schema.
+var ErrSchemaValidation = errors.New("scaffold file doesn't match the schema")
+
 // FlagsSchema contains flags for the schema command
 type FlagsSchema struct {
 	Type string // "scaffold" or "scaffoldrc"
+	// Validate is the path of a scaffold file to validate against the schema
+	// instead of printing it.
+	Validate string
 }
 
-func (ctrl *Controller) Schema(_ context.Context, c *cli.Command) error {
-	schemaType := c.String("type")
+func (ctrl *Controller) Schema(flags FlagsSchema) error {
+	if flags.Validate != "" {
+		if flags.Type != "scaffold" {
+			return fmt.Errorf("--validate only supports the scaffold schema")
+		}
+
+		return ctrl.validateSchema(flags.Validate)
+	}
 
 	var schema []byte
-	switch schemaType {
+	switch flags.Type {
 	case "scaffold":
-		schema = scaffoldSchema
+		s, err := scaffold.JSONSchema(ctrl.Version)
+		if err != nil {
+			return err
+		}
+
+		data, err := json.MarshalIndent(s, "", "  ")
+		if err != nil {
+			return err
+		}
+		schema = append(data, '\n')
 	case "scaffoldrc":
 		schema = scaffoldrcSchema
 	default:
-		return fmt.Errorf("unknown schema type: %s (use 'scaffold' or 'scaffoldrc')", schemaType)
+		return fmt.Errorf("unknown schema type: %s (use 'scaffold' or 'scaffoldrc')", flags.Type)
 	}
 
 	_, err := os.Stdout.Write(schema)
 	return err
 }
+
+// yamlErrorLine matches the line number of YAML syntax errors.
+var yamlErrorLine = regexp.MustCompile(`^yaml: line (\d+): (.*)$`)
+
+// validateSchema validates the scaffold file at path against the schema of
+// this version and prints the errors as path:line:column: message.
+func (ctrl *Controller) validateSchema(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	s, err := scaffold.JSONSchema(ctrl.Version)
+	if err != nil {
+		return err
+	}
+
+	errs, err := s.ValidateYAML(data)
+	if err != nil {
+		if m := yamlErrorLine.FindStringSubmatch(err.Error()); m != nil {
+			fmt.Printf("%s:%s: %s\n", path, m[1], m[2])
+			return ErrSchemaValidation
+		}
+		return fmt.Errorf("%s: %w", path, err)
+	}
+
+	for _, e := range errs {
+		fmt.Printf("%s:%s\n", path, e.Error())
+	}
+
+	// Keys added after this version are reported as unknown, point at the
+	// versions and capabilities the scaffold asks for.
+	if len(errs) > 0 {
+		conf, err := scaffold.ReadScaffoldFile(bytes.NewReader(data))
+		if err == nil {
+			var cerr *scaffold.CompatibilityError
+			if errors.As(conf.Metadata.CheckCompatibility(ctrl.Version), &cerr) {
+				for _, r := range cerr.Reasons {
+					fmt.Fprintln(os.Stderr, styles.Subtle(fmt.Sprintf("the scaffold %s, validated against %s", r, ctrl.Version)))
+				}
+			}
+		}
+
+		return ErrSchemaValidation
+	}
+
+	return nil
+}
@@ -0,0 +1,322 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/fsast"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/internal/printer"
+	"github.com/pmezard/go-difflib/difflib"
+)
+
+type FlagsTest struct {
+	// Update writes the snapshots instead of comparing them.
+	Update bool
+	// Run limits the test cases to the given names.
+	Run []string
+	// Matrix renders combinations of the confirm and select answers instead
+	// of the test cases.
+	Matrix bool
+	// Max is the maximum number of matrix combinations.
+	Max int
+	// Seed seeds the sampling of matrix combinations.
+	Seed int64
+}
+
+// testResult is the outcome of a single test case.
+type testResult struct {
+	name    string
+	status  string
+	failure string
+}
+
+// Test renders every test case of a scaffold into memory and compares the
+// output with the committed snapshots.
+func (ctrl *Controller) Test(args []string, flags FlagsTest) error {
+	ctrl.ready()
+
+	arg := "."
+	if len(args) > 0 {
+		arg = args[0]
+	}
+
+	// Tests are usually run from inside the scaffold, local directories are
+	// used as is.
+	path := arg
+	if info, err := os.Stat(arg); err != nil || !info.IsDir() {
+		path, err = ctrl.resolve(arg, ".", true, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	p, err := scaffold.LoadProject(rwfs.NewOsRFS(path), scaffold.Options{})
+	if err != nil {
+		return err
+	}
+
+	if flags.Matrix {
+		return ctrl.testMatrix(path, p.Conf, flags)
+	}
+
+	cases, err := scaffold.LoadTestCases(os.DirFS(path), p.Conf)
+	if err != nil {
+		return err
+	}
+
+	if len(flags.Run) > 0 {
+		cases = filterTestCases(cases, flags.Run)
+	}
+
+	if len(cases) == 0 {
+		return fmt.Errorf("no test cases found, add presets to the scaffold or files to %s/", scaffold.TestsDir)
+	}
+
+	results := make([]testResult, 0, len(cases))
+	failed := 0
+	for _, tc := range cases {
+		res := ctrl.runTestCase(path, tc, flags.Update)
+		if res.failure != "" {
+			failed++
+		}
+		results = append(results, res)
+	}
+
+	items := make([]printer.StatusListItem, len(results))
+	for i, res := range results {
+		items[i] = printer.StatusListItem{
+			Ok:     res.failure == "",
+			Status: res.name + ": " + res.status,
+		}
+	}
+
+	ctrl.printer.LineBreak()
+	ctrl.printer.StatusList("Test Cases", items)
+
+	for _, res := range results {
+		if res.failure == "" {
+			continue
+		}
+
+		ctrl.printer.LineBreak()
+		ctrl.printer.Title(res.name)
+		fmt.Println(strings.TrimRight(res.failure, "\n"))
+	}
+
+	ctrl.printer.LineBreak()
+
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test cases failed", failed, len(results))
+	}
+
+	return nil
+}
+
+// runTestCase renders the test case and compares, or updates, its snapshot.
+func (ctrl *Controller) runTestCase(scaffolddir string, tc scaffold.TestCase, update bool) (res testResult) {
+	res.name = tc.Name
+
+	got, err := ctrl.renderSnapshot(scaffolddir, tc)
+
+	var checksErr *scaffold.ChecksError
+	if err != nil && !errors.As(err, &checksErr) {
+		res.status = "render failed"
+		res.failure = err.Error()
+		return res
+	}
+
+	// Check failures are reported with the result of the snapshot.
+	defer func() {
+		if checksErr != nil {
+			if res.status == "ok" {
+				res.status = "checks failed"
+			} else {
+				res.status = "checks failed, " + res.status
+			}
+			res.failure = strings.TrimLeft(res.failure+"\n"+checksErr.Error(), "\n")
+		}
+	}()
+
+	snapshot := filepath.Join(scaffolddir, filepath.FromSlash(tc.Snapshot))
+
+	if update {
+		err := os.MkdirAll(filepath.Dir(snapshot), 0o755)
+		if err == nil {
+			err = os.WriteFile(snapshot, []byte(got), 0o644)
+		}
+
+		if err != nil {
+			res.status = "update failed"
+			res.failure = err.Error()
+			return res
+		}
+
+		res.status = "updated"
+		return res
+	}
+
+	want, err := os.ReadFile(snapshot)
+	if err != nil {
+		res.status = "missing snapshot"
+		if errors.Is(err, os.ErrNotExist) {
+			res.failure = fmt.Sprintf("%s does not exist, run with --update to create it", tc.Snapshot)
+		} else {
+			res.failure = err.Error()
+		}
+		return res
+	}
+
+	if string(want) == got {
+		res.status = "ok"
+		return res
+	}
+
+	diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+		A:        difflib.SplitLines(string(want)),
+		B:        difflib.SplitLines(got),
+		FromFile: tc.Snapshot,
+		ToFile:   "rendered",
+		Context:  3,
+	})
+	if err != nil {
+		diff = err.Error()
+	}
+
+	res.status = "snapshot mismatch"
+	res.failure = diff
+	return res
+}
+
+// renderSnapshot renders the test case into memory, runs the scaffold's checks
+// and returns the snapshot of the output. The snapshot is returned with a
+// *scaffold.ChecksError when checks fail.
+func (ctrl *Controller) renderSnapshot(scaffolddir string, tc scaffold.TestCase) (string, error) {
+	outfs := rwfs.NewMemoryWFS()
+
+	err := ctrl.runscaffold(runconf{
+		scaffolddir: scaffolddir,
+		noPrompt:    true,
+		varfunc: func(p *scaffold.Project) (map[string]any, error) {
+			vars := scaffold.MergeMaps(tc.Vars)
+			p.Name = fmt.Sprint(vars["Project"])
+			return vars, nil
+		},
+		outputfs: outfs,
+		verify:   true,
+	})
+
+	var checksErr *scaffold.ChecksError
+	if err != nil && !errors.As(err, &checksErr) {
+		return "", err
+	}
+
+	ast, astErr := fsast.New(outfs)
+	if astErr != nil {
+		return "", astErr
+	}
+
+	return ast.String(), err
+}
+
+// testMatrix renders combinations of the scaffold's confirm and select answers
+// and reports every distinct failure with the smallest answer set that
+// reproduces it.
+func (ctrl *Controller) testMatrix(scaffolddir string, conf *scaffold.ProjectScaffoldFile, flags FlagsTest) error {
+	matrix := scaffold.NewMatrix(ctrl.engine, conf)
+	combos := matrix.Combinations(scaffold.MatrixOptions{Max: flags.Max, Seed: flags.Seed})
+
+	render := func(combo []int) string {
+		err := ctrl.renderMatrix(scaffolddir, matrix.Vars(combo))
+		if err != nil {
+			return err.Error()
+		}
+		return ""
+	}
+
+	errs := make([]string, len(combos))
+	for i, combo := range combos {
+		errs[i] = render(combo)
+	}
+
+	failures := scaffold.GroupMatrixFailures(combos, errs)
+	for i, f := range failures {
+		failures[i].Minimal = matrix.Minimize(f.Minimal, func(combo []int) bool {
+			return render(combo) == f.Err
+		})
+	}
+
+	summary := fmt.Sprintf("%d combinations", len(combos))
+	if total := matrix.Total(len(combos)); total > len(combos) {
+		summary = fmt.Sprintf("%d sampled combinations (seed %d)", len(combos), flags.Seed)
+	}
+
+	items := []printer.StatusListItem{{Ok: len(failures) == 0, Status: summary}}
+	for _, f := range failures {
+		items = append(items, printer.StatusListItem{
+			Ok:     false,
+			Status: fmt.Sprintf("%s: failed in %d combination(s)", matrix.Describe(f.Minimal), f.Count),
+		})
+	}
+
+	ctrl.printer.LineBreak()
+	ctrl.printer.StatusList("Matrix", items)
+
+	for _, f := range failures {
+		ctrl.printer.LineBreak()
+		ctrl.printer.Title(matrix.Describe(f.Minimal))
+		fmt.Println(strings.TrimRight(f.Err, "\n"))
+	}
+
+	ctrl.printer.LineBreak()
+
+	if len(failures) > 0 {
+		failed := 0
+		for _, f := range failures {
+			failed += f.Count
+		}
+		return fmt.Errorf("%d of %d combinations failed", failed, len(combos))
+	}
+
+	return nil
+}
+
+// renderMatrix renders a matrix combination into memory and runs the
+// scaffold's checks. Panics in templates or functions are returned as errors
+// so a single combination can't abort the run.
+func (ctrl *Controller) renderMatrix(scaffolddir string, vars map[string]any) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return ctrl.runscaffold(runconf{
+		scaffolddir: scaffolddir,
+		noPrompt:    true,
+		varfunc: func(p *scaffold.Project) (map[string]any, error) {
+			p.Name = fmt.Sprint(vars["Project"])
+			return vars, nil
+		},
+		outputfs: rwfs.NewMemoryWFS(),
+		verify:   true,
+	})
+}
+
+func filterTestCases(cases []scaffold.TestCase, names []string) []scaffold.TestCase {
+	out := make([]scaffold.TestCase, 0, len(names))
+	for _, tc := range cases {
+		for _, name := range names {
+			if strings.EqualFold(tc.Name, name) {
+				out = append(out, tc)
+				break
+			}
+		}
+	}
+
+	return out
+}
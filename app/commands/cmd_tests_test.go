@@ -0,0 +1,128 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/app/scaffold/scaffoldrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// testFixture creates a scaffold with a README template and a check that
+// fails when the broken answer is set.
+func testFixture(t *testing.T) (ctrl *Controller, dir string) {
+	t.Helper()
+
+	dir = t.TempDir()
+
+	files := map[string]string{
+		"scaffold.yaml": `questions:
+  - name: title
+    prompt:
+      message: Title
+checks:
+  - name: no readme when broken
+    when: .Scaffold.broken
+    not_exists: ["{{ .Project }}/README.md"]
+`,
+		"{{ .Project }}/README.md": "# {{ .Scaffold.title }}\n",
+	}
+
+	for name, data := range files {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o644))
+	}
+
+	ctrl = &Controller{}
+	ctrl.Prepare(engine.New(), scaffoldrc.Default())
+
+	return ctrl, dir
+}
+
+func testCase(name string, vars map[string]any) scaffold.TestCase {
+	vars["Project"] = "app"
+	return scaffold.TestCase{
+		Name:     name,
+		Vars:     vars,
+		Snapshot: "tests/snapshots/" + name + ".snapshot",
+	}
+}
+
+func TestController_runTestCase(t *testing.T) {
+	ctrl, dir := testFixture(t)
+	tc := testCase("default", map[string]any{"title": "Hello"})
+	snapshot := filepath.Join(dir, "tests", "snapshots", "default.snapshot")
+
+	// Without a snapshot the case fails and points at --update.
+	res := ctrl.runTestCase(dir, tc, false)
+	assert.Equal(t, "missing snapshot", res.status)
+	assert.Equal(t, "tests/snapshots/default.snapshot does not exist, run with --update to create it", res.failure)
+
+	// --update writes the rendered snapshot.
+	res = ctrl.runTestCase(dir, tc, true)
+	assert.Equal(t, "updated", res.status)
+	assert.Empty(t, res.failure)
+
+	want, err := ctrl.renderSnapshot(dir, tc)
+	require.NoError(t, err)
+	assert.Contains(t, want, "README.md")
+
+	got, err := os.ReadFile(snapshot)
+	require.NoError(t, err)
+	assert.Equal(t, want, string(got))
+
+	res = ctrl.runTestCase(dir, tc, false)
+	assert.Equal(t, "ok", res.status)
+	assert.Empty(t, res.failure)
+
+	// A changed template is reported as a diff against the snapshot.
+	readme := filepath.Join(dir, "{{ .Project }}", "README.md")
+	require.NoError(t, os.WriteFile(readme, []byte("# {{ .Scaffold.title }}\n\nchanged\n"), 0o644))
+
+	res = ctrl.runTestCase(dir, tc, false)
+	assert.Equal(t, "snapshot mismatch", res.status)
+	assert.Contains(t, res.failure, "--- tests/snapshots/default.snapshot\n+++ rendered\n")
+	assert.Contains(t, res.failure, "+\t\tchanged\n")
+}
+
+func TestController_runTestCase_ChecksFailed(t *testing.T) {
+	ctrl, dir := testFixture(t)
+	tc := testCase("broken", map[string]any{"title": "Hello", "broken": true})
+
+	// Failing checks don't stop the snapshot from being written.
+	res := ctrl.runTestCase(dir, tc, true)
+	assert.Equal(t, "checks failed, updated", res.status)
+	assert.Contains(t, res.failure, "no readme when broken")
+	assert.FileExists(t, filepath.Join(dir, "tests", "snapshots", "broken.snapshot"))
+
+	res = ctrl.runTestCase(dir, tc, false)
+	assert.Equal(t, "checks failed", res.status)
+	assert.Contains(t, res.failure, "no readme when broken")
+
+	tc.Vars["title"] = "Changed"
+	res = ctrl.runTestCase(dir, tc, false)
+	assert.Equal(t, "checks failed, snapshot mismatch", res.status)
+	assert.Contains(t, res.failure, "-\t\t# Hello\n+\t\t# Changed\n")
+	assert.Contains(t, res.failure, "no readme when broken")
+}
+
+func Test_filterTestCases(t *testing.T) {
+	cases := []scaffold.TestCase{{Name: "default"}, {Name: "Docker"}, {Name: "minimal"}}
+
+	names := func(cases []scaffold.TestCase) []string {
+		out := []string{}
+		for _, tc := range cases {
+			out = append(out, tc.Name)
+		}
+		return out
+	}
+
+	assert.Equal(t, []string{"default", "Docker"}, names(filterTestCases(cases, []string{"docker", "DEFAULT"})), "names match case-insensitively, in case order")
+	assert.Equal(t, []string{"minimal"}, names(filterTestCases(cases, []string{"minimal", "minimal"})))
+	assert.Empty(t, filterTestCases(cases, []string{"missing"}))
+}
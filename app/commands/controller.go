@@ -3,15 +3,12 @@ package commands
 
 import (
 	"bytes"
-	"fmt"
 	"os"
 
 	"github.com/hay-kot/scaffold/app/core/engine"
-	"github.com/hay-kot/scaffold/app/scaffold/pkgs"
 	"github.com/hay-kot/scaffold/app/scaffold/scaffoldrc"
 	"github.com/hay-kot/scaffold/internal/printer"
 	"github.com/hay-kot/scaffold/internal/styles"
-	"github.com/rs/zerolog/log"
 	"gopkg.in/yaml.v3"
 )
 
@@ -60,33 +57,17 @@ func (ctrl *Controller) RuntimeConfigYAML() (string, error) {
 	return buff.String(), nil
 }
 
-// loadLocalScaffolds loads scaffolds from all configured scaffold directories.
-// Missing directories and empty directories are silently skipped (debug logged)
-// since the default .scaffold directory won't exist until `scaffold init` is run.
+// loadLocalScaffolds returns the names of the scaffolds in all configured
+// scaffold directories, see loadLocalCatalog.
 func (ctrl *Controller) loadLocalScaffolds() ([]string, error) {
-	localScaffolds := []string{}
-
-	for _, dir := range ctrl.Flags.ScaffoldDirs {
-		_, err := os.Stat(dir)
-		if os.IsNotExist(err) {
-			log.Debug().Str("dir", dir).Msg("scaffold directory not found, skipping")
-			continue
-		}
-		if err != nil {
-			return nil, fmt.Errorf("failed to check directory %s: %w", dir, err)
-		}
-
-		scaffolds, err := pkgs.ListFromFS(os.DirFS(dir))
-		if err != nil {
-			return nil, fmt.Errorf("failed to list scaffolds from %s: %w", dir, err)
-		}
-
-		if len(scaffolds) == 0 {
-			log.Debug().Str("dir", dir).Msg("no scaffolds found in directory, skipping")
-			continue
-		}
+	entries, err := ctrl.loadLocalCatalog()
+	if err != nil {
+		return nil, err
+	}
 
-		localScaffolds = append(localScaffolds, scaffolds...)
+	localScaffolds := make([]string, len(entries))
+	for i, e := range entries {
+		localScaffolds[i] = e.Ref
 	}
 
 	return localScaffolds, nil
@@ -0,0 +1,190 @@
+package commands
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/internal/printer"
+)
+
+// Lint output formats.
+const (
+	LintFormatText   = "text"
+	LintFormatJSON   = "json"
+	LintFormatSARIF  = "sarif"
+	LintFormatGitHub = "github"
+)
+
+type lintWriteFunc func(ctrl *Controller, w io.Writer, diags []scaffold.Diagnostic) error
+
+func lintWriter(format string) (lintWriteFunc, error) {
+	switch format {
+	case "", LintFormatText:
+		return writeLintText, nil
+	case LintFormatJSON:
+		return writeLintJSON, nil
+	case LintFormatSARIF:
+		return writeLintSARIF, nil
+	case LintFormatGitHub:
+		return writeLintGitHub, nil
+	default:
+		return nil, fmt.Errorf("unknown lint format %q: must be one of text, json, sarif, github", format)
+	}
+}
+
+// writeLintText prints the diagnostics as status lists, errors first.
+func writeLintText(ctrl *Controller, _ io.Writer, diags []scaffold.Diagnostic) error {
+	var errs, warnings []printer.StatusListItem
+
+	for _, d := range diags {
+		item := printer.StatusListItem{Ok: false, Status: fmt.Sprintf("%s (%s)", d, d.Rule)}
+		if d.Severity == scaffold.SeverityWarning {
+			warnings = append(warnings, item)
+		} else {
+			errs = append(errs, item)
+		}
+	}
+
+	if len(errs) > 0 {
+		ctrl.printer.StatusList("Scaffold Errors", errs)
+	}
+
+	if len(warnings) > 0 {
+		ctrl.printer.StatusList("Scaffold Warnings", warnings)
+	}
+
+	return nil
+}
+
+func writeLintJSON(_ *Controller, w io.Writer, diags []scaffold.Diagnostic) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(diags)
+}
+
+// writeLintGitHub writes GitHub Actions workflow commands, which annotate the
+// diagnostics in pull requests.
+func writeLintGitHub(_ *Controller, w io.Writer, diags []scaffold.Diagnostic) error {
+	for _, d := range diags {
+		props := []string{"file=" + escapeGitHubProperty(d.File)}
+		if d.Line > 0 {
+			props = append(props, fmt.Sprintf("line=%d", d.Line))
+		}
+		if d.Column > 0 {
+			props = append(props, fmt.Sprintf("col=%d", d.Column))
+		}
+		props = append(props, "title="+escapeGitHubProperty("scaffold lint ("+d.Rule+")"))
+
+		_, err := fmt.Fprintf(w, "::%s %s::%s\n", d.Severity, strings.Join(props, ","), escapeGitHubData(d.Message))
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func escapeGitHubData(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A").Replace(s)
+}
+
+func escapeGitHubProperty(s string) string {
+	return strings.NewReplacer("%", "%25", "\r", "%0D", "\n", "%0A", ":", "%3A", ",", "%2C").Replace(s)
+}
+
+// sarifLog is the subset of SARIF 2.1.0 written by lint.
+type sarifLog struct {
+	Schema  string     `json:"$schema"`
+	Version string     `json:"version"`
+	Runs    []sarifRun `json:"runs"`
+}
+
+type sarifRun struct {
+	Tool    sarifTool     `json:"tool"`
+	Results []sarifResult `json:"results"`
+}
+
+type sarifTool struct {
+	Driver sarifDriver `json:"driver"`
+}
+
+type sarifDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version,omitempty"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []sarifRule `json:"rules"`
+}
+
+type sarifRule struct {
+	ID                   string       `json:"id"`
+	ShortDescription     sarifMessage `json:"shortDescription"`
+	DefaultConfiguration struct {
+		Level string `json:"level"`
+	} `json:"defaultConfiguration"`
+}
+
+type sarifMessage struct {
+	Text string `json:"text"`
+}
+
+type sarifResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   sarifMessage    `json:"message"`
+	Locations []sarifLocation `json:"locations"`
+}
+
+type sarifLocation struct {
+	PhysicalLocation struct {
+		ArtifactLocation struct {
+			URI string `json:"uri"`
+		} `json:"artifactLocation"`
+		Region *sarifRegion `json:"region,omitempty"`
+	} `json:"physicalLocation"`
+}
+
+type sarifRegion struct {
+	StartLine   int `json:"startLine"`
+	StartColumn int `json:"startColumn,omitempty"`
+}
+
+func writeLintSARIF(ctrl *Controller, w io.Writer, diags []scaffold.Diagnostic) error {
+	driver := sarifDriver{
+		Name:           "scaffold",
+		Version:        ctrl.Version,
+		InformationURI: "https://github.com/hay-kot/scaffold",
+	}
+
+	for _, r := range scaffold.LintRules {
+		rule := sarifRule{ID: r.ID, ShortDescription: sarifMessage{Text: r.Description}}
+		rule.DefaultConfiguration.Level = string(r.Severity)
+		driver.Rules = append(driver.Rules, rule)
+	}
+
+	results := make([]sarifResult, 0, len(diags))
+	for _, d := range diags {
+		var loc sarifLocation
+		loc.PhysicalLocation.ArtifactLocation.URI = d.File
+		if d.Line > 0 {
+			loc.PhysicalLocation.Region = &sarifRegion{StartLine: d.Line, StartColumn: d.Column}
+		}
+
+		results = append(results, sarifResult{
+			RuleID:    d.Rule,
+			Level:     string(d.Severity),
+			Message:   sarifMessage{Text: d.Message},
+			Locations: []sarifLocation{loc},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []sarifRun{{Tool: sarifTool{Driver: driver}, Results: results}},
+	})
+}
@@ -0,0 +1,168 @@
+package commands
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lintFormatDiags are a positioned error and a warning without a position.
+var lintFormatDiags = []scaffold.Diagnostic{
+	scaffold.NewDiagnostic(scaffold.RuleParseError, "templates/a.txt", "unclosed action").At(scaffold.Position{Line: 3, Column: 7}),
+	scaffold.NewDiagnostic(scaffold.RuleUnusedQuestion, "scaffold.yaml", `question "name" is never used`),
+}
+
+func TestLintWriter(t *testing.T) {
+	for _, format := range []string{"", LintFormatText, LintFormatJSON, LintFormatSARIF, LintFormatGitHub} {
+		_, err := lintWriter(format)
+		require.NoError(t, err, format)
+	}
+
+	_, err := lintWriter("xml")
+	require.Error(t, err)
+}
+
+func TestWriteLintJSON(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeLintJSON(nil, &buf, lintFormatDiags))
+
+	assert.JSONEq(t, `[
+  {"file": "templates/a.txt", "line": 3, "column": 7, "rule": "parse-error", "severity": "error", "message": "unclosed action"},
+  {"file": "scaffold.yaml", "rule": "unused-question", "severity": "warning", "message": "question \"name\" is never used"}
+]`, buf.String())
+
+	buf.Reset()
+	require.NoError(t, writeLintJSON(nil, &buf, []scaffold.Diagnostic{}))
+	assert.JSONEq(t, `[]`, buf.String())
+}
+
+func TestWriteLintSARIF(t *testing.T) {
+	var buf bytes.Buffer
+	require.NoError(t, writeLintSARIF(&Controller{Version: "1.2.3"}, &buf, lintFormatDiags))
+
+	var log map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &log))
+
+	assert.Equal(t, "2.1.0", log["version"])
+	assert.Equal(t, "https://json.schemastore.org/sarif-2.1.0.json", log["$schema"])
+
+	runs := log["runs"].([]any)
+	require.Len(t, runs, 1)
+	run := runs[0].(map[string]any)
+
+	driver := run["tool"].(map[string]any)["driver"].(map[string]any)
+	assert.Equal(t, "scaffold", driver["name"])
+	assert.Equal(t, "1.2.3", driver["version"])
+
+	rules := driver["rules"].([]any)
+	require.Len(t, rules, len(scaffold.LintRules))
+	for i, r := range rules {
+		rule := r.(map[string]any)
+		assert.Equal(t, scaffold.LintRules[i].ID, rule["id"])
+		assert.Equal(t, string(scaffold.LintRules[i].Severity), rule["defaultConfiguration"].(map[string]any)["level"])
+	}
+
+	results := run["results"].([]any)
+	require.Len(t, results, 2)
+
+	want := []struct {
+		rule, level, uri, message string
+		region                    map[string]any
+	}{
+		{
+			rule: "parse-error", level: "error", uri: "templates/a.txt", message: "unclosed action",
+			region: map[string]any{"startLine": float64(3), "startColumn": float64(7)},
+		},
+		{
+			rule: "unused-question", level: "warning", uri: "scaffold.yaml", message: `question "name" is never used`,
+		},
+	}
+
+	for i, w := range want {
+		result := results[i].(map[string]any)
+		assert.Equal(t, w.rule, result["ruleId"])
+		assert.Equal(t, w.level, result["level"])
+		assert.Equal(t, w.message, result["message"].(map[string]any)["text"])
+
+		locations := result["locations"].([]any)
+		require.Len(t, locations, 1)
+		physical := locations[0].(map[string]any)["physicalLocation"].(map[string]any)
+		assert.Equal(t, w.uri, physical["artifactLocation"].(map[string]any)["uri"])
+
+		region, ok := physical["region"]
+		if w.region == nil {
+			assert.False(t, ok, "diagnostics without a line have no region")
+			continue
+		}
+		assert.Equal(t, w.region, region)
+	}
+}
+
+func TestWriteLintGitHub(t *testing.T) {
+	tests := []struct {
+		name string
+		diag scaffold.Diagnostic
+		want string
+	}{
+		{
+			name: "positioned",
+			diag: lintFormatDiags[0],
+			want: "::error file=templates/a.txt,line=3,col=7,title=scaffold lint (parse-error)::unclosed action\n",
+		},
+		{
+			name: "no position",
+			diag: lintFormatDiags[1],
+			want: "::warning file=scaffold.yaml,title=scaffold lint (unused-question)::question \"name\" is never used\n",
+		},
+		{
+			name: "line only",
+			diag: scaffold.NewDiagnostic(scaffold.RuleParseError, "a.txt", "bad").At(scaffold.Position{Line: 2}),
+			want: "::error file=a.txt,line=2,title=scaffold lint (parse-error)::bad\n",
+		},
+		{
+			name: "escaped message",
+			diag: scaffold.NewDiagnostic(scaffold.RuleParseError, "a.txt", "100% broken\r\nsee: a, b"),
+			want: "::error file=a.txt,title=scaffold lint (parse-error)::100%25 broken%0D%0Asee: a, b\n",
+		},
+		{
+			name: "escaped file",
+			diag: scaffold.NewDiagnostic(scaffold.RuleParseError, "dir:a,b%\n.txt", "bad"),
+			want: "::error file=dir%3Aa%2Cb%25%0A.txt,title=scaffold lint (parse-error)::bad\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, writeLintGitHub(nil, &buf, []scaffold.Diagnostic{tt.diag}))
+			assert.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestEscapeGitHub(t *testing.T) {
+	tests := []struct {
+		in       string
+		data     string
+		property string
+	}{
+		{in: "plain", data: "plain", property: "plain"},
+		{in: "50%", data: "50%25", property: "50%25"},
+		{in: "a\rb", data: "a%0Db", property: "a%0Db"},
+		{in: "a\nb", data: "a%0Ab", property: "a%0Ab"},
+		{in: "a:b", data: "a:b", property: "a%3Ab"},
+		{in: "a,b", data: "a,b", property: "a%2Cb"},
+		{in: "%0A", data: "%250A", property: "%250A"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			assert.Equal(t, tt.data, escapeGitHubData(tt.in))
+			assert.Equal(t, tt.property, escapeGitHubProperty(tt.in))
+		})
+	}
+}
@@ -4,8 +4,10 @@ import (
 	"errors"
 	"fmt"
 	"sort"
+	"strings"
 
 	"github.com/charmbracelet/huh"
+	"github.com/hay-kot/scaffold/app/scaffold"
 	"github.com/hay-kot/scaffold/internal/styles"
 )
 
@@ -32,12 +34,66 @@ func httpAuthPrompt(pkgurl string, theme styles.HuhTheme) (username string, pass
 	return username, password, nil
 }
 
-func scaffoldPickerPrompt(aliases map[string]string, localScaffolds []string, systemScaffolds []string, theme styles.HuhTheme) (string, error) {
-	if len(aliases) == 0 && len(localScaffolds) == 0 && len(systemScaffolds) == 0 {
+// maxConflictDiffLines limits the diff shown by conflictPrompt.
+const maxConflictDiffLines = 40
+
+func conflictPrompt(c scaffold.Conflict, theme styles.HuhTheme) (scaffold.ConflictAction, bool, error) {
+	var (
+		action scaffold.ConflictAction
+		all    bool
+	)
+
+	options := []huh.Option[scaffold.ConflictAction]{
+		huh.NewOption("Overwrite", scaffold.ConflictOverwrite),
+		huh.NewOption("Keep existing", scaffold.ConflictKeep),
+	}
+
+	if !c.Verbatim {
+		options = append(options, huh.NewOption("Merge with conflict markers", scaffold.ConflictMerge))
+	}
+
+	options = append(options, huh.NewOption(fmt.Sprintf("Write %s%s", c.Path, scaffold.SidecarSuffix), scaffold.ConflictSidecar))
+
+	diff := "Binary file or symlink, no diff available"
+	if !c.Verbatim {
+		lines := strings.Split(strings.TrimRight(c.Diff, "\n"), "\n")
+		if len(lines) > maxConflictDiffLines {
+			lines = append(lines[:maxConflictDiffLines], fmt.Sprintf("... %d more lines", len(lines)-maxConflictDiffLines))
+		}
+		diff = strings.Join(lines, "\n")
+	}
+
+	err := huh.NewForm(
+		huh.NewGroup(
+			huh.NewNote().
+				Title(fmt.Sprintf("%s already exists", c.Path)).
+				Description(diff),
+			huh.NewSelect[scaffold.ConflictAction]().
+				Title("What do you want to do?").
+				Options(options...).
+				Value(&action),
+			huh.NewConfirm().
+				Title("Apply to all remaining conflicts?").
+				Value(&all),
+		),
+	).WithTheme(styles.Theme(theme)).Run()
+	if err != nil {
+		return "", false, err
+	}
+
+	return action, all, nil
+}
+
+// scaffoldPickerPrompt asks for one of the aliases or scaffolds. Options are
+// labeled with the scaffold's name and tags so they can be filtered by them,
+// the description of the highlighted scaffold is shown below the title.
+func scaffoldPickerPrompt(aliases map[string]string, entries []catalogEntry, theme styles.HuhTheme) (string, error) {
+	if len(aliases) == 0 && len(entries) == 0 {
 		return "", errors.New("no scaffolds available, run 'scaffold update' to fetch scaffolds or 'scaffold init' to create local scaffolds")
 	}
 
-	options := make([]huh.Option[string], 0, len(aliases)+len(localScaffolds)+len(systemScaffolds))
+	options := make([]huh.Option[string], 0, len(aliases)+len(entries))
+	descriptions := map[string]string{}
 
 	if len(aliases) > 0 {
 		names := make([]string, 0, len(aliases))
@@ -51,12 +107,22 @@ func scaffoldPickerPrompt(aliases map[string]string, localScaffolds []string, sy
 		}
 	}
 
-	for _, name := range localScaffolds {
-		options = append(options, huh.NewOption(fmt.Sprintf("[local]  %s", name), name))
-	}
+	for _, e := range entries {
+		label, value := "[local]  "+e.Ref, e.Ref
+		if e.Source == "system" {
+			label, value = "[system] "+e.Ref, "https://"+e.Ref
+		}
 
-	for _, name := range systemScaffolds {
-		options = append(options, huh.NewOption(fmt.Sprintf("[system] %s", name), "https://"+name))
+		if e.Meta.Name != "" {
+			label += " · " + e.Meta.Name
+		}
+
+		for _, tag := range e.Meta.Tags {
+			label += " #" + tag
+		}
+
+		options = append(options, huh.NewOption(label, value))
+		descriptions[value] = pickerDescription(e.Meta)
 	}
 
 	var selected string
@@ -65,6 +131,7 @@ func scaffoldPickerPrompt(aliases map[string]string, localScaffolds []string, sy
 		huh.NewGroup(
 			huh.NewSelect[string]().
 				Title("Select a scaffold").
+				DescriptionFunc(func() string { return descriptions[selected] }, &selected).
 				Options(options...).
 				Filtering(true).
 				Value(&selected),
@@ -78,6 +145,29 @@ func scaffoldPickerPrompt(aliases map[string]string, localScaffolds []string, sy
 	return selected, nil
 }
 
+// pickerDescription returns the description of a scaffold in the picker.
+func pickerDescription(m scaffold.Metadata) string {
+	lines := []string{}
+
+	if m.Description != "" {
+		lines = append(lines, m.Description)
+	}
+
+	if m.Category != "" {
+		lines = append(lines, "Category: "+m.Category)
+	}
+
+	if len(m.Maintainers) > 0 {
+		lines = append(lines, "Maintainers: "+strings.Join(m.Maintainers, ", "))
+	}
+
+	if m.Homepage != "" {
+		lines = append(lines, m.Homepage)
+	}
+
+	return strings.Join(lines, "\n")
+}
+
 func didYouMeanPrompt(given, suggestion string, isSystem bool) bool {
 	ok := true
 
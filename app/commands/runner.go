@@ -28,27 +28,29 @@ type runconf struct {
 	// outputdir is the output directory or filesystem.
 	outputfs rwfs.WriteFS
 	options  scaffold.Options
+	// report is optional and records files that were not written.
+	report *scaffold.Report
+	// verify runs the scaffold's checks against the files written by the
+	// render. Checks see the template output as rendered, files the
+	// post_scaffold hook creates, changes or deletes are not visible to them.
+	verify bool
 }
 
 // runscaffold runs the scaffold. This method exists outside of the `new` receiver function
 // so that we can allow the `test` and `new` commands to share as much of the same code
 // as possible.
 func (ctrl *Controller) runscaffold(cfg runconf) error {
-	scaffoldFS := os.DirFS(cfg.scaffolddir)
+	scaffoldFS := rwfs.NewOsRFS(cfg.scaffolddir)
 	p, err := scaffold.LoadProject(scaffoldFS, cfg.options)
 	if err != nil {
 		return err
 	}
 
-	ok, err := p.Conf.Metadata.IsCompatible(log.Logger, ctrl.Version)
+	err = p.Conf.Metadata.CheckCompatibility(ctrl.Version)
 	if err != nil {
 		return err
 	}
 
-	if !ok {
-		return fmt.Errorf("scaffold requires version %s or higher", p.Conf.Metadata.MinimumVersion)
-	}
-
 	version, err := pkgs.GetVersion(cfg.scaffolddir)
 	if err != nil {
 		log.Debug().Err(err).Msg("failed to get version")
@@ -79,6 +81,15 @@ func (ctrl *Controller) runscaffold(cfg runconf) error {
 		Project: p,
 		ReadFS:  scaffoldFS,
 		WriteFS: cfg.outputfs,
+		Report:  cfg.report,
+	}
+
+	// Checks only see the files of this render, not the rest of the output
+	// directory or changes made by hooks.
+	var rendered *rwfs.MemoryWFS
+	if cfg.verify {
+		rendered = rwfs.NewMemoryWFS()
+		args.WriteFS = rwfs.NewTeeWFS(cfg.outputfs, rendered)
 	}
 
 	vars, err = scaffold.BuildVars(ctrl.engine, args.Project, vars)
@@ -98,6 +109,13 @@ func (ctrl *Controller) runscaffold(cfg runconf) error {
 		}
 	}
 
+	if cfg.verify {
+		err = scaffold.RunChecks(ctrl.engine, p.Conf.Checks, rendered, vars)
+		if err != nil {
+			return err
+		}
+	}
+
 	if !cfg.noPrompt && p.Conf.Messages.Post != "" {
 		rendered, err := ctrl.engine.TmplString(p.Conf.Messages.Post, vars)
 		if err != nil {
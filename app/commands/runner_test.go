@@ -0,0 +1,72 @@
+package commands
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/hay-kot/scaffold/app/scaffold/scaffoldrc"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestController_runscaffold_VerifyIgnoresHooks(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks are shell scripts")
+	}
+
+	scaffolddir := t.TempDir()
+	outdir := t.TempDir()
+
+	files := map[string]string{
+		"scaffold.yaml": `checks:
+  - name: hook output is not checked
+    not_exists: ["hooked.txt"]
+  - name: template output is checked as rendered
+    files: "{{ .Project }}/main.txt"
+    matches: "^rendered$"
+`,
+		"{{ .Project }}/main.txt": "rendered",
+		"hooks/post_scaffold": `#!/bin/sh
+echo hook > hooked.txt
+echo changed > app/main.txt
+`,
+	}
+
+	for name, data := range files {
+		path := filepath.Join(scaffolddir, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(data), 0o755))
+	}
+
+	rc := scaffoldrc.Default()
+	rc.Settings.RunHooks = scaffoldrc.RunHooksAlways
+
+	ctrl := &Controller{}
+	ctrl.Prepare(engine.New(), rc)
+
+	err := ctrl.runscaffold(runconf{
+		scaffolddir: scaffolddir,
+		noPrompt:    true,
+		varfunc: func(p *scaffold.Project) (map[string]any, error) {
+			p.Name = "app"
+			return map[string]any{}, nil
+		},
+		outputfs: rwfs.NewOsWFS(outdir),
+		verify:   true,
+	})
+	require.NoError(t, err)
+
+	// The hook ran, the checks passed against the files as rendered.
+	hooked, err := os.ReadFile(filepath.Join(outdir, "hooked.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "hook\n", string(hooked))
+
+	main, err := os.ReadFile(filepath.Join(outdir, "app", "main.txt"))
+	require.NoError(t, err)
+	assert.Equal(t, "changed\n", string(main))
+}
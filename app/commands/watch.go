@@ -0,0 +1,191 @@
+package commands
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// watchDebounce is how long watch waits for more events before calling fn,
+// editors often write a file in several steps.
+const watchDebounce = 100 * time.Millisecond
+
+// watcher reports changes to a set of files and directory trees.
+type watcher struct {
+	w *fsnotify.Watcher
+	// dirs are the watched trees, files the watched single files. Events for
+	// other paths in the parent directories of files are ignored.
+	dirs  []string
+	files map[string]bool
+}
+
+// watch calls fn each time a file under paths is created, changed or removed
+// until ctx is cancelled. Directories are watched recursively with fsnotify,
+// directories created while watching are added as they appear. Paths that
+// don't exist are skipped.
+func watch(ctx context.Context, paths []string, fn func()) error {
+	w, err := newWatcher(paths)
+	if err != nil {
+		return err
+	}
+	defer w.close()
+
+	return w.run(ctx, fn)
+}
+
+func newWatcher(paths []string) (*watcher, error) {
+	fw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &watcher{w: fw, files: map[string]bool{}}
+	for _, p := range paths {
+		err := w.add(p)
+		if err != nil {
+			_ = fw.Close()
+			return nil, err
+		}
+	}
+
+	return w, nil
+}
+
+func (w *watcher) close() error {
+	return w.w.Close()
+}
+
+// run calls fn once events stop arriving for watchDebounce, until ctx is
+// cancelled.
+func (w *watcher) run(ctx context.Context, fn func()) error {
+	timer := time.NewTimer(watchDebounce)
+	timer.Stop()
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err, ok := <-w.w.Errors:
+			if !ok {
+				return nil
+			}
+			return err
+		case event, ok := <-w.w.Events:
+			if !ok {
+				return nil
+			}
+
+			changed, err := w.handle(event)
+			if err != nil {
+				return err
+			}
+
+			if changed {
+				timer.Reset(watchDebounce)
+			}
+		case <-timer.C:
+			fn()
+		}
+	}
+}
+
+// add watches the tree at p when it's a directory, otherwise the parent
+// directory of the file is watched.
+func (w *watcher) add(p string) error {
+	p = filepath.Clean(p)
+
+	info, err := os.Stat(p)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	if !info.IsDir() {
+		w.files[p] = true
+		return w.w.Add(filepath.Dir(p))
+	}
+
+	w.dirs = append(w.dirs, p)
+	return w.addTree(p)
+}
+
+// addTree watches root and every directory below it, except .git.
+func (w *watcher) addTree(root string) error {
+	return filepath.WalkDir(root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			// Removed while walking, the parent reports the removal.
+			if errors.Is(err, fs.ErrNotExist) {
+				return nil
+			}
+			return err
+		}
+
+		if !d.IsDir() {
+			return nil
+		}
+
+		if d.Name() == ".git" {
+			return filepath.SkipDir
+		}
+
+		return w.w.Add(p)
+	})
+}
+
+// handle reports whether event changes a watched path, and watches
+// directories created in a watched tree.
+func (w *watcher) handle(event fsnotify.Event) (bool, error) {
+	// Permission and timestamp changes don't change what's rendered.
+	if event.Op == fsnotify.Chmod {
+		return false, nil
+	}
+
+	name := filepath.Clean(event.Name)
+	if w.files[name] {
+		return true, nil
+	}
+
+	for _, dir := range w.dirs {
+		rel, err := filepath.Rel(dir, name)
+		if err != nil || rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)) {
+			continue
+		}
+
+		if isGitPath(rel) {
+			return false, nil
+		}
+
+		if event.Has(fsnotify.Create) {
+			if info, err := os.Stat(name); err == nil && info.IsDir() {
+				err := w.addTree(name)
+				if err != nil {
+					return false, err
+				}
+			}
+		}
+
+		return true, nil
+	}
+
+	return false, nil
+}
+
+// isGitPath reports whether the relative path rel is in a .git directory.
+func isGitPath(rel string) bool {
+	for _, part := range strings.Split(rel, string(filepath.Separator)) {
+		if part == ".git" {
+			return true
+		}
+	}
+
+	return false
+}
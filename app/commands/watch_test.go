@@ -0,0 +1,103 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// watchFixture watches paths and returns a channel receiving a value for
+// every call of fn.
+func watchFixture(t *testing.T, paths ...string) <-chan struct{} {
+	t.Helper()
+
+	w, err := newWatcher(paths)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	calls := make(chan struct{}, 16)
+	done := make(chan error, 1)
+
+	go func() {
+		done <- w.run(ctx, func() { calls <- struct{}{} })
+	}()
+
+	t.Cleanup(func() {
+		cancel()
+		require.NoError(t, <-done)
+		require.NoError(t, w.close())
+	})
+
+	return calls
+}
+
+func expectCall(t *testing.T, calls <-chan struct{}, msg string) {
+	t.Helper()
+
+	select {
+	case <-calls:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no change reported: %s", msg)
+	}
+}
+
+func expectNoCall(t *testing.T, calls <-chan struct{}, msg string) {
+	t.Helper()
+
+	select {
+	case <-calls:
+		t.Fatalf("unexpected change reported: %s", msg)
+	case <-time.After(4 * watchDebounce):
+	}
+}
+
+func Test_watch_Directory(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, ".git"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "README.md"), []byte("# hi\n"), 0o644))
+
+	calls := watchFixture(t, dir, filepath.Join(dir, "missing"))
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "README.md"), []byte("# hello\n"), 0o644))
+	expectCall(t, calls, "file in a sub directory changed")
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, ".git", "HEAD"), []byte("ref\n"), 0o644))
+	expectNoCall(t, calls, ".git is ignored")
+
+	// Directories created while watching are watched as well.
+	nested := filepath.Join(dir, "templates", "new", "deep")
+	require.NoError(t, os.MkdirAll(nested, 0o755))
+	expectCall(t, calls, "directory created")
+
+	require.NoError(t, os.WriteFile(filepath.Join(nested, "a.txt"), []byte("a\n"), 0o644))
+	expectCall(t, calls, "file in a new directory created")
+
+	require.NoError(t, os.Remove(filepath.Join(nested, "a.txt")))
+	expectCall(t, calls, "file removed")
+}
+
+func Test_watch_File(t *testing.T) {
+	dir := t.TempDir()
+	file := filepath.Join(dir, "template.tmpl")
+	require.NoError(t, os.WriteFile(file, []byte("{{ .Project }}\n"), 0o644))
+
+	calls := watchFixture(t, file)
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other.txt"), []byte("x\n"), 0o644))
+	expectNoCall(t, calls, "siblings of watched files are ignored")
+
+	// Several writes in a row are reported once.
+	for i := range 3 {
+		require.NoError(t, os.WriteFile(file, []byte{byte('a' + i)}, 0o644))
+	}
+	expectCall(t, calls, "watched file changed")
+	expectNoCall(t, calls, "writes are debounced")
+
+	assert.Empty(t, calls)
+}
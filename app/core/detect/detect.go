@@ -0,0 +1,47 @@
+// Package detect tells binary files, which are copied verbatim, apart from text
+// files that can be rendered as templates.
+package detect
+
+import (
+	"bytes"
+)
+
+// SniffLen is the number of leading bytes inspected to detect binary files.
+const SniffLen = 512
+
+// maxControlRatio is the share of control characters above which a file is
+// treated as binary.
+const maxControlRatio = 0.3
+
+// IsBinary reports whether the leading bytes of a file belong to a binary
+// file: they contain a NUL byte or mostly control characters. The encoding
+// isn't checked, Latin-1 and other non UTF-8 text is rendered as a template.
+func IsBinary(head []byte) bool {
+	if len(head) == 0 {
+		return false
+	}
+
+	if bytes.IndexByte(head, 0) != -1 {
+		return true
+	}
+
+	control := 0
+	for _, b := range head {
+		if isControl(b) {
+			control++
+		}
+	}
+
+	return float64(control)/float64(len(head)) > maxControlRatio
+}
+
+// isControl reports whether b is an ASCII control character that doesn't
+// appear in text files. Whitespace, form feeds and escape sequences do.
+func isControl(b byte) bool {
+	switch b {
+	case '\t', '\n', '\v', '\f', '\r', 0x1b:
+		return false
+	}
+
+	return b < 0x20 || b == 0x7f
+}
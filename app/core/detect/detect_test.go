@@ -0,0 +1,39 @@
+package detect
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsBinary(t *testing.T) {
+	tests := []struct {
+		name string
+		head []byte
+		want bool
+	}{
+		{name: "empty", head: nil, want: false},
+		{name: "text", head: []byte("hello {{ .Project }}\n"), want: false},
+		{name: "json", head: []byte(`{"name": "{{ .Project }}"}`), want: false},
+		{name: "html", head: []byte("<!DOCTYPE html><html></html>"), want: false},
+		{name: "nul byte", head: []byte("abc\x00def"), want: true},
+		{name: "png", head: []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR"), want: true},
+		{name: "gzip", head: []byte("\x1f\x8b\x08"), want: true},
+		{name: "woff2", head: []byte("wOF2\x00\x01"), want: true},
+		{name: "bmp magic", head: []byte("BM is the bitmap magic number\n"), want: false},
+		{name: "mp3 magic", head: []byte("ID3 tags: {{ .Scaffold.tags }}\n"), want: false},
+		{name: "pdf magic", head: []byte("%PDF-1.7 is documented in {{ .Project }}\n"), want: false},
+		{name: "utf-8", head: []byte("héllo wörld ✓\n"), want: false},
+		{name: "truncated rune", head: []byte("héllo \xe2\x9c"), want: false},
+		{name: "latin-1", head: []byte("h\xe9llo w\xf6rld {{ .Project }}\n"), want: false},
+		{name: "ansi colors", head: []byte("\x1b[31mred\x1b[0m\n"), want: false},
+		{name: "few control characters", head: []byte("page one\f\x07 page two {{ .Project }}\n"), want: false},
+		{name: "mostly control characters", head: []byte("\x01\x02\x03\x04ab\x05\x06"), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsBinary(tt.head))
+		})
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"io"
 	"io/fs"
 	"path/filepath"
+	"sort"
 	"strings"
 	"text/template"
 	"unicode"
@@ -21,8 +22,10 @@ var ErrTemplateIsEmpty = fmt.Errorf("template is empty")
 type Vars map[string]any
 
 type opts struct {
-	delimLeft  string
-	delimRight string
+	delimLeft    string
+	delimRight   string
+	trimBlocks   bool
+	lstripBlocks bool
 }
 
 func WithDelims(left string, right string) func(*opts) {
@@ -75,6 +78,31 @@ func New() *Engine {
 	return e
 }
 
+// HasFunc reports whether name is a function registered with the engine. The
+// text/template builtins are not included.
+func (e *Engine) HasFunc(name string) bool {
+	_, ok := e.fm[name]
+	return ok
+}
+
+// FuncNames returns the names of the functions registered with the engine,
+// sorted.
+func (e *Engine) FuncNames() []string {
+	names := make([]string, 0, len(e.fm))
+	for name := range e.fm {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// Func returns the function registered with the engine as name.
+func (e *Engine) Func(name string) (any, bool) {
+	fn, ok := e.fm[name]
+	return fn, ok
+}
+
 func (e *Engine) parse(tmpl string, opt opts) (*template.Template, error) {
 	return template.New("scaffold").
 		Funcs(e.fm).
@@ -138,7 +166,7 @@ func (e *Engine) Factory(reader io.Reader, opfns ...func(*opts)) (*template.Temp
 		fn(&opt)
 	}
 
-	return e.parse(string(out), opt)
+	return e.parse(transformBlocks(string(out), opt), opt)
 }
 
 func (e *Engine) Render(w io.Writer, tmpl *template.Template, vars any) error {
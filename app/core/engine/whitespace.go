@@ -0,0 +1,149 @@
+package engine
+
+import (
+	"regexp"
+	"strings"
+)
+
+// WithTrimBlocks removes the first line break after a block action when
+// enabled, like Jinja's trim_blocks.
+func WithTrimBlocks(enabled bool) func(*opts) {
+	return func(o *opts) {
+		o.trimBlocks = enabled
+	}
+}
+
+// WithLStripBlocks removes the spaces and tabs before a block action that
+// starts a line when enabled, like Jinja's lstrip_blocks.
+func WithLStripBlocks(enabled bool) func(*opts) {
+	return func(o *opts) {
+		o.lstripBlocks = enabled
+	}
+}
+
+// blockKeywords are the actions that never produce output.
+var blockKeywords = map[string]bool{
+	"if":       true,
+	"else":     true,
+	"end":      true,
+	"range":    true,
+	"with":     true,
+	"define":   true,
+	"block":    true,
+	"break":    true,
+	"continue": true,
+}
+
+var assignmentPattern = regexp.MustCompile(`^\$\w*\s*:?=`)
+
+// isBlockAction reports whether the action with the inner text between the
+// delimiters is a control structure, variable assignment or comment.
+func isBlockAction(inner string) bool {
+	s := strings.TrimSpace(inner)
+	s = strings.TrimSpace(strings.TrimPrefix(s, "-"))
+
+	if strings.HasPrefix(s, "/*") || assignmentPattern.MatchString(s) {
+		return true
+	}
+
+	keyword, _, _ := strings.Cut(s, " ")
+	keyword, _, _ = strings.Cut(keyword, "(")
+	return blockKeywords[strings.TrimSpace(keyword)]
+}
+
+// actionEnd returns the index after the right delimiter of the action whose
+// inner text starts at pos, skipping delimiters inside comments and string
+// literals. ok is false when the action is not closed.
+func actionEnd(src string, pos int, right string) (end int, ok bool) {
+	inner := strings.TrimLeft(strings.TrimPrefix(src[pos:], "-"), " \t\r\n")
+	if strings.HasPrefix(inner, "/*") {
+		start := len(src) - len(inner)
+		x := strings.Index(src[start+2:], "*/")
+		if x < 0 {
+			return 0, false
+		}
+		pos = start + 2 + x + 2
+	}
+
+	for i := pos; i < len(src); i++ {
+		switch c := src[i]; c {
+		case '"', '\'', '`':
+			for i++; i < len(src) && src[i] != c; i++ {
+				if src[i] == '\\' && c != '`' {
+					i++
+				}
+			}
+		default:
+			if strings.HasPrefix(src[i:], right) {
+				return i + len(right), true
+			}
+		}
+	}
+
+	return 0, false
+}
+
+// transformBlocks applies trim_blocks and lstrip_blocks to the template
+// source. Removed line breaks are moved into comments so line numbers in
+// errors still match the source.
+func transformBlocks(src string, opt opts) string {
+	if !opt.trimBlocks && !opt.lstripBlocks {
+		return src
+	}
+
+	left, right := opt.delimLeft, opt.delimRight
+
+	var b strings.Builder
+	b.Grow(len(src))
+
+	last := 0 // start of the source not yet written
+	i := 0
+	for {
+		x := strings.Index(src[i:], left)
+		if x < 0 {
+			break
+		}
+
+		start := i + x
+		end, ok := actionEnd(src, start+len(left), right)
+		if !ok {
+			// Unclosed actions are left for the parser to report.
+			break
+		}
+
+		inner := src[start+len(left) : end-len(right)]
+		i = end
+
+		if !isBlockAction(inner) {
+			continue
+		}
+
+		if opt.lstripBlocks && !strings.HasPrefix(inner, "-") {
+			lineStart := strings.LastIndexByte(src[:start], '\n') + 1
+			if lineStart >= last && strings.Trim(src[lineStart:start], " \t") == "" {
+				b.WriteString(src[last:lineStart])
+				last = start
+			}
+		}
+
+		if opt.trimBlocks && !strings.HasSuffix(inner, "-") {
+			var nl string
+			switch {
+			case strings.HasPrefix(src[end:], "\n"):
+				nl = "\n"
+			case strings.HasPrefix(src[end:], "\r\n"):
+				nl = "\r\n"
+			}
+
+			if nl != "" {
+				b.WriteString(src[last:end])
+				b.WriteString(left + "/*" + nl + "*/" + right)
+				last = end + len(nl)
+				i = last
+			}
+		}
+	}
+
+	b.WriteString(src[last:])
+	return b.String()
+}
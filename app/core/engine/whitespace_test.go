@@ -0,0 +1,85 @@
+package engine
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFactory_WhitespaceControl(t *testing.T) {
+	vars := Vars{"Items": []string{"a", "b"}, "On": true}
+
+	tests := []struct {
+		name string
+		tmpl string
+		opts []func(*opts)
+		want string
+	}{
+		{
+			name: "disabled",
+			tmpl: "{{ if .On }}\non\n{{ end }}\n",
+			want: "\non\n\n",
+		},
+		{
+			name: "trim blocks",
+			tmpl: "{{ if .On }}\non\n{{ end }}\nafter\n",
+			opts: []func(*opts){WithTrimBlocks(true)},
+			want: "on\nafter\n",
+		},
+		{
+			name: "trim blocks keeps output actions",
+			tmpl: "{{ range .Items }}\n{{ . }}\n{{ end }}\n",
+			opts: []func(*opts){WithTrimBlocks(true)},
+			want: "a\nb\n",
+		},
+		{
+			name: "trim blocks removes one line break",
+			tmpl: "{{ $x := 1 }}\n\n{{/* comment */}}\r\nx\n",
+			opts: []func(*opts){WithTrimBlocks(true)},
+			want: "\nx\n",
+		},
+		{
+			name: "lstrip blocks",
+			tmpl: "list:\n  {{ range .Items }}\n  - {{ . }}\n  {{ end }}\n",
+			opts: []func(*opts){WithTrimBlocks(true), WithLStripBlocks(true)},
+			want: "list:\n  - a\n  - b\n",
+		},
+		{
+			name: "lstrip blocks only at line start",
+			tmpl: "x {{ if .On }}y{{ end }}\n\t{{ if .On }}z{{ end }}\n",
+			opts: []func(*opts){WithLStripBlocks(true)},
+			want: "x y\nz\n",
+		},
+		{
+			name: "delimiters in strings",
+			tmpl: "{{ if eq \"}}\" \"}}\" }}\nyes\n{{ end }}\n",
+			opts: []func(*opts){WithTrimBlocks(true)},
+			want: "yes\n",
+		},
+		{
+			name: "custom delimiters",
+			tmpl: "[[ if .On ]]\n{{ .Values }}\n[[ end ]]\n",
+			opts: []func(*opts){WithDelims("[[", "]]"), WithTrimBlocks(true)},
+			want: "{{ .Values }}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := tEngine.Factory(strings.NewReader(tt.tmpl), tt.opts...)
+			require.NoError(t, err)
+
+			out := &strings.Builder{}
+			require.NoError(t, tEngine.Render(out, tmpl, vars))
+			assert.Equal(t, tt.want, out.String())
+		})
+	}
+}
+
+func TestFactory_WhitespaceControlErrorLine(t *testing.T) {
+	_, err := tEngine.Factory(strings.NewReader("{{ if true }}\n\n{{ end }}\n{{ .Bad }\n"), WithTrimBlocks(true))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), ":4:", "line numbers match the source")
+}
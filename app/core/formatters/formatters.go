@@ -0,0 +1,94 @@
+// Package formatters provides the built-in formatters applied to rendered
+// files.
+package formatters
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Func formats the contents of a file.
+type Func func(data []byte) ([]byte, error)
+
+var registry = map[string]Func{
+	"go":   Go,
+	"json": JSON,
+	"yaml": YAML,
+}
+
+// Get returns the formatter registered as name.
+func Get(name string) (Func, bool) {
+	fn, ok := registry[name]
+	return fn, ok
+}
+
+// Names returns the names of the built-in formatters, sorted.
+func Names() []string {
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+	return names
+}
+
+// JSON re-indents JSON with two spaces, preserving key order.
+func JSON(data []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+
+	buff := bytes.NewBuffer(nil)
+
+	err := json.Indent(buff, bytes.TrimSpace(data), "", "  ")
+	if err != nil {
+		return nil, err
+	}
+
+	buff.WriteByte('\n')
+	return buff.Bytes(), nil
+}
+
+// YAML re-indents YAML with two spaces, preserving comments and key order.
+// Multi-document streams are kept as separate documents.
+func YAML(data []byte) ([]byte, error) {
+	if len(bytes.TrimSpace(data)) == 0 {
+		return data, nil
+	}
+
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	buff := bytes.NewBuffer(nil)
+
+	enc := yaml.NewEncoder(buff)
+	enc.SetIndent(2)
+
+	for {
+		var node yaml.Node
+
+		err := dec.Decode(&node)
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return nil, err
+		}
+
+		err = enc.Encode(&node)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := enc.Close()
+	if err != nil {
+		return nil, err
+	}
+
+	return buff.Bytes(), nil
+}
@@ -0,0 +1,84 @@
+package formatters
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGo(t *testing.T) {
+	tests := []struct {
+		name    string
+		in      string
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "whitespace",
+			in:   "package main\n\n\n\nfunc main()   {\n\n}\n\n\n",
+			want: "package main\n\nfunc main() {\n\n}\n",
+		},
+		{
+			name: "groups imports",
+			in:   "package main\n\nimport (\n\t\"github.com/acme/lib\"\n\t\"fmt\"\n\n\tzlog \"github.com/rs/zerolog/log\" // logger\n\t\"os\"\n)\n",
+			want: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n\n\t\"github.com/acme/lib\"\n\tzlog \"github.com/rs/zerolog/log\" // logger\n)\n",
+		},
+		{
+			name: "only stdlib",
+			in:   "package main\n\nimport (\n\t\"os\"\n\n\t\"fmt\"\n)\n",
+			want: "package main\n\nimport (\n\t\"fmt\"\n\t\"os\"\n)\n",
+		},
+		{
+			name: "comment lines are kept",
+			in:   "package main\n\nimport (\n\t// logging\n\t\"log\"\n\t\"fmt\"\n)\n",
+			want: "package main\n\nimport (\n\t// logging\n\t\"fmt\"\n\t\"log\"\n)\n",
+		},
+		{
+			name:    "syntax error",
+			in:      "package main\n\nfunc {\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Go([]byte(tt.in))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestJSON(t *testing.T) {
+	got, err := JSON([]byte("{\"b\": 1,\n\n    \"a\": [1,\n 2]}"))
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"b\": 1,\n  \"a\": [\n    1,\n    2\n  ]\n}\n", string(got))
+
+	_, err = JSON([]byte("{\"a\": }"))
+	require.Error(t, err)
+}
+
+func TestYAML(t *testing.T) {
+	got, err := YAML([]byte("b:    1\na:\n    - x   # first\n    - y\n---\nc:   true\n"))
+	require.NoError(t, err)
+	assert.Equal(t, "b: 1\na:\n  - x # first\n  - y\n---\nc: true\n", string(got))
+
+	_, err = YAML([]byte("a: [\n"))
+	require.Error(t, err)
+}
+
+func TestGet(t *testing.T) {
+	_, ok := Get("go")
+	assert.True(t, ok)
+
+	_, ok = Get("prettier")
+	assert.False(t, ok)
+
+	assert.Equal(t, []string{"go", "json", "yaml"}, Names())
+}
@@ -0,0 +1,163 @@
+package formatters
+
+import (
+	"bytes"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Go formats Go source like gofmt and groups the imports like goimports,
+// standard library packages first followed by all other packages.
+func Go(data []byte) ([]byte, error) {
+	out, err := format.Source(data)
+	if err != nil {
+		return nil, err
+	}
+
+	grouped, err := groupImports(out)
+	if err != nil {
+		return nil, err
+	}
+
+	if bytes.Equal(grouped, out) {
+		return out, nil
+	}
+
+	return format.Source(grouped)
+}
+
+// importLine is an import spec that occupies a single line.
+type importLine struct {
+	path string
+	text string
+}
+
+// groupImports regroups the specs of every parenthesized import declaration.
+// Declarations with comments on their own line are left as is, since the
+// comments can't be moved reliably.
+func groupImports(src []byte) ([]byte, error) {
+	fset := token.NewFileSet()
+
+	file, err := parser.ParseFile(fset, "", src, parser.ImportsOnly|parser.ParseComments)
+	if err != nil {
+		return nil, err
+	}
+
+	lines := strings.SplitAfter(string(src), "\n")
+
+	// Declarations are rewritten last to first so earlier line numbers stay
+	// valid.
+	for i := len(file.Decls) - 1; i >= 0; i-- {
+		decl, ok := file.Decls[i].(*ast.GenDecl)
+		if !ok || decl.Tok != token.IMPORT || !decl.Lparen.IsValid() {
+			continue
+		}
+
+		start := fset.Position(decl.Lparen).Line // line of "import ("
+		end := fset.Position(decl.Rparen).Line   // line of ")"
+		if start == end {
+			continue
+		}
+
+		specs, ok := importLines(fset, file, decl, lines)
+		if !ok {
+			continue
+		}
+
+		block := groupedBlock(specs)
+		lines = append(lines[:start], append(block, lines[end-1:]...)...)
+	}
+
+	return []byte(strings.Join(lines, "")), nil
+}
+
+// importLines returns the lines of the specs in decl, ok is false when the
+// declaration can't be regrouped.
+func importLines(fset *token.FileSet, file *ast.File, decl *ast.GenDecl, lines []string) ([]importLine, bool) {
+	start := fset.Position(decl.Lparen).Line
+	end := fset.Position(decl.Rparen).Line
+
+	for _, cg := range file.Comments {
+		line := fset.Position(cg.Pos()).Line
+		if line <= start || line >= end {
+			continue
+		}
+
+		// Trailing comments share a line with a spec and move with it.
+		trailing := false
+		for _, spec := range decl.Specs {
+			if fset.Position(spec.End()).Line == line {
+				trailing = true
+			}
+		}
+
+		if !trailing {
+			return nil, false
+		}
+	}
+
+	specs := make([]importLine, 0, len(decl.Specs))
+	for _, spec := range decl.Specs {
+		is := spec.(*ast.ImportSpec)
+
+		first, last := fset.Position(is.Pos()).Line, fset.Position(is.End()).Line
+		if first != last {
+			return nil, false
+		}
+
+		path, err := strconv.Unquote(is.Path.Value)
+		if err != nil {
+			return nil, false
+		}
+
+		specs = append(specs, importLine{path: path, text: strings.TrimSpace(lines[first-1])})
+	}
+
+	return specs, true
+}
+
+// groupedBlock returns the lines of an import block with the standard
+// library group followed by the third party group, each sorted by path.
+func groupedBlock(specs []importLine) []string {
+	var std, other []importLine
+	for _, s := range specs {
+		if isStdlib(s.path) {
+			std = append(std, s)
+		} else {
+			other = append(other, s)
+		}
+	}
+
+	var block []string
+	for _, group := range [][]importLine{std, other} {
+		if len(group) == 0 {
+			continue
+		}
+
+		sort.SliceStable(group, func(i, j int) bool {
+			return group[i].path < group[j].path
+		})
+
+		if len(block) > 0 {
+			block = append(block, "\n")
+		}
+
+		for _, s := range group {
+			block = append(block, "\t"+s.text+"\n")
+		}
+	}
+
+	return block
+}
+
+// isStdlib reports whether path is a standard library import path, which
+// never contain a dot in their first element.
+func isStdlib(path string) bool {
+	first, _, _ := strings.Cut(path, "/")
+	return !strings.Contains(first, ".")
+}
@@ -11,8 +11,9 @@ import (
 type NodeType string
 
 const (
-	DirNodeType  NodeType = "dir"
-	FileNodeType NodeType = "file"
+	DirNodeType     NodeType = "dir"
+	FileNodeType    NodeType = "file"
+	SymlinkNodeType NodeType = "symlink"
 )
 
 type AstNode struct {
@@ -29,12 +30,15 @@ func (n *AstNode) string(indent int) string {
 
 	for _, child := range n.Leafs {
 		prefix := fmt.Sprintf("%s:  (type=%s)", child.Path, child.NodeType)
-		if child.NodeType == "file" {
+		switch child.NodeType {
+		case SymlinkNodeType:
+			ast.WriteString(indentStr + prefix + "\n" + indentStr + "\t-> " + string(child.Content) + "\n")
+		case FileNodeType:
 			// ensure all new lines in the file are indented
 			content := string(bytes.ReplaceAll(child.Content, []byte("\n"), []byte("\n"+indentStr+"\t")))
 
 			ast.WriteString(indentStr + prefix + "\n" + indentStr + "\t" + content + "\n")
-		} else {
+		default:
 			ast.WriteString(indentStr + prefix + "\n" + child.string(indent+1))
 		}
 	}
@@ -66,7 +70,15 @@ func Build(subFs fs.FS, root *AstNode) error {
 			Path: file.Name(),
 		}
 
-		if file.IsDir() {
+		switch {
+		case file.Type()&fs.ModeSymlink != 0:
+			node.NodeType = SymlinkNodeType
+			target, err := readLink(subFs, file.Name())
+			if err != nil {
+				return err
+			}
+			node.Content = []byte(target)
+		case file.IsDir():
 			node.NodeType = DirNodeType
 			subsubFS, _ := fs.Sub(subFs, file.Name())
 
@@ -74,7 +86,7 @@ func Build(subFs fs.FS, root *AstNode) error {
 			if err != nil {
 				return err
 			}
-		} else {
+		default:
 			node.NodeType = FileNodeType
 			readContent, err := fs.ReadFile(subFs, file.Name())
 			if err != nil {
@@ -88,3 +100,17 @@ func Build(subFs fs.FS, root *AstNode) error {
 
 	return nil
 }
+
+// readLink returns the target of the link at name. File systems without
+// ReadLink support are expected to store the target as the contents of the
+// link, like rwfs.MemoryWFS and fstest.MapFS do.
+func readLink(fsys fs.FS, name string) (string, error) {
+	if rl, ok := fsys.(interface {
+		ReadLink(name string) (string, error)
+	}); ok {
+		return rl.ReadLink(name)
+	}
+
+	data, err := fs.ReadFile(fsys, name)
+	return string(data), err
+}
@@ -1,7 +1,10 @@
 package rwfs
 
 import (
+	"bytes"
+	"io"
 	"io/fs"
+	"path"
 	"strings"
 
 	"github.com/psanford/memfs"
@@ -34,6 +37,81 @@ func (m *MemoryWFS) WriteFile(path string, data []byte, perm fs.FileMode) error
 	return m.FS.WriteFile(path, data, perm)
 }
 
+// Create returns a writer that buffers the file contents in memory and writes
+// them to the file system on Close.
+func (m *MemoryWFS) Create(path string, perm fs.FileMode) (io.WriteCloser, error) {
+	return &memFileWriter{fs: m, path: path, perm: perm}, nil
+}
+
+type memFileWriter struct {
+	bytes.Buffer
+	fs   *MemoryWFS
+	path string
+	perm fs.FileMode
+}
+
+func (w *memFileWriter) Close() error {
+	return w.fs.WriteFile(w.path, w.Bytes(), w.perm)
+}
+
+// Chmod rewrites the file with the new permission bits. memfs can't change
+// the mode of a directory so directories and links are left untouched.
+func (m *MemoryWFS) Chmod(path string, mode fs.FileMode) error {
+	path = strings.TrimPrefix(path, "/")
+
+	info, err := fs.Stat(m.FS, path)
+	if err != nil {
+		return err
+	}
+
+	if info.IsDir() || info.Mode()&fs.ModeSymlink != 0 {
+		return nil
+	}
+
+	data, err := fs.ReadFile(m.FS, path)
+	if err != nil {
+		return err
+	}
+
+	return m.FS.WriteFile(path, data, mode.Perm())
+}
+
+// Symlink records newname as a link to oldname. memfs has no support for
+// links, they are stored as files with fs.ModeSymlink set and the target as
+// their contents, so they show up in Open, ReadDir and WalkDir like
+// fstest.MapFS links do.
+func (m *MemoryWFS) Symlink(oldname, newname string) error {
+	newname = strings.TrimPrefix(newname, "/")
+
+	err := m.MkdirAll(path.Dir(newname), fs.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return m.FS.WriteFile(newname, []byte(oldname), fs.ModeSymlink|0o777)
+}
+
+// ReadLink returns the target of a link created with Symlink.
+func (m *MemoryWFS) ReadLink(name string) (string, error) {
+	name = strings.TrimPrefix(name, "/")
+
+	info, err := fs.Stat(m.FS, name)
+	if err != nil {
+		return "", err
+	}
+
+	if info.Mode()&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	data, err := fs.ReadFile(m.FS, name)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
 func (m *MemoryWFS) RunHook(name string, data []byte, args []string) error {
 	return ErrHooksNotSupported
 }
@@ -0,0 +1,46 @@
+package rwfs
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMemoryWFS_Symlink(t *testing.T) {
+	m := NewMemoryWFS()
+	require.NoError(t, m.WriteFile("README.md", []byte("# demo\n"), 0o644))
+	require.NoError(t, m.Symlink("../README.md", "docs/index.md"))
+
+	target, err := m.ReadLink("docs/index.md")
+	require.NoError(t, err)
+	assert.Equal(t, "../README.md", target)
+
+	_, err = m.ReadLink("README.md")
+	require.ErrorIs(t, err, fs.ErrInvalid)
+
+	// Links are listed by WalkDir, also through sub file systems.
+	sub, err := fs.Sub(m, "docs")
+	require.NoError(t, err)
+
+	links := []string{}
+	err = fs.WalkDir(sub, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.Type()&fs.ModeSymlink != 0 {
+			links = append(links, p)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"index.md"}, links)
+
+	// Chmod leaves links untouched.
+	require.NoError(t, m.Chmod("docs/index.md", 0o600))
+	target, err = m.ReadLink("docs/index.md")
+	require.NoError(t, err)
+	assert.Equal(t, "../README.md", target)
+}
@@ -2,6 +2,7 @@ package rwfs
 
 import (
 	"context"
+	"io"
 	"io/fs"
 	"os"
 	"os/exec"
@@ -11,6 +12,31 @@ import (
 
 var _ WriteFS = &OsWFS{}
 
+// OsRFS is a read only file system rooted at a directory that can read
+// symbolic links. os.DirFS only implements ReadLink from Go 1.25 on.
+type OsRFS struct {
+	fs.FS
+	root string
+}
+
+// NewOsRFS returns a new OsRFS with the given root path.
+func NewOsRFS(root string) *OsRFS {
+	return &OsRFS{
+		FS:   os.DirFS(root),
+		root: root,
+	}
+}
+
+// ReadLink wraps os.Readlink and Joins the root path to the name/path before
+// calling os.Readlink
+func (o *OsRFS) ReadLink(name string) (string, error) {
+	if !fs.ValidPath(name) {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return os.Readlink(filepath.Join(o.root, filepath.FromSlash(name)))
+}
+
 type OsWFS struct {
 	fs.FS
 	root string
@@ -38,6 +64,35 @@ func (o *OsWFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
 	return os.WriteFile(filepath.Join(o.root, name), data, perm)
 }
 
+// Create wraps os.OpenFile and Joins the root path to the name/path before
+// opening the file for writing.
+func (o *OsWFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	return os.OpenFile(filepath.Join(o.root, name), os.O_WRONLY|os.O_CREATE|os.O_TRUNC, perm)
+}
+
+// Chmod wraps os.Chmod and Joins the root path to the name/path before
+// calling os.Chmod
+func (o *OsWFS) Chmod(name string, mode fs.FileMode) error {
+	return os.Chmod(filepath.Join(o.root, name), mode)
+}
+
+// Symlink wraps os.Symlink and Joins the root path to newname. oldname is
+// used as is so relative link targets are preserved. An existing file or
+// link at newname is removed first.
+func (o *OsWFS) Symlink(oldname, newname string) error {
+	newname = filepath.Join(o.root, newname)
+
+	info, err := os.Lstat(newname)
+	if err == nil && !info.IsDir() {
+		err = os.Remove(newname)
+		if err != nil {
+			return err
+		}
+	}
+
+	return os.Symlink(oldname, newname)
+}
+
 func (o *OsWFS) RunHook(name string, data []byte, args []string) error {
 	tmp, err := writeHook(name, data)
 
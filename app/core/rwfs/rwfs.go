@@ -4,6 +4,7 @@ package rwfs
 
 import (
 	"errors"
+	"io"
 	"io/fs"
 )
 
@@ -14,10 +15,19 @@ var ErrHooksNotSupported = errors.New("hooks not supported")
 type ReadFS = fs.FS
 
 // WriteFS is a file system that can be used to read and write files.
-// It is a alias for fs.FS that also implements Mkdir, MkdirAll and Create.
+// It is a alias for fs.FS that also implements MkdirAll, WriteFile, Create,
+// Chmod and Symlink.
 type WriteFS interface {
 	fs.FS
 	MkdirAll(path string, perm fs.FileMode) error
 	WriteFile(name string, data []byte, perm fs.FileMode) error
+	// Create opens a file for streaming writes, truncating it if it already
+	// exists. The file is only guaranteed to be written once Close returns.
+	Create(name string, perm fs.FileMode) (io.WriteCloser, error)
+	// Chmod changes the permission bits of an existing file.
+	Chmod(name string, mode fs.FileMode) error
+	// Symlink creates newname as a symbolic link to oldname, replacing any
+	// existing file at newname.
+	Symlink(oldname, newname string) error
 	RunHook(name string, data []byte, args []string) error
 }
@@ -0,0 +1,91 @@
+package rwfs
+
+import (
+	"errors"
+	"io"
+	"io/fs"
+)
+
+var _ WriteFS = &TeeWFS{}
+
+// TeeWFS is a WriteFS that writes to a destination file system and records
+// every write in a copy. Reads and hooks only use the destination, so the
+// copy holds exactly the files written through the TeeWFS.
+type TeeWFS struct {
+	WriteFS
+	copy WriteFS
+}
+
+// NewTeeWFS returns a TeeWFS writing to dst and copy.
+func NewTeeWFS(dst, copy WriteFS) *TeeWFS {
+	return &TeeWFS{WriteFS: dst, copy: copy}
+}
+
+func (t *TeeWFS) MkdirAll(path string, perm fs.FileMode) error {
+	err := t.WriteFS.MkdirAll(path, perm)
+	if err != nil {
+		return err
+	}
+
+	return t.copy.MkdirAll(path, perm)
+}
+
+func (t *TeeWFS) WriteFile(name string, data []byte, perm fs.FileMode) error {
+	err := t.WriteFS.WriteFile(name, data, perm)
+	if err != nil {
+		return err
+	}
+
+	return t.copy.WriteFile(name, data, perm)
+}
+
+func (t *TeeWFS) Create(name string, perm fs.FileMode) (io.WriteCloser, error) {
+	dst, err := t.WriteFS.Create(name, perm)
+	if err != nil {
+		return nil, err
+	}
+
+	cp, err := t.copy.Create(name, perm)
+	if err != nil {
+		return nil, errors.Join(err, dst.Close())
+	}
+
+	return &teeWriter{Writer: io.MultiWriter(dst, cp), closers: []io.Closer{dst, cp}}, nil
+}
+
+// Chmod changes the mode in both file systems, files that were not written
+// through the TeeWFS are missing from the copy and ignored.
+func (t *TeeWFS) Chmod(name string, mode fs.FileMode) error {
+	err := t.WriteFS.Chmod(name, mode)
+	if err != nil {
+		return err
+	}
+
+	err = t.copy.Chmod(name, mode)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+func (t *TeeWFS) Symlink(oldname, newname string) error {
+	err := t.WriteFS.Symlink(oldname, newname)
+	if err != nil {
+		return err
+	}
+
+	return t.copy.Symlink(oldname, newname)
+}
+
+type teeWriter struct {
+	io.Writer
+	closers []io.Closer
+}
+
+func (w *teeWriter) Close() error {
+	var errs []error
+	for _, c := range w.closers {
+		errs = append(errs, c.Close())
+	}
+	return errors.Join(errs...)
+}
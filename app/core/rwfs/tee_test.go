@@ -0,0 +1,51 @@
+package rwfs
+
+import (
+	"io/fs"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeeWFS(t *testing.T) {
+	dst := NewMemoryWFS()
+	require.NoError(t, dst.WriteFile("existing.txt", []byte("old"), 0o644))
+
+	cp := NewMemoryWFS()
+	tee := NewTeeWFS(dst, cp)
+
+	require.NoError(t, tee.MkdirAll("cmd", 0o755))
+	require.NoError(t, tee.WriteFile("cmd/main.go", []byte("package main\n"), 0o644))
+	require.NoError(t, tee.Chmod("existing.txt", 0o600), "files missing from the copy are ignored")
+
+	w, err := tee.Create("large.bin", 0o644)
+	require.NoError(t, err)
+	_, err = w.Write([]byte("data"))
+	require.NoError(t, err)
+	require.NoError(t, w.Close())
+
+	require.NoError(t, tee.Symlink("cmd/main.go", "main.go"))
+
+	data, err := fs.ReadFile(tee, "existing.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "old", string(data), "reads use the destination")
+
+	for _, fsys := range []fs.FS{dst, cp} {
+		data, err := fs.ReadFile(fsys, "large.bin")
+		require.NoError(t, err)
+		assert.Equal(t, "data", string(data))
+	}
+
+	written := []string{}
+	err = fs.WalkDir(cp, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		written = append(written, p)
+		return nil
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"cmd/main.go", "large.bin", "main.go"}, written)
+}
@@ -0,0 +1,427 @@
+package lsp
+
+import (
+	"fmt"
+	"io/fs"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/scaffold"
+)
+
+var (
+	partialPattern  = regexp.MustCompile(`partial\s+"([^"]*)$`)
+	scaffoldPattern = regexp.MustCompile(`\$?\.Scaffold\.(\w*)$`)
+	computedPattern = regexp.MustCompile(`\$?\.Computed\.(\w*)$`)
+	eachPattern     = regexp.MustCompile(`\$?\.Each\.(\w*)$`)
+	dotPattern      = regexp.MustCompile(`(?:^|[^\w.$)\]])\$?\.(\w*)$`)
+	funcPattern     = regexp.MustCompile(`(?:^|[\s(|])(\w*)$`)
+
+	partialCallPattern = regexp.MustCompile(`partial\s+"([^"]+)"`)
+)
+
+// topLevelVars are the variables available to every template.
+var topLevelVars = map[string]string{
+	"Project":       "The name of the project",
+	"ProjectKebab":  "The kebab case version of the project name",
+	"ProjectSnake":  "The snake case version of the project name",
+	"ProjectCamel":  "The camel case version of the project name",
+	"ProjectPascal": "The pascal case version of the project name",
+	"Scaffold":      "The answers to the scaffold's questions",
+	"Computed":      "The computed values of the scaffold",
+	"Each":          "The current item of an each expansion",
+}
+
+var eachVars = map[string]string{
+	"Item":   "The current item",
+	"Index":  "The zero-based index of the item",
+	"Parent": "The .Each context of the enclosing expansion",
+}
+
+// funcDocs documents the text/template builtins and the functions added by
+// the engine. Other functions are provided by sprout.
+var funcDocs = map[string]string{
+	"and":        "Returns the boolean AND of its arguments by returning the first empty argument or the last argument.",
+	"or":         "Returns the boolean OR of its arguments by returning the first non-empty argument or the last argument.",
+	"not":        "Returns the boolean negation of its single argument.",
+	"len":        "Returns the integer length of its argument.",
+	"index":      "Returns the result of indexing its first argument by the following arguments.",
+	"slice":      "Returns the result of slicing its first argument by the remaining arguments.",
+	"print":      "An alias for fmt.Sprint.",
+	"printf":     "An alias for fmt.Sprintf.",
+	"println":    "An alias for fmt.Sprintln.",
+	"html":       "Returns the escaped HTML equivalent of the textual representation of its arguments.",
+	"js":         "Returns the escaped JavaScript equivalent of the textual representation of its arguments.",
+	"urlquery":   "Returns the escaped value of the textual representation of its arguments in a form suitable for embedding in a URL query.",
+	"call":       "Returns the result of calling the first argument, which must be a function, with the remaining arguments as parameters.",
+	"eq":         "Returns the boolean truth of arg1 == arg2, or arg1 == any of the following arguments.",
+	"ne":         "Returns the boolean truth of arg1 != arg2.",
+	"lt":         "Returns the boolean truth of arg1 < arg2.",
+	"le":         "Returns the boolean truth of arg1 <= arg2.",
+	"gt":         "Returns the boolean truth of arg1 > arg2.",
+	"ge":         "Returns the boolean truth of arg1 >= arg2.",
+	"wraptmpl":   "Wraps a string in `{{` and `}}` so it can be used as a template.",
+	"isPlural":   "Returns true if the input is plural.",
+	"isSingular": "Returns true if the input is singular.",
+	"toPlural":   "Converts a singular word to its plural form.",
+	"toSingular": "Converts a plural word to its singular form.",
+	"partial":    "Renders the partial with the given name, a file in the scaffold's partials directory, with the data.",
+}
+
+// builtinFuncs are the text/template builtins, they aren't registered with
+// the engine.
+var builtinFuncs = []string{
+	"and", "call", "eq", "ge", "gt", "html", "index", "js", "le", "len", "lt",
+	"ne", "not", "or", "print", "printf", "println", "slice", "urlquery",
+}
+
+// action returns the text of the template action the cursor is in, from the
+// left delimiter to the cursor.
+func (s *Server) action(doc document, before string) (string, bool) {
+	left, right := "{{", "}}"
+
+	if doc.root != "" {
+		if p, err := s.project(doc.root); err == nil {
+			if l, r, err := p.Delims(doc.rel); err == nil {
+				left, right = l, r
+			}
+		}
+	}
+
+	open := strings.LastIndex(before, left)
+	if open == -1 || strings.LastIndex(before, right) > open {
+		return "", false
+	}
+
+	return before[open+len(left):], true
+}
+
+func (s *Server) completion(params TextDocumentPositionParams) (CompletionList, error) {
+	list := CompletionList{Items: []CompletionItem{}}
+
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return list, err
+	}
+
+	before := doc.text[:offset(doc.text, params.Position)]
+	action, ok := s.action(doc, before)
+	if !ok {
+		return list, nil
+	}
+
+	var conf *scaffold.ProjectScaffoldFile
+	if doc.root != "" {
+		if p, err := s.project(doc.root); err == nil {
+			conf = p.Conf
+		}
+	}
+
+	add := func(prefix string, item CompletionItem) {
+		if strings.HasPrefix(item.Label, prefix) {
+			list.Items = append(list.Items, item)
+		}
+	}
+
+	switch {
+	case partialPattern.MatchString(action):
+		if doc.root == "" {
+			break
+		}
+
+		prefix := partialPattern.FindStringSubmatch(action)[1]
+		_ = fs.WalkDir(s.fsys(doc.root), "partials", func(p string, d fs.DirEntry, err error) error {
+			if err != nil || d.IsDir() {
+				return nil
+			}
+
+			rel := strings.TrimPrefix(p, "partials/")
+			add(prefix, CompletionItem{Label: strings.TrimSuffix(rel, filepath.Ext(rel)), Kind: KindFile, Detail: p})
+			return nil
+		})
+	case scaffoldPattern.MatchString(action):
+		if conf == nil {
+			break
+		}
+
+		prefix := scaffoldPattern.FindStringSubmatch(action)[1]
+		for _, q := range conf.Questions {
+			add(prefix, CompletionItem{Label: q.Name, Kind: KindField, Detail: q.Title(), Documentation: markdown(questionDoc(q))})
+		}
+	case computedPattern.MatchString(action):
+		if conf == nil {
+			break
+		}
+
+		prefix := computedPattern.FindStringSubmatch(action)[1]
+		for _, name := range sortedKeys(conf.Computed) {
+			add(prefix, CompletionItem{Label: name, Kind: KindField, Detail: conf.Computed[name]})
+		}
+	case eachPattern.MatchString(action):
+		prefix := eachPattern.FindStringSubmatch(action)[1]
+		for _, name := range sortedKeys(eachVars) {
+			add(prefix, CompletionItem{Label: name, Kind: KindField, Detail: eachVars[name]})
+		}
+	case dotPattern.MatchString(action):
+		prefix := dotPattern.FindStringSubmatch(action)[1]
+		for _, name := range sortedKeys(topLevelVars) {
+			add(prefix, CompletionItem{Label: name, Kind: KindVariable, Detail: topLevelVars[name]})
+		}
+	case funcPattern.MatchString(action):
+		prefix := funcPattern.FindStringSubmatch(action)[1]
+		for _, name := range s.funcNames() {
+			add(prefix, CompletionItem{Label: name, Kind: KindFunction, Detail: s.signature(name)})
+		}
+	}
+
+	return list, nil
+}
+
+func (s *Server) hover(params TextDocumentPositionParams) (*Hover, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	off := offset(doc.text, params.Position)
+
+	start, end := off, off
+	for start > 0 && isIdentByte(doc.text[start-1]) {
+		start--
+	}
+	for end < len(doc.text) && isIdentByte(doc.text[end]) {
+		end++
+	}
+
+	if start == end {
+		return nil, nil
+	}
+
+	word := doc.text[start:end]
+	action, ok := s.action(doc, doc.text[:start])
+	if !ok {
+		return nil, nil
+	}
+
+	var contents string
+	switch {
+	case strings.HasSuffix(action, ".Scaffold."):
+		if doc.root == "" {
+			return nil, nil
+		}
+
+		p, err := s.project(doc.root)
+		if err != nil {
+			return nil, nil
+		}
+
+		for _, q := range p.Conf.Questions {
+			if q.Name == word {
+				contents = questionDoc(q)
+			}
+		}
+	case strings.HasSuffix(action, ".Computed."):
+		if doc.root == "" {
+			return nil, nil
+		}
+
+		p, err := s.project(doc.root)
+		if err != nil {
+			return nil, nil
+		}
+
+		if v, ok := p.Conf.Computed[word]; ok {
+			contents = fmt.Sprintf("**computed** `%s`\n\n```\n%s\n```", word, v)
+		}
+	case strings.HasSuffix(action, "."):
+		if d, ok := topLevelVars[word]; ok && !strings.HasSuffix(action, "..") {
+			contents = fmt.Sprintf("`.%s`\n\n%s", word, d)
+		}
+	default:
+		if s.isFunc(word) {
+			contents = s.funcDoc(word)
+		}
+	}
+
+	if contents == "" {
+		return nil, nil
+	}
+
+	return &Hover{Contents: *markdown(contents)}, nil
+}
+
+func (s *Server) definition(params TextDocumentPositionParams) ([]Location, error) {
+	doc, err := s.document(params.TextDocument.URI)
+	if err != nil {
+		return nil, err
+	}
+
+	if doc.root == "" {
+		return []Location{}, nil
+	}
+
+	off := offset(doc.text, params.Position)
+
+	lineStart := strings.LastIndexByte(doc.text[:off], '\n') + 1
+	lineEnd := strings.IndexByte(doc.text[off:], '\n')
+	if lineEnd == -1 {
+		lineEnd = len(doc.text)
+	} else {
+		lineEnd += off
+	}
+
+	line := doc.text[lineStart:lineEnd]
+	col := off - lineStart
+
+	for _, m := range partialCallPattern.FindAllStringSubmatchIndex(line, -1) {
+		if col < m[0] || col > m[1] {
+			continue
+		}
+
+		file, ok := partialFile(s.fsys(doc.root), line[m[2]:m[3]])
+		if !ok {
+			return []Location{}, nil
+		}
+
+		return []Location{{URI: pathToURI(filepath.Join(doc.root, filepath.FromSlash(file)))}}, nil
+	}
+
+	return []Location{}, nil
+}
+
+func (s *Server) funcNames() []string {
+	names := append(s.eng.FuncNames(), builtinFuncs...)
+	sort.Strings(names)
+	return names
+}
+
+func (s *Server) isFunc(name string) bool {
+	if _, ok := s.eng.Func(name); ok {
+		return true
+	}
+
+	for _, b := range builtinFuncs {
+		if b == name {
+			return true
+		}
+	}
+
+	return false
+}
+
+// genericFunc is the type sprout wraps its functions in, its signature says
+// nothing about the arguments.
+var genericFunc = reflect.TypeOf(func(...any) (any, error) { return nil, nil })
+
+// signature returns the Go signature of the function called name, or an empty
+// string when it isn't known.
+func (s *Server) signature(name string) string {
+	fn, ok := s.eng.Func(name)
+	if !ok {
+		return ""
+	}
+
+	t := reflect.TypeOf(fn)
+	if t == nil || t.Kind() != reflect.Func || t == genericFunc {
+		return ""
+	}
+
+	typeName := func(t reflect.Type) string {
+		return strings.ReplaceAll(t.String(), "interface {}", "any")
+	}
+
+	ins := make([]string, t.NumIn())
+	for i := range ins {
+		if t.IsVariadic() && i == t.NumIn()-1 {
+			ins[i] = "..." + typeName(t.In(i).Elem())
+			continue
+		}
+		ins[i] = typeName(t.In(i))
+	}
+
+	outs := make([]string, t.NumOut())
+	for i := range outs {
+		outs[i] = typeName(t.Out(i))
+	}
+
+	sig := fmt.Sprintf("func %s(%s)", name, strings.Join(ins, ", "))
+	switch len(outs) {
+	case 0:
+		return sig
+	case 1:
+		return sig + " " + outs[0]
+	default:
+		return sig + " (" + strings.Join(outs, ", ") + ")"
+	}
+}
+
+func (s *Server) funcDoc(name string) string {
+	doc, ok := funcDocs[name]
+	if !ok {
+		doc = "Provided by sprout, see the [sprout documentation](https://docs.atom.codes/sprout)."
+	}
+
+	sig := s.signature(name)
+	if sig == "" {
+		return fmt.Sprintf("**%s**\n\n%s", name, doc)
+	}
+
+	return fmt.Sprintf("```go\n%s\n```\n\n%s", sig, doc)
+}
+
+// questionDoc describes a question for hover and completion documentation.
+func questionDoc(q scaffold.Question) string {
+	kind := "input"
+	switch {
+	case q.Prompt.IsConfirm():
+		kind = "confirm"
+	case q.Prompt.IsMultiSelect():
+		kind = "multi select"
+	case q.Prompt.IsSelect():
+		kind = "select"
+	case q.Prompt.IsObjectLoop():
+		kind = "object loop"
+	case q.Prompt.IsInputLoop():
+		kind = "input loop"
+	case q.Prompt.IsTextInput():
+		kind = "text"
+	}
+
+	b := &strings.Builder{}
+	fmt.Fprintf(b, "**%s** `.Scaffold.%s`\n\n%s", kind, q.Name, q.Title())
+
+	if d := q.Description(); d != "" {
+		fmt.Fprintf(b, "\n\n%s", d)
+	}
+
+	if q.Prompt.Options != nil {
+		fmt.Fprintf(b, "\n\nOptions: %s", strings.Join(*q.Prompt.Options, ", "))
+	}
+
+	if q.Prompt.Default != nil {
+		fmt.Fprintf(b, "\n\nDefault: `%v`", q.Prompt.Default)
+	}
+
+	return b.String()
+}
+
+func markdown(s string) *MarkupContent {
+	return &MarkupContent{Kind: "markdown", Value: s}
+}
+
+func isIdentByte(b byte) bool {
+	return b == '_' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,50 @@
+package lsp
+
+import (
+	"bytes"
+	"io/fs"
+	"time"
+)
+
+// overlayFS serves the unsaved contents of open documents in place of the
+// files on disk. Only existing files are replaced, directory listings come
+// from the base file system.
+type overlayFS struct {
+	base  fs.FS
+	files map[string][]byte
+}
+
+func (o overlayFS) Open(name string) (fs.File, error) {
+	data, ok := o.files[name]
+	if !ok {
+		return o.base.Open(name)
+	}
+
+	info, err := fs.Stat(o.base, name)
+	if err != nil {
+		return nil, err
+	}
+
+	return &overlayFile{Reader: bytes.NewReader(data), info: overlayInfo{FileInfo: info, size: int64(len(data))}}, nil
+}
+
+func (o overlayFS) ReadDir(name string) ([]fs.DirEntry, error) {
+	return fs.ReadDir(o.base, name)
+}
+
+type overlayFile struct {
+	*bytes.Reader
+	info overlayInfo
+}
+
+func (f *overlayFile) Stat() (fs.FileInfo, error) { return f.info, nil }
+func (f *overlayFile) Close() error               { return nil }
+
+// overlayInfo reports the size of the unsaved contents.
+type overlayInfo struct {
+	fs.FileInfo
+	size int64
+}
+
+func (i overlayInfo) Size() int64        { return i.size }
+func (i overlayInfo) ModTime() time.Time { return time.Now() }
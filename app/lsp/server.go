@@ -0,0 +1,442 @@
+// Package lsp implements a language server for scaffolds. It publishes lint
+// diagnostics and provides completion, hover and go to definition in
+// scaffold files and templates.
+package lsp
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/rs/zerolog/log"
+)
+
+// yamlLinePattern matches the line number in yaml errors.
+var yamlLinePattern = regexp.MustCompile(`line (\d+)`)
+
+// Server is a language server speaking the protocol over a single
+// connection.
+type Server struct {
+	eng     *engine.Engine
+	version string
+	conn    *conn
+
+	// docs holds the contents of open documents by URI.
+	docs map[string]string
+	// published holds the URIs with diagnostics, they are cleared when the
+	// problems are fixed.
+	published map[string]bool
+}
+
+func New(eng *engine.Engine, version string) *Server {
+	return &Server{
+		eng:       eng,
+		version:   version,
+		docs:      map[string]string{},
+		published: map[string]bool{},
+	}
+}
+
+// Run serves requests read from r and writes responses to w until the
+// client sends exit or closes r.
+func (s *Server) Run(r io.Reader, w io.Writer) error {
+	s.conn = newConn(r, w)
+
+	for {
+		req, err := s.conn.read()
+		if err != nil {
+			var rerr *responseError
+			switch {
+			case errors.Is(err, io.EOF):
+				return nil
+			case errors.As(err, &rerr):
+				_ = s.conn.reply(json.RawMessage("null"), nil, rerr)
+				continue
+			default:
+				return err
+			}
+		}
+
+		if req.Method == "exit" {
+			return nil
+		}
+
+		result, err := s.handle(req)
+		if req.isNotification() {
+			if err != nil {
+				log.Debug().Err(err).Str("method", req.Method).Msg("lsp notification failed")
+			}
+			continue
+		}
+
+		err = s.conn.reply(req.ID, result, err)
+		if err != nil {
+			return err
+		}
+	}
+}
+
+func (s *Server) handle(req request) (any, error) {
+	decode := func(v any) error {
+		err := json.Unmarshal(req.Params, v)
+		if err != nil {
+			return &responseError{Code: codeInvalidParams, Message: err.Error()}
+		}
+		return nil
+	}
+
+	switch req.Method {
+	case "initialize":
+		return InitializeResult{
+			Capabilities: ServerCapabilities{
+				TextDocumentSync:   1,
+				CompletionProvider: CompletionOptions{TriggerCharacters: []string{".", "\"", " "}},
+				HoverProvider:      true,
+				DefinitionProvider: true,
+			},
+			ServerInfo: ServerInfo{Name: "scaffold", Version: s.version},
+		}, nil
+	case "initialized", "shutdown":
+		return nil, nil
+	case "textDocument/didOpen":
+		var params DidOpenTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		s.docs[params.TextDocument.URI] = params.TextDocument.Text
+		return nil, s.lint(params.TextDocument.URI)
+	case "textDocument/didChange":
+		var params DidChangeTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		if n := len(params.ContentChanges); n > 0 {
+			s.docs[params.TextDocument.URI] = params.ContentChanges[n-1].Text
+		}
+		return nil, s.lint(params.TextDocument.URI)
+	case "textDocument/didSave":
+		var params DidSaveTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		return nil, s.lint(params.TextDocument.URI)
+	case "textDocument/didClose":
+		var params DidCloseTextDocumentParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		delete(s.docs, params.TextDocument.URI)
+		return nil, s.lint(params.TextDocument.URI)
+	case "textDocument/completion":
+		var params TextDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		return s.completion(params)
+	case "textDocument/hover":
+		var params TextDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		return s.hover(params)
+	case "textDocument/definition":
+		var params TextDocumentPositionParams
+		if err := decode(&params); err != nil {
+			return nil, err
+		}
+
+		return s.definition(params)
+	default:
+		return nil, &responseError{Code: codeMethodNotFound, Message: "method not found: " + req.Method}
+	}
+}
+
+// document is an open or on disk file in a scaffold.
+type document struct {
+	// root is the directory of the scaffold, empty when the file is not in
+	// a scaffold.
+	root string
+	// rel is the slash separated path of the file in the scaffold.
+	rel  string
+	text string
+}
+
+func uriToPath(uri string) (string, error) {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "", err
+	}
+
+	if u.Scheme != "file" {
+		return "", fmt.Errorf("unsupported uri scheme: %s", u.Scheme)
+	}
+
+	return filepath.FromSlash(u.Path), nil
+}
+
+func pathToURI(p string) string {
+	return (&url.URL{Scheme: "file", Path: filepath.ToSlash(p)}).String()
+}
+
+// findRoot returns the closest parent directory of p holding a scaffold
+// file.
+func findRoot(p string) string {
+	dir := filepath.Dir(p)
+	for {
+		for _, name := range []string{"scaffold.yaml", "scaffold.yml"} {
+			if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+				return dir
+			}
+		}
+
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return ""
+		}
+		dir = parent
+	}
+}
+
+// document returns the contents and scaffold of the file at uri.
+func (s *Server) document(uri string) (document, error) {
+	p, err := uriToPath(uri)
+	if err != nil {
+		return document{}, err
+	}
+
+	doc := document{root: findRoot(p)}
+
+	text, ok := s.docs[uri]
+	if !ok {
+		data, err := os.ReadFile(p)
+		if err != nil {
+			return document{}, err
+		}
+		text = string(data)
+	}
+	doc.text = text
+
+	if doc.root != "" {
+		rel, err := filepath.Rel(doc.root, p)
+		if err != nil {
+			return document{}, err
+		}
+		doc.rel = filepath.ToSlash(rel)
+	}
+
+	return doc, nil
+}
+
+// fsys returns the file system of the scaffold at root with the contents of
+// open documents.
+func (s *Server) fsys(root string) fs.FS {
+	files := map[string][]byte{}
+	for uri, text := range s.docs {
+		p, err := uriToPath(uri)
+		if err != nil {
+			continue
+		}
+
+		rel, err := filepath.Rel(root, p)
+		if err != nil || strings.HasPrefix(rel, "..") {
+			continue
+		}
+
+		files[filepath.ToSlash(rel)] = []byte(text)
+	}
+
+	return overlayFS{base: os.DirFS(root), files: files}
+}
+
+// project loads the scaffold at root.
+func (s *Server) project(root string) (*scaffold.Project, error) {
+	return scaffold.LoadProject(s.fsys(root), scaffold.Options{})
+}
+
+// confFile returns the name of the scaffold file at root.
+func confFile(root string) string {
+	if _, err := os.Stat(filepath.Join(root, "scaffold.yml")); err == nil {
+		return "scaffold.yml"
+	}
+	return "scaffold.yaml"
+}
+
+// lint publishes the diagnostics of the scaffold containing uri.
+func (s *Server) lint(uri string) error {
+	p, err := uriToPath(uri)
+	if err != nil {
+		return err
+	}
+
+	root := findRoot(p)
+	if root == "" {
+		return nil
+	}
+
+	fsys := s.fsys(root)
+	conf := confFile(root)
+
+	var diags []scaffold.Diagnostic
+	project, err := scaffold.LoadProject(fsys, scaffold.Options{})
+	if err != nil {
+		d := scaffold.NewDiagnostic(scaffold.RuleInvalidConfig, conf, err.Error())
+		if m := yamlLinePattern.FindStringSubmatch(err.Error()); m != nil {
+			d.Line, _ = strconv.Atoi(m[1])
+		}
+		diags = append(diags, d)
+	} else {
+		data, _ := fs.ReadFile(fsys, conf)
+		pos, _ := scaffold.ReadConfPositions(data)
+		diags = scaffold.FilterDiagnostics(scaffold.LintProject(s.eng, project), project.Conf.Lint, conf, pos)
+	}
+
+	byURI := map[string][]Diagnostic{}
+	for _, d := range diags {
+		file := filepath.Join(root, filepath.FromSlash(d.File))
+		fileURI := pathToURI(file)
+
+		data, _ := fs.ReadFile(fsys, d.File)
+		byURI[fileURI] = append(byURI[fileURI], toDiagnostic(d, string(data)))
+	}
+
+	// Clear the diagnostics of files in this scaffold that no longer have
+	// problems.
+	rootURI := pathToURI(root) + "/"
+	for published := range s.published {
+		if _, ok := byURI[published]; !ok && strings.HasPrefix(published, rootURI) {
+			byURI[published] = []Diagnostic{}
+		}
+	}
+
+	for fileURI, fileDiags := range byURI {
+		err := s.conn.notify("textDocument/publishDiagnostics", PublishDiagnosticsParams{URI: fileURI, Diagnostics: fileDiags})
+		if err != nil {
+			return err
+		}
+
+		if len(fileDiags) == 0 {
+			delete(s.published, fileURI)
+		} else {
+			s.published[fileURI] = true
+		}
+	}
+
+	return nil
+}
+
+// toDiagnostic converts a lint diagnostic, positions are converted to UTF-16
+// offsets using the text of the file.
+func toDiagnostic(d scaffold.Diagnostic, text string) Diagnostic {
+	out := Diagnostic{
+		Severity: SeverityError,
+		Code:     d.Rule,
+		Source:   "scaffold",
+		Message:  d.Message,
+	}
+
+	if d.Severity == scaffold.SeverityWarning {
+		out.Severity = SeverityWarning
+	}
+
+	if d.Line == 0 {
+		return out
+	}
+
+	lines := strings.Split(text, "\n")
+	line := d.Line - 1
+	if line >= len(lines) {
+		out.Range.Start.Line, out.Range.End.Line = line, line
+		return out
+	}
+
+	lineText := strings.TrimSuffix(lines[line], "\r")
+	out.Range = Range{Start: Position{Line: line}, End: Position{Line: line, Character: utf16Len(lineText)}}
+
+	if d.Column > 0 {
+		start := min(d.Column-1, len(lineText))
+		end := start
+		for end < len(lineText) && isWordByte(lineText[end]) {
+			end++
+		}
+		if end == start && end < len(lineText) {
+			end++
+		}
+
+		out.Range.Start.Character = utf16Len(lineText[:start])
+		out.Range.End.Character = utf16Len(lineText[:end])
+	}
+
+	return out
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || b == '.' || b >= '0' && b <= '9' || b >= 'a' && b <= 'z' || b >= 'A' && b <= 'Z'
+}
+
+func utf16Len(s string) int {
+	n := 0
+	for _, r := range s {
+		n += len(utf16.Encode([]rune{r}))
+	}
+	return n
+}
+
+// offset converts a position to a byte offset in text.
+func offset(text string, pos Position) int {
+	off := 0
+	for i := 0; i < pos.Line; i++ {
+		idx := strings.IndexByte(text[off:], '\n')
+		if idx == -1 {
+			return len(text)
+		}
+		off += idx + 1
+	}
+
+	units := 0
+	for off < len(text) && units < pos.Character && text[off] != '\n' {
+		r, size := utf8.DecodeRuneInString(text[off:])
+		units += len(utf16.Encode([]rune{r}))
+		off += size
+	}
+
+	return off
+}
+
+// partialFile returns the path of the partial called name in fsys.
+func partialFile(fsys fs.FS, name string) (string, bool) {
+	var found string
+
+	_ = fs.WalkDir(fsys, "partials", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return nil
+		}
+
+		rel := strings.TrimPrefix(p, "partials/")
+		if strings.TrimSuffix(rel, path.Ext(rel)) == name {
+			found = p
+			return fs.SkipAll
+		}
+		return nil
+	})
+
+	return found, found != ""
+}
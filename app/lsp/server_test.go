@@ -0,0 +1,224 @@
+package lsp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/scaffold"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+
+	for name, data := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		require.NoError(t, os.MkdirAll(filepath.Dir(p), 0o755))
+		require.NoError(t, os.WriteFile(p, []byte(data), 0o644))
+	}
+}
+
+// session builds the input for a server from requests, requests with an id
+// expect a response.
+type session struct {
+	buf bytes.Buffer
+	id  int
+}
+
+func (s *session) send(method string, params any, call bool) {
+	msg := map[string]any{"jsonrpc": "2.0", "method": method, "params": params}
+	if call {
+		s.id++
+		msg["id"] = s.id
+	}
+
+	body, _ := json.Marshal(msg)
+	fmt.Fprintf(&s.buf, "Content-Length: %d\r\n\r\n%s", len(body), body)
+}
+
+type message struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  *responseError  `json:"error"`
+}
+
+func readMessages(t *testing.T, r io.Reader) []message {
+	t.Helper()
+
+	br := bufio.NewReader(r)
+	tr := textproto.NewReader(br)
+
+	var msgs []message
+	for {
+		header, err := tr.ReadMIMEHeader()
+		if err == io.EOF {
+			return msgs
+		}
+		require.NoError(t, err)
+
+		n, err := strconv.Atoi(header.Get("Content-Length"))
+		require.NoError(t, err)
+
+		body := make([]byte, n)
+		_, err = io.ReadFull(br, body)
+		require.NoError(t, err)
+
+		var msg message
+		require.NoError(t, json.Unmarshal(body, &msg))
+		msgs = append(msgs, msg)
+	}
+}
+
+func TestServer(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"scaffold.yaml": `questions:
+  - name: description
+    prompt:
+      message: Project description
+  - name: license
+    prompt:
+      message: License
+      options: [MIT, Apache-2.0]
+      default: MIT
+`,
+		"partials/header.tmpl":     "// {{ .Project }}\n",
+		"{{ .Project }}/README.md": "{{ .Scaffold.description }}\n",
+	})
+
+	readme := pathToURI(filepath.Join(root, "{{ .Project }}", "README.md"))
+	text := "{{ partial \"header\" . }}\n{{ .Scaffold.missing }} {{ .Scaffold.description }}\n{{ toPlural .Project }}\n"
+
+	pos := func(line, char int) map[string]any {
+		return map[string]any{
+			"textDocument": map[string]any{"uri": readme},
+			"position":     map[string]any{"line": line, "character": char},
+		}
+	}
+
+	s := &session{}
+	s.send("initialize", map[string]any{}, true)
+	s.send("initialized", map[string]any{}, false)
+	s.send("textDocument/didOpen", map[string]any{
+		"textDocument": map[string]any{"uri": readme, "languageId": "markdown", "version": 1, "text": text},
+	}, false)
+	s.send("textDocument/completion", pos(1, 37), true)
+	s.send("textDocument/completion", pos(2, 5), true)
+	s.send("textDocument/hover", pos(2, 4), true)
+	s.send("textDocument/hover", pos(1, 40), true)
+	s.send("textDocument/definition", pos(0, 14), true)
+	s.send("textDocument/unknown", map[string]any{}, true)
+	s.send("shutdown", nil, true)
+	s.send("exit", nil, false)
+
+	out := &bytes.Buffer{}
+	require.NoError(t, New(engine.New(), "test").Run(&s.buf, out))
+
+	responses := map[int]message{}
+	diagnostics := map[string][]Diagnostic{}
+	for _, msg := range readMessages(t, out) {
+		switch {
+		case msg.ID != nil:
+			responses[*msg.ID] = msg
+		case msg.Method == "textDocument/publishDiagnostics":
+			var params PublishDiagnosticsParams
+			require.NoError(t, json.Unmarshal(msg.Params, &params))
+			diagnostics[params.URI] = params.Diagnostics
+		}
+	}
+
+	t.Run("initialize", func(t *testing.T) {
+		var result InitializeResult
+		require.NoError(t, json.Unmarshal(responses[1].Result, &result))
+		assert.Equal(t, "scaffold", result.ServerInfo.Name)
+		assert.True(t, result.Capabilities.HoverProvider)
+	})
+
+	t.Run("diagnostics", func(t *testing.T) {
+		diags := diagnostics[readme]
+		require.Len(t, diags, 1)
+		assert.Equal(t, scaffold.RuleUndefinedQuestion, diags[0].Code)
+		assert.Equal(t, Range{Start: Position{Line: 1, Character: 12}, End: Position{Line: 1, Character: 20}}, diags[0].Range)
+
+		conf := diagnostics[pathToURI(filepath.Join(root, "scaffold.yaml"))]
+		require.Len(t, conf, 1)
+		assert.Equal(t, scaffold.RuleUnusedQuestion, conf[0].Code)
+		assert.Equal(t, SeverityWarning, conf[0].Severity)
+	})
+
+	labels := func(id int) []string {
+		var list CompletionList
+		require.NoError(t, json.Unmarshal(responses[id].Result, &list))
+
+		out := []string{}
+		for _, item := range list.Items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+
+	t.Run("completion", func(t *testing.T) {
+		assert.Equal(t, []string{"description", "license"}, labels(2))
+		assert.Contains(t, labels(3), "toPlural")
+		assert.NotContains(t, labels(3), "lower")
+	})
+
+	t.Run("hover", func(t *testing.T) {
+		var fn Hover
+		require.NoError(t, json.Unmarshal(responses[4].Result, &fn))
+		assert.Contains(t, fn.Contents.Value, "func toPlural(string) string")
+
+		var q Hover
+		require.NoError(t, json.Unmarshal(responses[5].Result, &q))
+		assert.Contains(t, q.Contents.Value, "`.Scaffold.description`")
+		assert.Contains(t, q.Contents.Value, "Project description")
+	})
+
+	t.Run("definition", func(t *testing.T) {
+		var locs []Location
+		require.NoError(t, json.Unmarshal(responses[6].Result, &locs))
+		require.Len(t, locs, 1)
+		assert.Equal(t, pathToURI(filepath.Join(root, "partials", "header.tmpl")), locs[0].URI)
+	})
+
+	t.Run("unknown method", func(t *testing.T) {
+		require.NotNil(t, responses[7].Error)
+		assert.Equal(t, codeMethodNotFound, responses[7].Error.Code)
+	})
+
+	t.Run("shutdown", func(t *testing.T) {
+		assert.Nil(t, responses[8].Error)
+	})
+}
+
+func TestOffset(t *testing.T) {
+	text := "ab\nc€d\n"
+
+	tests := []struct {
+		pos  Position
+		want int
+	}{
+		{Position{Line: 0, Character: 0}, 0},
+		{Position{Line: 0, Character: 2}, 2},
+		{Position{Line: 0, Character: 9}, 2},
+		{Position{Line: 1, Character: 2}, 7},
+		{Position{Line: 1, Character: 3}, 8},
+		{Position{Line: 5, Character: 0}, len(text)},
+	}
+
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, offset(text, tt.pos), "%+v", tt.pos)
+	}
+}
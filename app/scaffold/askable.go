@@ -1,6 +1,9 @@
 package scaffold
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
 	"github.com/charmbracelet/huh"
@@ -8,11 +11,29 @@ import (
 	"github.com/hay-kot/scaffold/internal/styles"
 )
 
+// runHuhForm runs a question form, tests replace it to answer forms without a
+// terminal.
+var runHuhForm = (*huh.Form).Run
+
 type Askable struct {
 	Name  string
 	Key   string
 	Hook  func(vars engine.Vars) error
 	Field huh.Field
+
+	// run and value are set for askables that are not a single form field,
+	// such as object loops, which run their own forms.
+	run   func() error
+	value func() any
+}
+
+// GetValue returns the current value of the askable.
+func (a *Askable) GetValue() any {
+	if a.value != nil {
+		return a.value()
+	}
+
+	return a.Field.GetValue()
 }
 
 func NewAskable(name string, key string, field huh.Field, fn func(vars engine.Vars) error) *Askable {
@@ -33,7 +54,7 @@ func (a *Askable) String() string {
 	bldr.WriteString(styles.Bold(a.Name))
 	bldr.WriteString(" ")
 
-	val := a.Field.GetValue()
+	val := a.GetValue()
 
 	switch v := val.(type) {
 	case string:
@@ -64,6 +85,24 @@ func (a *Askable) String() string {
 			bldr.WriteString("      - ")
 			bldr.WriteString(styles.Base(v))
 		}
+	case []map[string]any:
+		if len(v) == 0 {
+			return ""
+		}
+
+		for _, item := range v {
+			keys := slices.Sorted(maps.Keys(item))
+			for i, k := range keys {
+				bldr.WriteString("\n")
+				if i == 0 {
+					bldr.WriteString("      - ")
+				} else {
+					bldr.WriteString("        ")
+				}
+				bldr.WriteString(styles.Light(k + ": "))
+				bldr.WriteString(styles.Base(fmt.Sprint(item[k])))
+			}
+		}
 	case bool:
 		if v {
 			bldr.WriteString(styles.Base("true"))
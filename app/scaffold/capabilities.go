@@ -0,0 +1,138 @@
+package scaffold
+
+// Capability is a scaffold file feature that not every release of scaffold
+// supports. Scaffolds list the capabilities they depend on in
+// metadata.requires, releases that don't know a required capability refuse
+// to run the scaffold and ask for an upgrade.
+type Capability struct {
+	ID          string
+	Description string
+	// Keys are the scaffold file keys that use the capability.
+	Keys []string
+	// Since is the first release supporting the capability, lint warns when
+	// metadata.minimum_version is older.
+	Since string
+
+	used func(conf *ProjectScaffoldFile) bool
+}
+
+// nextRelease is the release that ships the capabilities added since the last
+// tag. It's bumped by the release process when the next version is tagged.
+const nextRelease = "0.14.0"
+
+// Capabilities lists the capabilities known to this release.
+var Capabilities = []Capability{
+	{
+		ID:          "features",
+		Description: "Files rendered only when a feature is enabled",
+		Keys:        []string{"features"},
+		Since:       "0.3.0",
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Features) > 0 },
+	},
+	{
+		ID:          "inject",
+		Description: "Injecting templates into existing files",
+		Keys:        []string{"inject"},
+		Since:       "0.3.0",
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Inject) > 0 },
+	},
+	// delimiters were added after 0.3.0 and before 0.6.1, the earliest
+	// candidate is used so scaffolds declaring a release that has them aren't
+	// warned.
+	{
+		ID:          "delimiters",
+		Description: "Custom template delimiters per glob",
+		Keys:        []string{"delimiters"},
+		Since:       "0.4.0",
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Delimiters) > 0 },
+	},
+	{
+		ID:          "each",
+		Description: "Multi-file expansion of list variables",
+		Keys:        []string{"each"},
+		Since:       "0.13.1",
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Each) > 0 },
+	},
+	{
+		ID:          "object-loops",
+		Description: "Looped questions producing lists of objects",
+		Keys:        []string{"questions[].prompt.fields"},
+		Since:       nextRelease,
+		used: func(c *ProjectScaffoldFile) bool {
+			for _, q := range c.Questions {
+				if len(q.Prompt.Fields) > 0 {
+					return true
+				}
+			}
+			return false
+		},
+	},
+	{
+		ID:          "empty-files",
+		Description: "Keeping files and directories that render empty",
+		Keys:        []string{"empty", "keep_empty"},
+		Since:       nextRelease,
+		used:        func(c *ProjectScaffoldFile) bool { return c.Empty != "" || len(c.KeepEmpty) > 0 },
+	},
+	{
+		ID:          "formatters",
+		Description: "Built-in formatters applied to rendered files",
+		Keys:        []string{"format"},
+		Since:       nextRelease,
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Format) > 0 },
+	},
+	{
+		ID:          "whitespace-control",
+		Description: "Trimming whitespace around template actions",
+		Keys:        []string{"whitespace"},
+		Since:       nextRelease,
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Whitespace) > 0 },
+	},
+	{
+		ID:          "output-checks",
+		Description: "Assertions about the rendered output",
+		Keys:        []string{"checks"},
+		Since:       nextRelease,
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Checks) > 0 },
+	},
+	{
+		ID:          "lint-config",
+		Description: "Disabling lint rules in the scaffold file",
+		Keys:        []string{"lint"},
+		Since:       nextRelease,
+		used:        func(c *ProjectScaffoldFile) bool { return len(c.Lint.Disable) > 0 },
+	},
+	{
+		ID:          "version-constraints",
+		Description: "Version constraints, maximum versions and required capabilities",
+		Keys:        []string{"metadata.constraints", "metadata.maximum_version", "metadata.requires"},
+		Since:       nextRelease,
+		used: func(c *ProjectScaffoldFile) bool {
+			m := c.Metadata
+			return m.Constraints != "" || m.MaximumVersion != "" || len(m.Requires) > 0
+		},
+	},
+}
+
+// LookupCapability returns the capability with the given ID.
+func LookupCapability(id string) (Capability, bool) {
+	for _, c := range Capabilities {
+		if c.ID == id {
+			return c, true
+		}
+	}
+
+	return Capability{}, false
+}
+
+// UsedCapabilities returns the capabilities used by the scaffold file.
+func UsedCapabilities(conf *ProjectScaffoldFile) []Capability {
+	var out []Capability
+	for _, c := range Capabilities {
+		if c.used(conf) {
+			out = append(out, c)
+		}
+	}
+
+	return out
+}
@@ -0,0 +1,118 @@
+package scaffold
+
+import (
+	"testing"
+
+	"github.com/hashicorp/go-version"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUsedCapabilities(t *testing.T) {
+	conf := &ProjectScaffoldFile{
+		Each:   []EachConfig{{Var: "services"}},
+		Format: []Format{{Glob: "*.go", Formatter: "go"}},
+		Questions: []Question{
+			{Name: "models", Prompt: AnyPrompt{Loop: true, Fields: []Question{{Name: "name"}}}},
+		},
+		Metadata: Metadata{Constraints: ">=1.0"},
+	}
+
+	ids := []string{}
+	for _, c := range UsedCapabilities(conf) {
+		ids = append(ids, c.ID)
+	}
+
+	assert.Equal(t, []string{"each", "object-loops", "formatters", "version-constraints"}, ids)
+	assert.Empty(t, UsedCapabilities(&ProjectScaffoldFile{}))
+}
+
+func TestCapabilities_Unique(t *testing.T) {
+	seen := map[string]bool{}
+	for _, c := range Capabilities {
+		require.False(t, seen[c.ID], "duplicate capability %s", c.ID)
+		seen[c.ID] = true
+
+		assert.NotEmpty(t, c.Keys, c.ID)
+		assert.NotNil(t, c.used, c.ID)
+
+		_, err := version.NewVersion(c.Since)
+		require.NoError(t, err, "capability %s has no release", c.ID)
+
+		got, ok := LookupCapability(c.ID)
+		require.True(t, ok)
+		assert.Equal(t, c.ID, got.ID)
+	}
+}
+
+func TestLintMinimumVersion(t *testing.T) {
+	caps := []Capability{
+		{ID: "formatters", Keys: []string{"format"}, Since: "1.2.0", used: func(c *ProjectScaffoldFile) bool { return len(c.Format) > 0 }},
+		{ID: "checks", Keys: []string{"checks"}, used: func(c *ProjectScaffoldFile) bool { return len(c.Checks) > 0 }},
+	}
+
+	format := []Format{{Glob: "*.go", Formatter: "go"}}
+
+	tests := []struct {
+		name string
+		conf ProjectScaffoldFile
+		want []string
+	}{
+		{name: "unused", conf: ProjectScaffoldFile{}},
+		{name: "supported", conf: ProjectScaffoldFile{Format: format, Metadata: Metadata{MinimumVersion: "1.2.0"}}},
+		{name: "required", conf: ProjectScaffoldFile{Format: format, Metadata: Metadata{Requires: []string{"formatters"}}}},
+		{name: "unreleased", conf: ProjectScaffoldFile{Checks: []Check{{Exists: []string{"a"}}}}},
+		{
+			name: "older minimum",
+			conf: ProjectScaffoldFile{Format: format, Metadata: Metadata{MinimumVersion: "1.1.0"}},
+			want: []string{"format requires scaffold 1.2.0 or higher (formatters) but metadata.minimum_version is 1.1.0"},
+		},
+		{
+			name: "no minimum",
+			conf: ProjectScaffoldFile{Format: format},
+			want: []string{"format requires scaffold 1.2.0 or higher (formatters) but metadata.minimum_version is not set"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := []string{}
+			for _, d := range lintMinimumVersion("scaffold.yaml", &tt.conf, nil, caps) {
+				assert.Equal(t, RuleMinimumVersion, d.Rule)
+				assert.Equal(t, SeverityWarning, d.Severity)
+				got = append(got, d.Message)
+			}
+
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLintMinimumVersion_Capabilities(t *testing.T) {
+	conf := &ProjectScaffoldFile{
+		Features:   []Feature{{Value: "true", Globs: []string{"docker/**"}}},
+		Delimiters: []Delimiters{{Glob: "*.tmpl", Left: "[[", Right: "]]"}},
+		Each:       []EachConfig{{Var: "services"}},
+		Format:     []Format{{Glob: "*.go", Formatter: "go"}},
+		Checks:     []Check{{Exists: []string{"go.mod"}}},
+		Metadata:   Metadata{MinimumVersion: "0.4.0", Requires: []string{"output-checks"}},
+	}
+
+	got := []string{}
+	for _, d := range lintMinimumVersion("scaffold.yaml", conf, nil, Capabilities) {
+		got = append(got, d.Message)
+	}
+
+	assert.Equal(t, []string{
+		"each requires scaffold 0.13.1 or higher (each) but metadata.minimum_version is 0.4.0",
+		"format requires scaffold " + nextRelease + " or higher (formatters) but metadata.minimum_version is 0.4.0",
+		"metadata.constraints, metadata.maximum_version, metadata.requires requires scaffold " + nextRelease + " or higher (version-constraints) but metadata.minimum_version is 0.4.0",
+	}, got, "features and delimiters are supported by 0.4.0, output-checks are required")
+
+	conf.Metadata.MinimumVersion = nextRelease
+	assert.Empty(t, lintMinimumVersion("scaffold.yaml", conf, nil, Capabilities))
+}
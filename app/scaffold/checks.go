@@ -0,0 +1,302 @@
+package scaffold
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/fs"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/internal/jsonschema"
+	"gopkg.in/yaml.v3"
+)
+
+// Check is an assertion about the output of a scaffold. Paths and globs are
+// relative to the output directory and are rendered as templates.
+type Check struct {
+	Name string `yaml:"name" description:"Name shown when the check fails"`
+	// When limits the check to renders where the condition, a pipeline or a
+	// template, is true.
+	When string `yaml:"when" description:"Condition, a pipeline or template, that must be true for the check to run"`
+	// Exists are globs that must each match at least one file or directory.
+	Exists []string `yaml:"exists" description:"Globs that must each match at least one file or directory"`
+	// NotExists are globs that must not match any file or directory.
+	NotExists []string `yaml:"not_exists" description:"Globs that must not match any file or directory"`
+	// Files selects the files for the Matches, NotMatches and Parse
+	// assertions.
+	Files string `yaml:"files" description:"Glob selecting the files for matches, not_matches and parse"`
+	// Matches is a regular expression every selected file must match.
+	// Validate ensures Matches and NotMatches compile.
+	Matches string `yaml:"matches" description:"Regular expression every selected file must match"`
+	// NotMatches is a regular expression no selected file may match.
+	NotMatches string `yaml:"not_matches" description:"Regular expression no selected file may match"`
+	// Parse is the format every selected file must parse as, one of go,
+	// json or yaml.
+	Parse string `yaml:"parse" description:"Format every selected file must parse as"`
+}
+
+func (Check) JSONSchemaExtend(s *jsonschema.Schema) {
+	names := make([]string, 0, len(parsers))
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	parse, _ := s.Properties.Get("parse")
+	for _, name := range names {
+		parse.Enum = append(parse.Enum, name)
+	}
+}
+
+// parsers are the formats supported by Check.Parse.
+var parsers = map[string]func(path string, data []byte) error{
+	"go": func(path string, data []byte) error {
+		_, err := parser.ParseFile(token.NewFileSet(), path, data, parser.AllErrors)
+		return err
+	},
+	"json": func(_ string, data []byte) error {
+		var v any
+		return json.Unmarshal(data, &v)
+	},
+	"yaml": func(_ string, data []byte) error {
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		for {
+			var v any
+			err := dec.Decode(&v)
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+		}
+	},
+}
+
+// label returns the name used for the check in failures.
+func (c Check) label(i int) string {
+	if c.Name != "" {
+		return c.Name
+	}
+	return fmt.Sprintf("checks[%d]", i)
+}
+
+// Validate reports configuration errors in the check.
+func (c Check) Validate() error {
+	if len(c.Exists) == 0 && len(c.NotExists) == 0 && c.Files == "" {
+		return errors.New("check has no assertions")
+	}
+
+	hasContent := c.Matches != "" || c.NotMatches != "" || c.Parse != ""
+	if c.Files != "" && !hasContent {
+		return errors.New("files requires matches, not_matches or parse")
+	}
+	if c.Files == "" && hasContent {
+		return errors.New("matches, not_matches and parse require files")
+	}
+
+	for _, expr := range []string{c.Matches, c.NotMatches} {
+		if _, err := regexp.Compile(expr); err != nil {
+			return fmt.Errorf("invalid regular expression %q: %w", expr, err)
+		}
+	}
+
+	if _, ok := parsers[c.Parse]; c.Parse != "" && !ok {
+		return fmt.Errorf("invalid parse %q: must be one of go, json, yaml", c.Parse)
+	}
+
+	return nil
+}
+
+// CheckFailure is a failed assertion.
+type CheckFailure struct {
+	Check   string
+	Message string
+}
+
+func (f CheckFailure) String() string {
+	return f.Check + ": " + f.Message
+}
+
+// ChecksError is returned when checks fail.
+type ChecksError struct {
+	Failures []CheckFailure
+}
+
+func (e *ChecksError) Error() string {
+	bldr := strings.Builder{}
+	fmt.Fprintf(&bldr, "%d check(s) failed:", len(e.Failures))
+	for _, f := range e.Failures {
+		bldr.WriteString("\n  - " + f.String())
+	}
+	return bldr.String()
+}
+
+// RunChecks runs the checks against the output in fsys. It returns a
+// *ChecksError when assertions fail and other errors for invalid checks.
+func RunChecks(eng *engine.Engine, checks []Check, fsys fs.FS, vars engine.Vars) error {
+	if len(checks) == 0 {
+		return nil
+	}
+
+	paths := []string{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if path != "." {
+			paths = append(paths, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	var failures []CheckFailure
+	for i, c := range checks {
+		label := c.label(i)
+
+		err := c.Validate()
+		if err != nil {
+			return fmt.Errorf("check %q: %w", label, err)
+		}
+
+		if c.When != "" {
+			ok, err := evalWhen(eng, c.When, vars)
+			if err != nil {
+				return fmt.Errorf("check %q: evaluating when %q: %w", label, c.When, err)
+			}
+
+			if !ok {
+				continue
+			}
+		}
+
+		msgs, err := c.run(eng, fsys, paths, vars)
+		if err != nil {
+			return fmt.Errorf("check %q: %w", label, err)
+		}
+
+		for _, msg := range msgs {
+			failures = append(failures, CheckFailure{Check: label, Message: msg})
+		}
+	}
+
+	if len(failures) > 0 {
+		return &ChecksError{Failures: failures}
+	}
+
+	return nil
+}
+
+// run returns a message for every failed assertion of the check.
+func (c Check) run(eng *engine.Engine, fsys fs.FS, paths []string, vars engine.Vars) ([]string, error) {
+	var msgs []string
+
+	match := func(glob string) ([]string, string, error) {
+		glob, err := eng.TmplString(glob, vars)
+		if err != nil {
+			return nil, "", err
+		}
+
+		var matched []string
+		for _, p := range paths {
+			ok, err := doublestar.Match(glob, p)
+			if err != nil {
+				return nil, "", fmt.Errorf("invalid glob %q: %w", glob, err)
+			}
+
+			if ok {
+				matched = append(matched, p)
+			}
+		}
+
+		return matched, glob, nil
+	}
+
+	for _, glob := range c.Exists {
+		matched, glob, err := match(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		if len(matched) == 0 {
+			msgs = append(msgs, fmt.Sprintf("%s does not exist", glob))
+		}
+	}
+
+	for _, glob := range c.NotExists {
+		matched, _, err := match(glob)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, p := range matched {
+			msgs = append(msgs, fmt.Sprintf("%s exists", p))
+		}
+	}
+
+	if c.Files == "" {
+		return msgs, nil
+	}
+
+	matched, glob, err := match(c.Files)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(matched) == 0 {
+		return append(msgs, fmt.Sprintf("no files match %s", glob)), nil
+	}
+
+	var matches, notMatches *regexp.Regexp
+	if c.Matches != "" {
+		matches = regexp.MustCompile(c.Matches)
+	}
+	if c.NotMatches != "" {
+		notMatches = regexp.MustCompile(c.NotMatches)
+	}
+
+	sort.Strings(matched)
+	for _, p := range matched {
+		info, err := fs.Stat(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if info.IsDir() {
+			continue
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return nil, err
+		}
+
+		if matches != nil && !matches.Match(data) {
+			msgs = append(msgs, fmt.Sprintf("%s does not match %q", p, c.Matches))
+		}
+
+		if notMatches != nil && notMatches.Match(data) {
+			msgs = append(msgs, fmt.Sprintf("%s matches %q", p, c.NotMatches))
+		}
+
+		if c.Parse != "" {
+			if err := parsers[c.Parse](p, data); err != nil {
+				msgs = append(msgs, fmt.Sprintf("%s is not valid %s: %v", p, c.Parse, err))
+			}
+		}
+	}
+
+	return msgs, nil
+}
@@ -0,0 +1,84 @@
+package scaffold
+
+import (
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRunChecks(t *testing.T) {
+	out := fstest.MapFS{
+		"app/Dockerfile":    {Data: []byte("FROM alpine\n")},
+		"app/main.go":       {Data: []byte("package main\n\nfunc main() {}\n")},
+		"app/broken.go":     {Data: []byte("package main\n\nfunc {\n")},
+		"app/config.json":   {Data: []byte(`{"port": 8080}`)},
+		"app/values.yaml":   {Data: []byte("a: 1\n---\nb: [\n")},
+		"app/README.md":     {Data: []byte("# app\nTODO: docs\n")},
+		"app/docs/.gitkeep": {Data: []byte("")},
+	}
+
+	vars := engine.Vars{"Project": "app", "Scaffold": engine.Vars{"docker": false}}
+
+	checks := []Check{
+		{Name: "dockerfile", When: "not .Scaffold.docker", NotExists: []string{"{{ .Project }}/Dockerfile"}},
+		{Name: "skipped", When: ".Scaffold.docker", Exists: []string{"missing"}},
+		{Name: "readme", Exists: []string{"app/README.md", "app/docs", "app/LICENSE"}},
+		{Name: "go", Files: "**/*.go", Parse: "go"},
+		{Name: "json", Files: "**/*.json", Parse: "json"},
+		{Name: "yaml", Files: "**/*.yaml", Parse: "yaml"},
+		{Name: "todo", Files: "**/*.md", Matches: "^# ", NotMatches: "TODO"},
+		{Files: "**/*.toml", Parse: "yaml"},
+	}
+
+	err := RunChecks(tEngine, checks, out, vars)
+
+	var checksErr *ChecksError
+	require.True(t, errors.As(err, &checksErr))
+
+	msgs := make([]string, len(checksErr.Failures))
+	for i, f := range checksErr.Failures {
+		msgs[i] = f.String()
+	}
+
+	assert.Equal(t, []string{
+		"dockerfile: app/Dockerfile exists",
+		"readme: app/LICENSE does not exist",
+		"go: app/broken.go is not valid go: app/broken.go:3:6: expected 'IDENT', found '{' (and 3 more errors)",
+		"yaml: app/values.yaml is not valid yaml: yaml: line 3: did not find expected node content",
+		`todo: app/README.md matches "TODO"`,
+		"checks[7]: no files match **/*.toml",
+	}, msgs)
+
+	require.NoError(t, RunChecks(tEngine, checks[1:2], out, vars), "checks are skipped when false")
+}
+
+func TestCheck_Validate(t *testing.T) {
+	tests := []struct {
+		name  string
+		check Check
+		ok    bool
+	}{
+		{name: "exists", check: Check{Exists: []string{"a"}}, ok: true},
+		{name: "content", check: Check{Files: "*.go", Parse: "go"}, ok: true},
+		{name: "empty", check: Check{Name: "x"}},
+		{name: "files without assertion", check: Check{Files: "*.go"}},
+		{name: "assertion without files", check: Check{Matches: "x", Exists: []string{"a"}}},
+		{name: "bad regex", check: Check{Files: "*", Matches: "("}},
+		{name: "bad parse", check: Check{Files: "*", Parse: "toml"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.check.Validate()
+			if tt.ok {
+				require.NoError(t, err)
+			} else {
+				require.Error(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,253 @@
+package scaffold
+
+import (
+	"bytes"
+	"fmt"
+	"io/fs"
+	"strings"
+
+	"github.com/pmezard/go-difflib/difflib"
+	"github.com/rs/zerolog/log"
+)
+
+// ConflictAction is what happens when a rendered file already exists in the
+// output.
+type ConflictAction string
+
+const (
+	// ConflictKeep leaves the existing file untouched.
+	ConflictKeep ConflictAction = "keep"
+	// ConflictOverwrite replaces the existing file.
+	ConflictOverwrite ConflictAction = "overwrite"
+	// ConflictMerge writes both versions to the file, separated by conflict
+	// markers where they differ.
+	ConflictMerge ConflictAction = "merge"
+	// ConflictSidecar writes the rendered file next to the existing one with
+	// a ".new" suffix.
+	ConflictSidecar ConflictAction = "sidecar"
+	// ConflictPrompt asks Options.ResolveConflict for every conflict.
+	ConflictPrompt ConflictAction = "prompt"
+)
+
+// SidecarSuffix is appended to the path of files written by ConflictSidecar.
+const SidecarSuffix = ".new"
+
+// ConflictActions lists the valid values of Options.OnConflict.
+var ConflictActions = []ConflictAction{ConflictKeep, ConflictOverwrite, ConflictMerge, ConflictSidecar, ConflictPrompt}
+
+// ParseConflictAction parses s into a ConflictAction.
+func ParseConflictAction(s string) (ConflictAction, error) {
+	for _, a := range ConflictActions {
+		if string(a) == s {
+			return a, nil
+		}
+	}
+
+	names := make([]string, len(ConflictActions))
+	for i, a := range ConflictActions {
+		names[i] = string(a)
+	}
+
+	return "", fmt.Errorf("invalid conflict action %q: must be one of %s", s, strings.Join(names, ", "))
+}
+
+// Conflict describes a rendered file that already exists in the output.
+type Conflict struct {
+	// Path is the output path of the file.
+	Path string
+	// Diff is a unified diff from the existing file to the rendered file.
+	Diff string
+	// Verbatim is true for files that are copied without rendering, like
+	// binary files and symlinks. They have no diff and can't be merged.
+	Verbatim bool
+}
+
+// ConflictResolver chooses the action for a conflict. When all is true the
+// action is used for every remaining conflict without asking again.
+type ConflictResolver func(c Conflict) (action ConflictAction, all bool, err error)
+
+// ResolvedConflict records how a conflict was resolved.
+type ResolvedConflict struct {
+	Path   string
+	Action ConflictAction
+}
+
+// conflictAction returns the action for existing output of a file with the
+// front matter fm, which is nil for files without it.
+func conflictAction(opts Options, fm *FrontMatter) ConflictAction {
+	if fm != nil {
+		if fm.Merge == MergeAppend || fm.Merge == MergePrepend {
+			// Existing files are merged by mergeOutput.
+			return ConflictOverwrite
+		}
+
+		switch fm.Overwrite {
+		case OverwriteAlways:
+			return ConflictOverwrite
+		case OverwriteNever:
+			return ConflictKeep
+		case OverwritePrompt:
+			return ConflictPrompt
+		}
+	}
+
+	if opts.OnConflict == "" {
+		// The no-clobber guard has already handled existing files.
+		return ConflictOverwrite
+	}
+
+	return opts.OnConflict
+}
+
+// resolveConflict resolves the conflict for output written to outpath when
+// it already exists. data is the rendered output, nil for verbatim files. It
+// returns the path and data to write, ok is false when nothing is written.
+func (args *RWFSArgs) resolveConflict(action ConflictAction, outpath string, data []byte, verbatim bool) (path string, out []byte, ok bool, err error) {
+	if action == ConflictOverwrite {
+		return outpath, data, true, nil
+	}
+
+	if _, err := fs.Stat(args.WriteFS, outpath); err != nil {
+		return outpath, data, true, nil
+	}
+
+	var existing []byte
+	if !verbatim {
+		existing, err = fs.ReadFile(args.WriteFS, outpath)
+		if err != nil {
+			return "", nil, false, err
+		}
+
+		if bytes.Equal(existing, data) {
+			return outpath, data, true, nil
+		}
+	}
+
+	if action == ConflictPrompt {
+		action, err = args.promptConflict(outpath, existing, data, verbatim)
+		if err != nil {
+			return "", nil, false, err
+		}
+	}
+
+	if action == ConflictMerge && verbatim {
+		log.Debug().Str("path", outpath).Msg("verbatim files can't be merged, writing sidecar")
+		action = ConflictSidecar
+	}
+
+	log.Debug().Str("path", outpath).Str("action", string(action)).Msg("resolved conflict")
+	if args.Report != nil {
+		args.Report.Conflicts = append(args.Report.Conflicts, ResolvedConflict{Path: outpath, Action: action})
+	}
+
+	switch action {
+	case ConflictKeep:
+		return "", nil, false, nil
+	case ConflictSidecar:
+		return outpath + SidecarSuffix, data, true, nil
+	case ConflictMerge:
+		return outpath, mergeConflict(existing, data), true, nil
+	case ConflictOverwrite:
+		return outpath, data, true, nil
+	default:
+		return "", nil, false, fmt.Errorf("%s: invalid conflict action %q", outpath, action)
+	}
+}
+
+// promptConflict asks the conflict resolver for the action to take, existing
+// files are kept when there is no resolver.
+func (args *RWFSArgs) promptConflict(outpath string, existing, data []byte, verbatim bool) (ConflictAction, error) {
+	if args.conflictAll != "" {
+		return args.conflictAll, nil
+	}
+
+	resolve := args.Project.Options.ResolveConflict
+	if resolve == nil {
+		return ConflictKeep, nil
+	}
+
+	c := Conflict{Path: outpath, Verbatim: verbatim}
+	if !verbatim {
+		diff, err := difflib.GetUnifiedDiffString(difflib.UnifiedDiff{
+			A:        splitLines(existing),
+			B:        splitLines(data),
+			FromFile: outpath,
+			ToFile:   outpath + " (rendered)",
+			Context:  3,
+		})
+		if err != nil {
+			return "", err
+		}
+
+		c.Diff = diff
+	}
+
+	action, all, err := resolve(c)
+	if err != nil {
+		return "", err
+	}
+
+	if action == ConflictPrompt {
+		return "", fmt.Errorf("%s: conflict resolver returned %q", outpath, action)
+	}
+
+	if all {
+		args.conflictAll = action
+	}
+
+	return action, nil
+}
+
+// mergeConflict combines the existing and rendered contents line by line,
+// wrapping the lines that differ in git style conflict markers.
+func mergeConflict(existing, rendered []byte) []byte {
+	a, b := splitLines(existing), splitLines(rendered)
+	ensureNewline(a)
+	ensureNewline(b)
+
+	m := difflib.NewMatcherWithJunk(a, b, false, nil)
+
+	var out bytes.Buffer
+	for _, op := range m.GetOpCodes() {
+		if op.Tag == 'e' {
+			for _, line := range a[op.I1:op.I2] {
+				out.WriteString(line)
+			}
+			continue
+		}
+
+		out.WriteString("<<<<<<< existing\n")
+		for _, line := range a[op.I1:op.I2] {
+			out.WriteString(line)
+		}
+		out.WriteString("=======\n")
+		for _, line := range b[op.J1:op.J2] {
+			out.WriteString(line)
+		}
+		out.WriteString(">>>>>>> rendered\n")
+	}
+
+	return out.Bytes()
+}
+
+// splitLines splits data into lines, keeping the line breaks.
+func splitLines(data []byte) []string {
+	if len(data) == 0 {
+		return nil
+	}
+
+	lines := strings.SplitAfter(string(data), "\n")
+	if lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	return lines
+}
+
+// ensureNewline adds a line break to the last line so conflict markers start
+// on their own line.
+func ensureNewline(lines []string) {
+	if n := len(lines); n > 0 && !strings.HasSuffix(lines[n-1], "\n") {
+		lines[n-1] += "\n"
+	}
+}
@@ -0,0 +1,195 @@
+package scaffold
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseConflictAction(t *testing.T) {
+	got, err := ParseConflictAction("sidecar")
+	require.NoError(t, err)
+	assert.Equal(t, ConflictSidecar, got)
+
+	_, err = ParseConflictAction("skip")
+	require.Error(t, err)
+}
+
+func Test_mergeConflict(t *testing.T) {
+	tests := []struct {
+		name     string
+		existing string
+		rendered string
+		want     string
+	}{
+		{
+			name:     "changed line",
+			existing: "a\nb\nc\n",
+			rendered: "a\nB\nc\n",
+			want:     "a\n<<<<<<< existing\nb\n=======\nB\n>>>>>>> rendered\nc\n",
+		},
+		{
+			name:     "added and removed lines",
+			existing: "a\nlocal\nc\n",
+			rendered: "a\nc\nd\n",
+			want:     "a\n<<<<<<< existing\nlocal\n=======\n>>>>>>> rendered\nc\n<<<<<<< existing\n=======\nd\n>>>>>>> rendered\n",
+		},
+		{
+			name:     "missing trailing newline",
+			existing: "a",
+			rendered: "b",
+			want:     "<<<<<<< existing\na\n=======\nb\n>>>>>>> rendered\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := mergeConflict([]byte(tt.existing), []byte(tt.rendered))
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func conflictRender(t *testing.T, opts Options, report *Report) *rwfs.MemoryWFS {
+	t.Helper()
+
+	readFS := fstest.MapFS{
+		"templates/a.txt":     {Data: []byte("a\n{{ .Project }}\n")},
+		"templates/b.txt":     {Data: []byte("b\n")},
+		"templates/same.txt":  {Data: []byte("same\n")},
+		"templates/new.txt":   {Data: []byte("new\n")},
+		"templates/logo.png":  {Data: []byte{0x89, 'P', 'N', 'G', 0x00, 0x02}},
+		"templates/assets/x":  {Data: []byte("{{ raw }}\n")},
+		"templates/front.txt": {Data: []byte("---scaffold\noverwrite: always\n---\nfront\n")},
+	}
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         TemplateDirName,
+		Conf:         &ProjectScaffoldFile{Skip: []string{"assets/*"}},
+		Options:      opts,
+	}
+
+	memFS := rwfs.NewMemoryWFS()
+	require.NoError(t, memFS.MkdirAll("assets", 0o755))
+	for p, data := range map[string]string{
+		"a.txt":     "a\nlocal\n",
+		"b.txt":     "old b\n",
+		"same.txt":  "same\n",
+		"logo.png":  "\x89PNG\x00\x01",
+		"assets/x":  "old x\n",
+		"front.txt": "old front\n",
+	} {
+		require.NoError(t, memFS.WriteFile(p, []byte(data), 0o644))
+	}
+
+	err := RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+		Report:  report,
+	}, engine.Vars{"Project": "app"})
+	require.NoError(t, err)
+
+	return memFS
+}
+
+func readAll(t *testing.T, fsys fs.FS) map[string]string {
+	t.Helper()
+
+	got := map[string]string{}
+	err := fs.WalkDir(fsys, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(fsys, path)
+		got[path] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+
+	return got
+}
+
+func Test_RenderRWFS_OnConflict(t *testing.T) {
+	t.Run("keep", func(t *testing.T) {
+		report := &Report{}
+		got := readAll(t, conflictRender(t, Options{OnConflict: ConflictKeep}, report))
+
+		assert.Equal(t, "a\nlocal\n", got["a.txt"])
+		assert.Equal(t, "old b\n", got["b.txt"])
+		assert.Equal(t, "new\n", got["new.txt"])
+		assert.Equal(t, "\x89PNG\x00\x01", got["logo.png"])
+		assert.Equal(t, "old x\n", got["assets/x"])
+		assert.Equal(t, "front\n", got["front.txt"], "front matter overrides the action")
+
+		assert.ElementsMatch(t, []ResolvedConflict{
+			{Path: "a.txt", Action: ConflictKeep},
+			{Path: "b.txt", Action: ConflictKeep},
+			{Path: "logo.png", Action: ConflictKeep},
+			{Path: "assets/x", Action: ConflictKeep},
+		}, report.Conflicts, "identical files are not conflicts")
+	})
+
+	t.Run("sidecar", func(t *testing.T) {
+		got := readAll(t, conflictRender(t, Options{OnConflict: ConflictSidecar}, nil))
+
+		assert.Equal(t, "a\nlocal\n", got["a.txt"])
+		assert.Equal(t, "a\napp\n", got["a.txt.new"])
+		assert.Equal(t, "b\n", got["b.txt.new"])
+		assert.Equal(t, "\x89PNG\x00\x02", got["logo.png.new"])
+		assert.Equal(t, "{{ raw }}\n", got["assets/x.new"])
+		assert.NotContains(t, got, "same.txt.new")
+		assert.NotContains(t, got, "new.txt.new")
+	})
+
+	t.Run("merge", func(t *testing.T) {
+		got := readAll(t, conflictRender(t, Options{OnConflict: ConflictMerge}, nil))
+
+		assert.Equal(t, "a\n<<<<<<< existing\nlocal\n=======\napp\n>>>>>>> rendered\n", got["a.txt"])
+		assert.Equal(t, "\x89PNG\x00\x02", got["logo.png.new"], "binary files fall back to sidecars")
+	})
+
+	t.Run("prompt apply to all", func(t *testing.T) {
+		conflicts := []Conflict{}
+		opts := Options{
+			OnConflict: ConflictPrompt,
+			ResolveConflict: func(c Conflict) (ConflictAction, bool, error) {
+				conflicts = append(conflicts, c)
+				return ConflictOverwrite, true, nil
+			},
+		}
+
+		got := readAll(t, conflictRender(t, opts, nil))
+		require.Len(t, conflicts, 1, "resolver is not called after apply to all")
+
+		assert.Equal(t, "a\napp\n", got["a.txt"])
+		assert.Equal(t, "b\n", got["b.txt"])
+		assert.Equal(t, "\x89PNG\x00\x02", got["logo.png"])
+		assert.Equal(t, "{{ raw }}\n", got["assets/x"])
+	})
+
+	t.Run("prompt diff", func(t *testing.T) {
+		conflicts := map[string]Conflict{}
+		opts := Options{
+			OnConflict: ConflictPrompt,
+			ResolveConflict: func(c Conflict) (ConflictAction, bool, error) {
+				conflicts[c.Path] = c
+				return ConflictKeep, false, nil
+			},
+		}
+
+		conflictRender(t, opts, nil)
+
+		assert.Equal(t, "--- a.txt\n+++ a.txt (rendered)\n@@ -1,2 +1,2 @@\n a\n-local\n+app\n", conflicts["a.txt"].Diff)
+		assert.True(t, conflicts["logo.png"].Verbatim)
+		assert.Empty(t, conflicts["logo.png"].Diff)
+		assert.NotContains(t, conflicts, "same.txt")
+	})
+}
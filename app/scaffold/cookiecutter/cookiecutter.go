@@ -0,0 +1,379 @@
+// Package cookiecutter converts cookiecutter project templates into native
+// scaffold projects.
+//
+// Cookiecutter templates use Jinja2 syntax which is a superset of what can be
+// expressed with Go templates. The converter translates the common subset
+// (variable references, simple filters and if/else blocks) and reports
+// everything it could not translate as a warning so the author can finish
+// the conversion by hand.
+package cookiecutter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/detect"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold/internal/convert"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	ContextFile = "cookiecutter.json"
+	HooksDir    = "hooks"
+
+	// projectDir is the native scaffold directory name the cookiecutter slug
+	// directory is mapped to.
+	projectDir = "{{ .Project }}"
+)
+
+var ErrNotCookiecutter = errors.New("cookiecutter.json not found")
+
+// slugDirPattern matches a top level directory name that is a single
+// cookiecutter variable reference e.g. {{cookiecutter.project_slug}}
+var slugDirPattern = regexp.MustCompile(`^\{\{\s*cookiecutter\.(\w+)\s*\}\}$`)
+
+// Detect reports whether the filesystem looks like a cookiecutter template.
+func Detect(fsys fs.FS) bool {
+	_, err := fs.Stat(fsys, ContextFile)
+	if err != nil {
+		return false
+	}
+
+	_, _, err = findSlugDir(fsys)
+	return err == nil
+}
+
+// Result contains the output of a conversion.
+type Result struct {
+	// SlugVar is the cookiecutter variable that named the project directory.
+	// References to it are translated to .Project.
+	SlugVar string
+	// Warnings lists every construct that could not be translated
+	// automatically and must be reviewed by hand.
+	Warnings []string
+}
+
+func (r *Result) warnf(format string, args ...any) {
+	r.Warnings = append(r.Warnings, fmt.Sprintf(format, args...))
+}
+
+// Convert reads the cookiecutter template in src and writes an equivalent
+// scaffold project into dst.
+func Convert(src fs.FS, dst rwfs.WriteFS) (*Result, error) {
+	ctxfile, err := src.Open(ContextFile)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotCookiecutter
+		}
+		return nil, err
+	}
+	defer func() { _ = ctxfile.Close() }()
+
+	entries, err := readContext(ctxfile)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read %s: %w", ContextFile, err)
+	}
+
+	slugDir, slugVar, err := findSlugDir(src)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{SlugVar: slugVar}
+	tr := &translator{slugVar: slugVar, computed: map[string]bool{}, res: res}
+
+	conf := scaffoldFile{}
+
+	// cookiecutter 2.2+ allows human readable prompts under a private key.
+	prompts := map[string]string{}
+	for _, entry := range entries {
+		if entry.Key != "__prompts__" {
+			continue
+		}
+
+		m, _ := entry.Value.(map[string]any)
+		for k, v := range m {
+			if s, ok := v.(string); ok {
+				prompts[k] = s
+			}
+		}
+	}
+
+	for _, entry := range entries {
+		switch {
+		case entry.Key == "__prompts__":
+			// handled above
+		case entry.Key == "_copy_without_render":
+			globs, ok := entry.Value.([]any)
+			if !ok {
+				res.warnf("%s: _copy_without_render must be a list", ContextFile)
+				continue
+			}
+
+			for _, g := range globs {
+				s, ok := g.(string)
+				if !ok {
+					continue
+				}
+
+				conf.Skip = append(conf.Skip, tr.skipGlob(s, slugDir))
+			}
+		case strings.HasPrefix(entry.Key, "_"):
+			res.warnf("%s: private variable %q is not supported and was ignored", ContextFile, entry.Key)
+		case entry.Key == slugVar:
+			// The slug is provided by the Project name question that scaffold
+			// asks for every project scaffold.
+		default:
+			tr.addVariable(&conf, entry, prompts[entry.Key])
+		}
+	}
+
+	err = fs.WalkDir(src, slugDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		rel := tr.translatePath(strings.TrimPrefix(p, slugDir+"/"), p)
+		outpath := path.Join(projectDir, rel)
+
+		data, err := fs.ReadFile(src, p)
+		if err != nil {
+			return err
+		}
+
+		if !detect.IsBinary(data) && !conf.skips(rel) {
+			data = []byte(tr.translate(string(data), p))
+		}
+
+		return convert.WriteFile(dst, outpath, data, convert.FileMode(d))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	err = convertHooks(src, dst, tr)
+	if err != nil {
+		return nil, err
+	}
+
+	buff := bytes.NewBuffer(nil)
+	enc := yaml.NewEncoder(buff)
+	enc.SetIndent(2)
+	err = enc.Encode(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dst.WriteFile("scaffold.yaml", buff.Bytes(), 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// scaffoldFile is the subset of the scaffold.yaml format produced by the
+// converter. It is declared separately from scaffold.ProjectScaffoldFile so
+// empty sections are omitted from the output.
+type scaffoldFile struct {
+	Questions []question        `yaml:"questions,omitempty"`
+	Computed  map[string]string `yaml:"computed,omitempty"`
+	Skip      []string          `yaml:"skip,omitempty"`
+}
+
+type question struct {
+	Name   string `yaml:"name"`
+	Prompt prompt `yaml:"prompt"`
+}
+
+type prompt struct {
+	Message string   `yaml:"message,omitempty"`
+	Confirm string   `yaml:"confirm,omitempty"`
+	Default any      `yaml:"default,omitempty"`
+	Options []string `yaml:"options,omitempty"`
+}
+
+// addVariable maps a cookiecutter.json entry to a question, or to a computed
+// value when the entry is derived from other variables.
+func (t *translator) addVariable(conf *scaffoldFile, entry contextEntry, message string) {
+	if message == "" {
+		message = convert.Humanize(entry.Key)
+	}
+
+	q := question{Name: entry.Key}
+
+	switch v := entry.Value.(type) {
+	case string:
+		if strings.Contains(v, "{{") || strings.Contains(v, "{%") {
+			out, ok := t.tryTranslate(v)
+			// computed values are rendered independently and can't refer to
+			// each other.
+			if ok && !strings.Contains(out, ".Computed.") {
+				if conf.Computed == nil {
+					conf.Computed = map[string]string{}
+				}
+
+				conf.Computed[entry.Key] = out
+				t.computed[entry.Key] = true
+				return
+			}
+
+			t.res.warnf("%s: default for %q could not be translated and was dropped: %s", ContextFile, entry.Key, v)
+			v = ""
+		}
+
+		q.Prompt = prompt{Message: message, Default: v}
+	case bool:
+		q.Prompt = prompt{Confirm: message, Default: v}
+	case float64:
+		q.Prompt = prompt{Message: message, Default: strconv.FormatFloat(v, 'f', -1, 64)}
+	case []any:
+		options := make([]string, 0, len(v))
+		for _, opt := range v {
+			s, ok := opt.(string)
+			if !ok {
+				t.res.warnf("%s: option %v for %q is not a string and was ignored", ContextFile, opt, entry.Key)
+				continue
+			}
+			options = append(options, s)
+		}
+
+		q.Prompt = prompt{Message: message, Options: options}
+		if len(options) > 0 {
+			q.Prompt.Default = options[0]
+		}
+	case nil:
+		q.Prompt = prompt{Message: message}
+	default:
+		t.res.warnf("%s: variable %q has unsupported type %T and was ignored", ContextFile, entry.Key, v)
+		return
+	}
+
+	conf.Questions = append(conf.Questions, q)
+}
+
+// contextEntry is a single key/value pair from cookiecutter.json. The order of
+// entries is preserved since cookiecutter prompts in declaration order.
+type contextEntry struct {
+	Key   string
+	Value any
+}
+
+func readContext(r io.Reader) ([]contextEntry, error) {
+	dec := json.NewDecoder(r)
+
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, err
+	}
+
+	if delim, ok := tok.(json.Delim); !ok || delim != '{' {
+		return nil, errors.New("expected a JSON object")
+	}
+
+	entries := []contextEntry{}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, err
+		}
+
+		key, ok := tok.(string)
+		if !ok {
+			return nil, fmt.Errorf("unexpected token %v", tok)
+		}
+
+		var value any
+		err = dec.Decode(&value)
+		if err != nil {
+			return nil, err
+		}
+
+		entries = append(entries, contextEntry{Key: key, Value: value})
+	}
+
+	return entries, nil
+}
+
+func findSlugDir(fsys fs.FS) (dir string, variable string, err error) {
+	entries, err := fs.ReadDir(fsys, ".")
+	if err != nil {
+		return "", "", err
+	}
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+
+		m := slugDirPattern.FindStringSubmatch(entry.Name())
+		if m != nil {
+			return entry.Name(), m[1], nil
+		}
+	}
+
+	return "", "", errors.New("no {{cookiecutter.<var>}} project directory found")
+}
+
+func convertHooks(src fs.FS, dst rwfs.WriteFS, tr *translator) error {
+	entries, err := fs.ReadDir(src, HooksDir)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	// Scaffold runs a single post_scaffold hook, the first post_gen_project
+	// hook is converted and the others are reported.
+	converted := ""
+	for _, entry := range entries {
+		if entry.IsDir() {
+			continue
+		}
+
+		name := entry.Name()
+		p := path.Join(HooksDir, name)
+
+		if !strings.HasPrefix(name, "post_gen_project") {
+			tr.res.warnf("%s: only post_gen_project hooks are supported", p)
+			continue
+		}
+
+		if strings.HasSuffix(name, ".py") {
+			tr.res.warnf("%s: python hooks are not supported, port it to a post_scaffold shell script", p)
+			continue
+		}
+
+		if converted != "" {
+			tr.res.warnf("%s: only one post_gen_project hook is supported, %s was converted", p, converted)
+			continue
+		}
+
+		data, err := fs.ReadFile(src, p)
+		if err != nil {
+			return err
+		}
+
+		converted = p
+		err = convert.WriteFile(dst, path.Join(HooksDir, "post_scaffold"), []byte(tr.translate(string(data), p)), 0o755)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,160 @@
+package cookiecutter
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func cookiecutterFS() fstest.MapFS {
+	return fstest.MapFS{
+		"cookiecutter.json": {Data: []byte(`{
+  "project_name": "My Project",
+  "project_slug": "{{ cookiecutter.project_name.lower()|replace(' ', '_') }}",
+  "author": "Jane Doe",
+  "use_docker": ["y", "n"],
+  "open_source": true,
+  "module_path": "github.com/{{ cookiecutter.author }}",
+  "_copy_without_render": ["*.html"],
+  "_extensions": ["jinja2_time.TimeExtension"]
+}`)},
+		"{{cookiecutter.project_slug}}/README.md": {Data: []byte(
+			"# {{ cookiecutter.project_name }}\n" +
+				"{% if cookiecutter.use_docker == 'y' %}\n" +
+				"docker run {{ cookiecutter.project_slug }}\n" +
+				"{% endif %}\n" +
+				"{{ cookiecutter.author | upper }}\n",
+		)},
+		"{{cookiecutter.project_slug}}/{{cookiecutter.author}}.txt": {Data: []byte("{{ cookiecutter.module_path }}\n")},
+		"{{cookiecutter.project_slug}}/site/index.html":             {Data: []byte("{{ not translated }}\n")},
+		"{{cookiecutter.project_slug}}/ci.yml":                      {Data: []byte("{% raw %}${{ secrets.TOKEN }}{% endraw %}\n{{ now() }}\n")},
+		"hooks/post_gen_project.sh":                                 {Data: []byte("echo {{ cookiecutter.project_slug }}\n")},
+		"hooks/post_gen_project.zsh":                                {Data: []byte("echo zsh\n")},
+		"hooks/pre_gen_project.py":                                  {Data: []byte("print('hi')\n")},
+	}
+}
+
+func TestDetect(t *testing.T) {
+	assert.True(t, Detect(cookiecutterFS()))
+	assert.False(t, Detect(fstest.MapFS{"scaffold.yaml": {}}))
+}
+
+func TestConvert(t *testing.T) {
+	dst := rwfs.NewMemoryWFS()
+
+	res, err := Convert(cookiecutterFS(), dst)
+	require.NoError(t, err)
+	assert.Equal(t, "project_slug", res.SlugVar)
+
+	read := func(p string) string {
+		t.Helper()
+		b, err := fs.ReadFile(dst, p)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, `questions:
+  - name: project_name
+    prompt:
+      message: Project name
+      default: My Project
+  - name: author
+    prompt:
+      message: Author
+      default: Jane Doe
+  - name: use_docker
+    prompt:
+      message: Use docker
+      default: "y"
+      options:
+        - "y"
+        - "n"
+  - name: open_source
+    prompt:
+      confirm: Open source
+      default: true
+computed:
+  module_path: github.com/{{ .Scaffold.author }}
+skip:
+  - '**/*.html'
+`, read("scaffold.yaml"))
+
+	assert.Equal(t, "# {{ .Scaffold.project_name }}\n"+
+		"{{ if eq .Scaffold.use_docker \"y\" }}\n"+
+		"docker run {{ .Project }}\n"+
+		"{{ end }}\n"+
+		"{{ .Scaffold.author | upper }}\n", read("{{ .Project }}/README.md"))
+
+	assert.Equal(t, "{{ .Computed.module_path }}\n", read("{{ .Project }}/{{ .Scaffold.author }}.txt"))
+	assert.Equal(t, "{{ not translated }}\n", read("{{ .Project }}/site/index.html"), "skipped files are copied verbatim")
+	assert.Equal(t, "${{ \"{{\" }} secrets.TOKEN }}\n{{ now() }}\n", read("{{ .Project }}/ci.yml"))
+	assert.Equal(t, "echo {{ .Project }}\n", read("hooks/post_scaffold"))
+
+	assert.ElementsMatch(t, []string{
+		`cookiecutter.json: private variable "_extensions" is not supported and was ignored`,
+		`{{cookiecutter.project_slug}}/ci.yml:2: unsupported expression "{{ now() }}"`,
+		`hooks/post_gen_project.zsh: only one post_gen_project hook is supported, hooks/post_gen_project.sh was converted`,
+		`hooks/pre_gen_project.py: only post_gen_project hooks are supported`,
+	}, res.Warnings)
+}
+
+func TestConvert_NotCookiecutter(t *testing.T) {
+	_, err := Convert(fstest.MapFS{}, rwfs.NewMemoryWFS())
+	require.ErrorIs(t, err, ErrNotCookiecutter)
+}
+
+func Test_translator_expr(t *testing.T) {
+	tr := &translator{slugVar: "slug", computed: map[string]bool{}, res: &Result{}}
+
+	tests := []struct {
+		in   string
+		want string
+		ok   bool
+	}{
+		{in: "cookiecutter.name", want: ".Scaffold.name", ok: true},
+		{in: "cookiecutter.slug", want: ".Project", ok: true},
+		{in: "cookiecutter.name.lower().strip()", want: ".Scaffold.name | lower | trim", ok: true},
+		{in: "cookiecutter.name | replace('-', '_') | title", want: `.Scaffold.name | replace "-" "_" | title`, ok: true},
+		{in: "'literal'", want: `"literal"`, ok: true},
+		{in: "cookiecutter.name | slugify", ok: false},
+		{in: "range(10)", ok: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := tr.expr(tt.in)
+			assert.Equal(t, tt.ok, ok)
+			if tt.ok {
+				assert.Equal(t, tt.want, got)
+			}
+		})
+	}
+}
+
+func Test_translator_cond(t *testing.T) {
+	tr := &translator{slugVar: "slug", computed: map[string]bool{}, res: &Result{}}
+
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{in: "cookiecutter.docker", want: ".Scaffold.docker"},
+		{in: "cookiecutter.docker == 'y'", want: `eq .Scaffold.docker "y"`},
+		{in: "cookiecutter.db != 'none'", want: `ne .Scaffold.db "none"`},
+		{in: "not cookiecutter.docker", want: "not (.Scaffold.docker)"},
+		{in: "cookiecutter.a == 'y' and cookiecutter.b", want: `and (eq .Scaffold.a "y") (.Scaffold.b)`},
+		{in: "cookiecutter.name.lower() == 'x'", want: `eq (.Scaffold.name | lower) "x"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, ok := tr.cond(tt.in)
+			require.True(t, ok)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -0,0 +1,374 @@
+package cookiecutter
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+var (
+	rawPattern = regexp.MustCompile(`(?s)\{%-?\s*raw\s*-?%\}(.*?)\{%-?\s*endraw\s*-?%\}`)
+	// tagPattern matches Jinja comments, statements and expressions. Each
+	// kind is matched in a single pass so translated output is never
+	// translated twice.
+	tagPattern = regexp.MustCompile(`(?s)\{#-?(.*?)-?#\}|\{%(-?)\s*(.*?)\s*(-?)%\}|\{\{(-?)\s*(.*?)\s*(-?)\}\}`)
+
+	varPattern    = regexp.MustCompile(`^cookiecutter\.(\w+)((?:\.\w+\([^()]*\))*)$`)
+	methodPattern = regexp.MustCompile(`\.(\w+)\(([^()]*)\)`)
+	filterPattern = regexp.MustCompile(`^(\w+)(?:\((.*)\))?$`)
+	numberPattern = regexp.MustCompile(`^-?\d+(\.\d+)?$`)
+)
+
+// translator converts Jinja2 syntax used by cookiecutter into the equivalent
+// Go template syntax.
+type translator struct {
+	slugVar  string
+	computed map[string]bool
+	res      *Result
+}
+
+// warnFunc is called for every construct that could not be translated.
+type warnFunc func(line int, msg string)
+
+// translate converts a template and records a warning on the result for each
+// construct that could not be translated. source is only used for messages.
+func (t *translator) translate(s string, source string) string {
+	return t.translateText(s, func(line int, msg string) {
+		t.res.warnf("%s:%d: %s", source, line, msg)
+	})
+}
+
+// tryTranslate converts a template and reports whether every construct was
+// translated.
+func (t *translator) tryTranslate(s string) (string, bool) {
+	ok := true
+	out := t.translateText(s, func(int, string) { ok = false })
+	return out, ok
+}
+
+func (t *translator) translateText(s string, warn warnFunc) string {
+	var b strings.Builder
+
+	last := 0
+	for _, m := range rawPattern.FindAllStringSubmatchIndex(s, -1) {
+		b.WriteString(t.translateSegment(s[last:m[0]], lineOf(s, last), warn))
+		// Raw blocks are emitted as-is, Go templates only need the opening
+		// delimiter escaped.
+		b.WriteString(strings.ReplaceAll(s[m[2]:m[3]], "{{", `{{ "{{" }}`))
+		last = m[1]
+	}
+
+	b.WriteString(t.translateSegment(s[last:], lineOf(s, last), warn))
+	return b.String()
+}
+
+func (t *translator) translateSegment(s string, startLine int, warn warnFunc) string {
+	lineAt := func(offset int) int {
+		return startLine + strings.Count(s[:offset], "\n")
+	}
+
+	return replaceAllIndex(tagPattern, s, func(m []string, offset int) string {
+		switch {
+		case strings.HasPrefix(m[0], "{#"):
+			return "{{/*" + strings.ReplaceAll(m[1], "*/", "* /") + "*/}}"
+		case strings.HasPrefix(m[0], "{%"):
+			out, ok := t.stmt(m[3])
+			if !ok {
+				warn(lineAt(offset), fmt.Sprintf("unsupported statement %q", m[0]))
+				return m[0]
+			}
+
+			return wrapAction(m[2], out, m[4])
+		default:
+			out, ok := t.expr(m[6])
+			if !ok {
+				warn(lineAt(offset), fmt.Sprintf("unsupported expression %q", m[0]))
+				return m[0]
+			}
+
+			return wrapAction(m[5], out, m[7])
+		}
+	})
+}
+
+// translatePath converts the template expressions within a file path. Paths
+// containing constructs that can't be translated are left as-is.
+func (t *translator) translatePath(p string, source string) string {
+	return t.translateText(p, func(_ int, msg string) {
+		t.res.warnf("%s: path %s", source, msg)
+	})
+}
+
+// skipGlob converts a _copy_without_render glob into a scaffold skip glob.
+// Cookiecutter matches with fnmatch where '*' also matches path separators.
+func (t *translator) skipGlob(glob string, slugDir string) string {
+	glob = strings.TrimPrefix(glob, slugDir+"/")
+	glob = t.translatePath(glob, ContextFile)
+
+	if !strings.Contains(glob, "/") {
+		glob = "**/" + glob
+	}
+
+	return glob
+}
+
+func (t *translator) stmt(stmt string) (string, bool) {
+	keyword, rest, _ := strings.Cut(stmt, " ")
+	rest = strings.TrimSpace(rest)
+
+	switch keyword {
+	case "if":
+		cond, ok := t.cond(rest)
+		return "if " + cond, ok
+	case "elif":
+		cond, ok := t.cond(rest)
+		return "else if " + cond, ok
+	case "else":
+		return "else", rest == ""
+	case "endif":
+		return "end", rest == ""
+	default:
+		return "", false
+	}
+}
+
+func (t *translator) cond(cond string) (string, bool) {
+	for _, op := range [...]string{" or ", " and "} {
+		parts := splitOutsideQuotes(cond, op)
+		if len(parts) < 2 {
+			continue
+		}
+
+		fn := strings.TrimSpace(op)
+		args := make([]string, len(parts))
+		for i, part := range parts {
+			arg, ok := t.cond(strings.TrimSpace(part))
+			if !ok {
+				return "", false
+			}
+			args[i] = "(" + arg + ")"
+		}
+
+		return fn + " " + strings.Join(args, " "), true
+	}
+
+	if rest, ok := strings.CutPrefix(cond, "not "); ok {
+		arg, ok := t.cond(strings.TrimSpace(rest))
+		return "not (" + arg + ")", ok
+	}
+
+	for op, fn := range map[string]string{"==": "eq", "!=": "ne"} {
+		parts := splitOutsideQuotes(cond, op)
+		if len(parts) != 2 {
+			continue
+		}
+
+		left, lok := t.operand(strings.TrimSpace(parts[0]))
+		right, rok := t.operand(strings.TrimSpace(parts[1]))
+		return fn + " " + left + " " + right, lok && rok
+	}
+
+	return t.expr(cond)
+}
+
+// operand translates an expression used as a function argument, wrapping
+// pipelines in parentheses.
+func (t *translator) operand(s string) (string, bool) {
+	out, ok := t.expr(s)
+	if strings.Contains(out, "|") {
+		out = "(" + out + ")"
+	}
+	return out, ok
+}
+
+func (t *translator) expr(expr string) (string, bool) {
+	parts := splitOutsideQuotes(expr, "|")
+
+	out, ok := t.value(strings.TrimSpace(parts[0]))
+	if !ok {
+		return "", false
+	}
+
+	for _, part := range parts[1:] {
+		m := filterPattern.FindStringSubmatch(strings.TrimSpace(part))
+		if m == nil {
+			return "", false
+		}
+
+		fn, ok := filter(m[1], m[2])
+		if !ok {
+			return "", false
+		}
+
+		out += " | " + fn
+	}
+
+	return out, true
+}
+
+func (t *translator) value(s string) (string, bool) {
+	if lit, ok := literal(s); ok {
+		return lit, true
+	}
+
+	m := varPattern.FindStringSubmatch(s)
+	if m == nil {
+		return "", false
+	}
+
+	var out string
+	switch {
+	case m[1] == t.slugVar:
+		out = ".Project"
+	case t.computed[m[1]]:
+		out = ".Computed." + m[1]
+	default:
+		out = ".Scaffold." + m[1]
+	}
+
+	for _, method := range methodPattern.FindAllStringSubmatch(m[2], -1) {
+		fn, ok := filter(method[1], method[2])
+		if !ok {
+			return "", false
+		}
+
+		out += " | " + fn
+	}
+
+	return out, true
+}
+
+// filter maps a Jinja filter or python string method to a template function.
+func filter(name string, args string) (string, bool) {
+	var fn string
+	switch name {
+	case "lower", "upper", "title", "trim":
+		fn = name
+	case "strip":
+		fn = "trim"
+	case "replace":
+		fn = "replace"
+	default:
+		return "", false
+	}
+
+	if strings.TrimSpace(args) == "" {
+		return fn, fn != "replace"
+	}
+
+	parts := splitOutsideQuotes(args, ",")
+	out := []string{fn}
+	for _, part := range parts {
+		lit, ok := literal(strings.TrimSpace(part))
+		if !ok {
+			return "", false
+		}
+		out = append(out, lit)
+	}
+
+	return strings.Join(out, " "), true
+}
+
+// literal converts a python string, boolean or number literal.
+func literal(s string) (string, bool) {
+	if len(s) >= 2 && (s[0] == '\'' || s[0] == '"') && s[len(s)-1] == s[0] {
+		return strconv.Quote(s[1 : len(s)-1]), true
+	}
+
+	switch s {
+	case "True", "true":
+		return "true", true
+	case "False", "false":
+		return "false", true
+	}
+
+	if numberPattern.MatchString(s) {
+		return s, true
+	}
+
+	return "", false
+}
+
+func wrapAction(left, body, right string) string {
+	l := "{{ "
+	if left == "-" {
+		l = "{{- "
+	}
+
+	r := " }}"
+	if right == "-" {
+		r = " -}}"
+	}
+
+	return l + body + r
+}
+
+// splitOutsideQuotes splits s on sep, ignoring separators within single or
+// double quoted strings.
+func splitOutsideQuotes(s string, sep string) []string {
+	var (
+		parts []string
+		quote byte
+		last  int
+	)
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case strings.HasPrefix(s[i:], sep):
+			parts = append(parts, s[last:i])
+			i += len(sep) - 1
+			last = i + 1
+		}
+	}
+
+	return append(parts, s[last:])
+}
+
+// replaceAllIndex is like regexp.ReplaceAllStringFunc but also passes the
+// submatches and the byte offset of the match to fn.
+func replaceAllIndex(re *regexp.Regexp, s string, fn func(m []string, offset int) string) string {
+	var b strings.Builder
+
+	last := 0
+	for _, idx := range re.FindAllStringSubmatchIndex(s, -1) {
+		m := make([]string, len(idx)/2)
+		for i := range m {
+			if idx[2*i] >= 0 {
+				m[i] = s[idx[2*i]:idx[2*i+1]]
+			}
+		}
+
+		b.WriteString(s[last:idx[0]])
+		b.WriteString(fn(m, idx[0]))
+		last = idx[1]
+	}
+
+	b.WriteString(s[last:])
+	return b.String()
+}
+
+func lineOf(s string, offset int) int {
+	return strings.Count(s[:offset], "\n") + 1
+}
+
+// skips reports whether the converted path matches one of the skip globs.
+func (f scaffoldFile) skips(p string) bool {
+	for _, glob := range f.Skip {
+		match, _ := doublestar.PathMatch(glob, p)
+		if match {
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,117 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"regexp"
+	"sync"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/rs/zerolog/log"
+)
+
+// maxDirectiveLen is the number of leading bytes searched for a directive.
+const maxDirectiveLen = 4096
+
+// whenDirectivePatterns caches the compiled when directive pattern for each
+// delimiter pair, there are only a few pairs but many rendered files.
+var whenDirectivePatterns sync.Map // [2]string -> *regexp.Regexp
+
+// whenDirectivePattern matches a when directive on the first line of a
+// template, written as a template comment with the file's delimiters:
+//
+//	{{/* scaffold:when .Scaffold.docker */}}
+func whenDirectivePattern(left, right string) *regexp.Regexp {
+	key := [2]string{left, right}
+	if re, ok := whenDirectivePatterns.Load(key); ok {
+		return re.(*regexp.Regexp)
+	}
+
+	re := regexp.MustCompile(`^` + regexp.QuoteMeta(left) + `-?\s*/\*\s*scaffold:when\s+(.+?)\s*\*/\s*-?` + regexp.QuoteMeta(right) + `[ \t]*(\r?\n|$)`)
+	actual, _ := whenDirectivePatterns.LoadOrStore(key, re)
+	return actual.(*regexp.Regexp)
+}
+
+// parseWhenDirective returns the condition of the when directive on the
+// first line of data and the number of bytes the directive line occupies,
+// including the line break.
+func parseWhenDirective(data []byte, left, right string) (cond string, n int, ok bool) {
+	m := whenDirectivePattern(left, right).FindSubmatchIndex(data)
+	if m == nil {
+		return "", 0, false
+	}
+
+	return string(data[m[2]:m[3]]), m[1], true
+}
+
+// evalCondition evaluates a template pipeline using the template engine's
+// truthiness, so false, 0, empty strings and empty lists are false.
+func evalCondition(eng *engine.Engine, cond string, vars engine.Vars) (bool, error) {
+	out, err := eng.TmplString("{{ if "+cond+" }}true{{ end }}", vars)
+	if err != nil {
+		return false, err
+	}
+
+	return out == "true", nil
+}
+
+// readHead reads up to n leading bytes of the file at path.
+func readHead(fsys fs.FS, path string, n int) ([]byte, error) {
+	f, err := fsys.Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	defer f.Close() //nolint:errcheck
+
+	buf := make([]byte, n)
+	read, err := io.ReadFull(f, buf)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, err
+	}
+
+	return buf[:read], nil
+}
+
+// guardWhenDirective skips files whose when directive evaluates to false.
+func guardWhenDirective(e *engine.Engine, args *RWFSArgs, vars engine.Vars) filepathGuard {
+	return func(sourcePath, outpath string, f fs.DirEntry) (string, error) {
+		if f.IsDir() || isSymlink(f) {
+			return outpath, nil
+		}
+
+		left, right, err := fileDelims(args, sourcePath)
+		if err != nil {
+			return "", err
+		}
+
+		head, err := readHead(args.ReadFS, sourcePath, maxDirectiveLen)
+		if err != nil {
+			return "", err
+		}
+
+		if !bytes.HasPrefix(head, []byte(left)) {
+			return outpath, nil
+		}
+
+		cond, _, ok := parseWhenDirective(head, left, right)
+		if !ok {
+			return outpath, nil
+		}
+
+		include, err := evalCondition(e, cond, vars)
+		if err != nil {
+			return "", fmt.Errorf("%s: evaluating scaffold:when %q: %w", sourcePath, cond, err)
+		}
+
+		if !include {
+			log.Debug().Str("path", sourcePath).Str("when", cond).Msg("when directive is false, skipping")
+			return "", errSkipRender
+		}
+
+		return outpath, nil
+	}
+}
@@ -0,0 +1,158 @@
+package scaffold
+
+import (
+	"errors"
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/apperrors"
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_parseWhenDirective(t *testing.T) {
+	tests := []struct {
+		name  string
+		data  string
+		left  string
+		right string
+		cond  string
+		n     int
+		ok    bool
+	}{
+		{
+			name: "basic",
+			data: "{{/* scaffold:when .Scaffold.docker */}}\nFROM alpine\n",
+			cond: ".Scaffold.docker",
+			n:    41,
+			ok:   true,
+		},
+		{
+			name: "trim markers and crlf",
+			data: "{{- /* scaffold:when eq .Scaffold.db \"pg\" */ -}}\r\nbody",
+			cond: `eq .Scaffold.db "pg"`,
+			n:    50,
+			ok:   true,
+		},
+		{
+			name: "only line",
+			data: "{{/* scaffold:when false */}}",
+			cond: "false",
+			n:    29,
+			ok:   true,
+		},
+		{
+			name:  "custom delimiters",
+			data:  "[[/* scaffold:when .Scaffold.ci */]]\n{{ .Values }}",
+			left:  "[[",
+			right: "]]",
+			cond:  ".Scaffold.ci",
+			n:     37,
+			ok:    true,
+		},
+		{
+			name: "not on first line",
+			data: "\n{{/* scaffold:when .Scaffold.docker */}}\n",
+		},
+		{
+			name: "regular comment",
+			data: "{{/* a comment */}}\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			left, right := tt.left, tt.right
+			if left == "" {
+				left, right = "{{", "}}"
+			}
+
+			cond, n, ok := parseWhenDirective([]byte(tt.data), left, right)
+			assert.Equal(t, tt.ok, ok)
+			assert.Equal(t, tt.cond, cond)
+			assert.Equal(t, tt.n, n)
+		})
+	}
+}
+
+func Test_whenDirectivePattern_Cached(t *testing.T) {
+	re := whenDirectivePattern("{{", "}}")
+	assert.Same(t, re, whenDirectivePattern("{{", "}}"), "compiled once per delimiter pair")
+	assert.NotSame(t, re, whenDirectivePattern("[[", "]]"))
+}
+
+func Test_RenderRWFS_ConditionalPaths(t *testing.T) {
+	readFS := fstest.MapFS{
+		"templates/{{ if .Scaffold.docker }}Dockerfile{{ end }}":     {Data: []byte("FROM alpine\n")},
+		"templates/{{ if .Scaffold.ci }}.github{{ end }}/ci.yml":     {Data: []byte("on: push\n")},
+		"templates/compose.yml":                                      {Data: []byte("{{/* scaffold:when .Scaffold.docker */}}\nservices: {}\n")},
+		"templates/Makefile":                                         {Data: []byte("{{- /* scaffold:when not .Scaffold.docker */ -}}\nbuild:\n")},
+		"templates/README.md":                                        {Data: []byte("# {{ .Project }}\n")},
+		"templates/raw/{{ if .Scaffold.ci }}ci.tmpl{{ end }}":        {Data: []byte("{{ raw }}")},
+		"templates/raw/{{ if not .Scaffold.ci }}no-ci.tmpl{{ end }}": {Data: []byte("{{ raw }}")},
+	}
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         TemplateDirName,
+		Conf: &ProjectScaffoldFile{
+			Skip: []string{"raw/*"},
+		},
+	}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{"docker": true, "ci": false})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	err = fs.WalkDir(memFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(memFS, path)
+		got[path] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"Dockerfile":     "FROM alpine\n",
+		"compose.yml":    "services: {}\n",
+		"README.md":      "# templates\n",
+		"raw/no-ci.tmpl": "{{ raw }}",
+	}, got)
+}
+
+func Test_RenderRWFS_WhenDirectiveErrorLine(t *testing.T) {
+	readFS := fstest.MapFS{
+		"templates/main.go": {Data: []byte("{{/* scaffold:when true */}}\npackage main\n{{ .Missing.Field }\n")},
+	}
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         TemplateDirName,
+		Conf:         &ProjectScaffoldFile{},
+	}
+
+	err := RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: rwfs.NewMemoryWFS(),
+		Project: project,
+	}, engine.Vars{})
+	require.Error(t, err)
+
+	var terr *apperrors.TemplateError
+	require.True(t, errors.As(err, &terr))
+	assert.Equal(t, 3, terr.LineNumber, "line numbers match the source file")
+}
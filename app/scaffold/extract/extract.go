@@ -0,0 +1,361 @@
+// Package extract creates a scaffold from an existing project by replacing
+// literal values with template variables.
+package extract
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/hay-kot/scaffold/app/core/detect"
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/hay-kot/scaffold/app/scaffold/internal/convert"
+	"github.com/huandu/xstrings"
+	"gopkg.in/yaml.v3"
+)
+
+// ProjectVar is the mapping target for the project name.
+const ProjectVar = ".Project"
+
+// DefaultIgnore is always excluded from extraction.
+var DefaultIgnore = []string{".git", ".git/**"}
+
+// Mapping maps a literal value in the source project to a template variable.
+type Mapping struct {
+	Literal string
+	// Var is either ProjectVar or the name of a question, which is available
+	// to templates as .Scaffold.<Var>.
+	Var string
+}
+
+// IsProject reports whether the mapping targets the project name.
+func (m Mapping) IsProject() bool {
+	return m.Var == ProjectVar
+}
+
+// ParseMappings parses arguments in the literal=variable format. The variable
+// can be '.Project', '.Scaffold.<name>' or a bare question name.
+func ParseMappings(args []string) ([]Mapping, error) {
+	out := make([]Mapping, 0, len(args))
+
+	for _, arg := range args {
+		// Variable names never contain '=' but literals might.
+		idx := strings.LastIndex(arg, "=")
+		if idx == -1 {
+			return nil, fmt.Errorf("invalid mapping %q: missing '='", arg)
+		}
+
+		literal, variable := arg[:idx], strings.TrimSpace(arg[idx+1:])
+		if literal == "" {
+			return nil, fmt.Errorf("invalid mapping %q: empty literal", arg)
+		}
+
+		switch {
+		case variable == ProjectVar || variable == "Project":
+			variable = ProjectVar
+		default:
+			variable = strings.TrimPrefix(variable, ".Scaffold.")
+			if variable == "" || !engine.IsValidIdentifier(variable) {
+				return nil, fmt.Errorf("invalid mapping %q: invalid variable name", arg)
+			}
+		}
+
+		out = append(out, Mapping{Literal: literal, Var: variable})
+	}
+
+	return out, nil
+}
+
+// DestIgnore returns the ignore globs that exclude dest from an extraction of
+// source, so a scaffold written inside the project isn't extracted into
+// itself. Both paths are resolved, a dest outside of source needs no globs and
+// a dest that is source is an error.
+func DestIgnore(source, dest string) ([]string, error) {
+	resolve := func(p string) (string, error) {
+		abs, err := filepath.Abs(p)
+		if err != nil {
+			return "", err
+		}
+
+		// dest may not exist yet, its absolute path is used as is.
+		resolved, err := filepath.EvalSymlinks(abs)
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) {
+				return abs, nil
+			}
+			return "", err
+		}
+
+		return resolved, nil
+	}
+
+	src, err := resolve(source)
+	if err != nil {
+		return nil, err
+	}
+
+	dst, err := resolve(dest)
+	if err != nil {
+		return nil, err
+	}
+
+	rel, err := filepath.Rel(src, dst)
+	if err != nil {
+		return nil, nil
+	}
+
+	switch {
+	case rel == ".":
+		return nil, fmt.Errorf("destination %s is the source directory", dest)
+	case rel == ".." || strings.HasPrefix(rel, ".."+string(filepath.Separator)):
+		return nil, nil
+	}
+
+	glob := convert.EscapeGlob(filepath.ToSlash(rel))
+	return []string{glob, glob + "/**"}, nil
+}
+
+type Options struct {
+	Mappings []Mapping
+	// Ignore contains globs, relative to the project root, of paths that are
+	// excluded from the scaffold in addition to DefaultIgnore.
+	Ignore []string
+}
+
+// Result summarizes an extraction.
+type Result struct {
+	// NameTemplate is the directory the project files were written to.
+	NameTemplate string
+	// Files is the number of files written to the scaffold.
+	Files int
+	// Replacements maps each replaced string to its template and the number
+	// of times it was replaced.
+	Replacements []Replacement
+	// Warnings lists case variants that could not be mapped.
+	Warnings []string
+}
+
+type Replacement struct {
+	Literal  string
+	Template string
+	Count    int
+}
+
+// Extract copies the project in src into a new scaffold in dst, replacing
+// every mapped literal, and its case variants, in file contents and paths.
+func Extract(eng *engine.Engine, src fs.FS, dst rwfs.WriteFS, opts Options) (*Result, error) {
+	if len(opts.Mappings) == 0 {
+		return nil, errors.New("at least one mapping is required")
+	}
+
+	res := &Result{NameTemplate: "templates"}
+	for _, m := range opts.Mappings {
+		if m.IsProject() {
+			res.NameTemplate = "{{ .Project }}"
+		}
+	}
+
+	replacements := variants(eng, opts.Mappings, res)
+	counts := make([]int, len(replacements))
+
+	oldnew := []string{"{{", `{{ "{{" }}`}
+	for _, r := range replacements {
+		oldnew = append(oldnew, r.Literal, r.Template)
+	}
+	replacer := strings.NewReplacer(oldnew...)
+
+	replace := func(s string) string {
+		out := replacer.Replace(s)
+		// Templates can't appear in the escaped input so every occurrence
+		// in the output is a replacement.
+		for i, r := range replacements {
+			counts[i] += strings.Count(out, r.Template)
+		}
+		return out
+	}
+
+	conf := scaffoldFile{}
+	for _, m := range opts.Mappings {
+		if m.IsProject() {
+			continue
+		}
+
+		conf.Questions = append(conf.Questions, question{
+			Name: m.Var,
+			Prompt: prompt{
+				Message: convert.Humanize(m.Var),
+				Default: m.Literal,
+			},
+		})
+	}
+
+	ignore := append(DefaultIgnore[:len(DefaultIgnore):len(DefaultIgnore)], opts.Ignore...)
+
+	err := fs.WalkDir(src, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if p == "." {
+			return nil
+		}
+
+		for _, glob := range ignore {
+			match, err := doublestar.Match(glob, p)
+			if err != nil {
+				return fmt.Errorf("invalid ignore pattern %q: %w", glob, err)
+			}
+
+			if match {
+				if d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(src, p)
+		if err != nil {
+			return err
+		}
+
+		outrel := replace(p)
+
+		if detect.IsBinary(data) {
+			// Binary files can't be templated, they are copied verbatim and
+			// excluded from rendering. The skip pattern matches the template
+			// path, which may contain template actions.
+			conf.Skip = append(conf.Skip, convert.EscapeGlob(outrel))
+		} else {
+			data = []byte(replace(string(data)))
+		}
+
+		res.Files++
+		return convert.WriteFile(dst, path.Join(res.NameTemplate, outrel), data, convert.FileMode(d))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i, r := range replacements {
+		r.Count = counts[i]
+		res.Replacements = append(res.Replacements, r)
+	}
+
+	buff := bytes.NewBuffer(nil)
+	enc := yaml.NewEncoder(buff)
+	enc.SetIndent(2)
+	err = enc.Encode(conf)
+	if err != nil {
+		return nil, err
+	}
+
+	err = dst.WriteFile("scaffold.yaml", buff.Bytes(), 0o644)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// variants returns the replacements for every mapping, including the case
+// variants of each literal, ordered longest literal first so that longer
+// matches take precedence.
+//
+// Variants are detected with the same xstrings conversions that BuildVars
+// uses for the .Project* variables. Question variables are rendered with the
+// engine's case functions, which are only used when they produce the same
+// result as xstrings for the literal.
+func variants(eng *engine.Engine, mappings []Mapping, res *Result) []Replacement {
+	type variant struct {
+		convert func(string) string
+		project string
+		fn      string
+	}
+
+	conversions := []variant{
+		{convert: xstrings.ToSnakeCase, project: ".ProjectSnake", fn: "toSnakeCase"},
+		{convert: xstrings.ToKebabCase, project: ".ProjectKebab", fn: "toKebabCase"},
+		{convert: xstrings.ToCamelCase, project: ".ProjectCamel", fn: "toCamelCase"},
+		{convert: xstrings.ToPascalCase, project: ".ProjectPascal", fn: "toPascalCase"},
+	}
+
+	seen := map[string]bool{}
+	out := []Replacement{}
+
+	add := func(literal, tmpl string) {
+		if literal == "" || seen[literal] {
+			return
+		}
+
+		seen[literal] = true
+		out = append(out, Replacement{Literal: literal, Template: "{{ " + tmpl + " }}"})
+	}
+
+	// Exact literals are added first so they win over a colliding variant of
+	// another mapping.
+	for _, m := range mappings {
+		if m.IsProject() {
+			add(m.Literal, ProjectVar)
+		} else {
+			add(m.Literal, ".Scaffold."+m.Var)
+		}
+	}
+
+	for _, m := range mappings {
+		for _, c := range conversions {
+			literal := c.convert(m.Literal)
+			if seen[literal] {
+				continue
+			}
+
+			if m.IsProject() {
+				add(literal, c.project)
+				continue
+			}
+
+			tmpl := ".Scaffold." + m.Var + " | " + c.fn
+
+			got, err := eng.TmplString("{{ "+tmpl+" }}", engine.Vars{"Scaffold": engine.Vars{m.Var: m.Literal}})
+			if err != nil || got != literal {
+				res.Warnings = append(res.Warnings, fmt.Sprintf("%q (%s of %q) was not replaced: %s renders %q", literal, c.fn, m.Literal, c.fn, got))
+				continue
+			}
+
+			add(literal, tmpl)
+		}
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return len(out[i].Literal) > len(out[j].Literal)
+	})
+
+	return out
+}
+
+// scaffoldFile is the subset of the scaffold.yaml format produced by Extract.
+type scaffoldFile struct {
+	Questions []question `yaml:"questions,omitempty"`
+	Skip      []string   `yaml:"skip,omitempty"`
+}
+
+type question struct {
+	Name   string `yaml:"name"`
+	Prompt prompt `yaml:"prompt"`
+}
+
+type prompt struct {
+	Message string `yaml:"message"`
+	Default string `yaml:"default,omitempty"`
+}
@@ -0,0 +1,188 @@
+package extract
+
+import (
+	"io/fs"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseMappings(t *testing.T) {
+	got, err := ParseMappings([]string{
+		"acme-billing=.Project",
+		"8080=.Scaffold.port",
+		"a=b=owner",
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, []Mapping{
+		{Literal: "acme-billing", Var: ProjectVar},
+		{Literal: "8080", Var: "port"},
+		{Literal: "a=b", Var: "owner"},
+	}, got)
+
+	for _, bad := range []string{"novalue", "=.Project", "x=.Scaffold.bad-name"} {
+		_, err := ParseMappings([]string{bad})
+		assert.Error(t, err, bad)
+	}
+}
+
+func TestExtract(t *testing.T) {
+	src := fstest.MapFS{
+		"go.mod":                    {Data: []byte("module github.com/acme/acme-billing\n")},
+		"cmd/acme_billing/main.go":  {Data: []byte("// AcmeBilling listens on 8080\nvar acmeBilling = \"{{ raw }}\"\n")},
+		"cmd/acme_billing/icon.bin": {Data: []byte{0x00, '{', '{'}},
+		"assets/logo.png":           {Data: []byte{0x89, 'P', 'N', 'G', 0x00, 0x01}},
+		".git/HEAD":                 {Data: []byte("ref: refs/heads/main\n")},
+		"node_modules/dep/index.js": {Data: []byte("")},
+	}
+
+	mappings, err := ParseMappings([]string{"acme-billing=.Project", "8080=port"})
+	require.NoError(t, err)
+
+	dst := rwfs.NewMemoryWFS()
+	res, err := Extract(engine.New(), src, dst, Options{
+		Mappings: mappings,
+		Ignore:   []string{"node_modules/**"},
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, "{{ .Project }}", res.NameTemplate)
+	assert.Equal(t, 4, res.Files)
+	assert.Empty(t, res.Warnings)
+
+	read := func(p string) string {
+		t.Helper()
+		b, err := fs.ReadFile(dst, p)
+		require.NoError(t, err)
+		return string(b)
+	}
+
+	assert.Equal(t, "module github.com/acme/{{ .Project }}\n", read("{{ .Project }}/go.mod"))
+	assert.Equal(t,
+		"// {{ .ProjectPascal }} listens on {{ .Scaffold.port }}\nvar {{ .ProjectCamel }} = \"{{ \"{{\" }} raw }}\"\n",
+		read("{{ .Project }}/cmd/{{ .ProjectSnake }}/main.go"),
+	)
+	assert.Equal(t, string([]byte{0x89, 'P', 'N', 'G', 0x00, 0x01}), read("{{ .Project }}/assets/logo.png"))
+
+	assert.Equal(t, `questions:
+  - name: port
+    prompt:
+      message: Port
+      default: "8080"
+skip:
+  - assets/logo.png
+  - cmd/\{\{ .ProjectSnake \}\}/icon.bin
+`, read("scaffold.yaml"))
+
+	// Skip patterns are matched against the template paths.
+	match, err := doublestar.PathMatch(`cmd/\{\{ .ProjectSnake \}\}/icon.bin`, "cmd/{{ .ProjectSnake }}/icon.bin")
+	require.NoError(t, err)
+	assert.True(t, match)
+	assert.Equal(t, string([]byte{0x00, '{', '{'}), read("{{ .Project }}/cmd/{{ .ProjectSnake }}/icon.bin"))
+
+	_, err = fs.Stat(dst, "{{ .Project }}/.git/HEAD")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+	_, err = fs.Stat(dst, "{{ .Project }}/node_modules/dep/index.js")
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+
+	counts := map[string]int{}
+	for _, r := range res.Replacements {
+		counts[r.Literal] = r.Count
+	}
+
+	assert.Equal(t, map[string]int{
+		"acme-billing": 1,
+		"acme_billing": 2,
+		"acmeBilling":  1,
+		"AcmeBilling":  1,
+		"8080":         1,
+	}, counts)
+}
+
+func TestExtract_RendersBackToSource(t *testing.T) {
+	src := fstest.MapFS{
+		"README.md": {Data: []byte("my-service my_service MyService myService\n")},
+	}
+
+	dst := rwfs.NewMemoryWFS()
+	res, err := Extract(engine.New(), src, dst, Options{
+		Mappings: []Mapping{{Literal: "my-service", Var: "service"}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, "templates", res.NameTemplate)
+
+	tmpl, err := fs.ReadFile(dst, "templates/README.md")
+	require.NoError(t, err)
+
+	out, err := engine.New().TmplString(string(tmpl), engine.Vars{
+		"Scaffold": engine.Vars{"service": "my-service"},
+	})
+	require.NoError(t, err)
+
+	for _, w := range res.Warnings {
+		t.Log(w)
+	}
+
+	// Every variant the engine can reproduce renders back to the original.
+	assert.Equal(t, "my-service my_service MyService myService\n", out)
+}
+
+func TestDestIgnore(t *testing.T) {
+	root := t.TempDir()
+
+	tests := []struct {
+		name    string
+		dest    string
+		want    []string
+		wantErr bool
+	}{
+		{name: "outside", dest: filepath.Join(filepath.Dir(root), "other"), want: nil},
+		{name: "inside", dest: filepath.Join(root, ".scaffold", "app"), want: []string{".scaffold/app", ".scaffold/app/**"}},
+		{name: "escaped", dest: filepath.Join(root, "[tmpl]"), want: []string{`\[tmpl\]`, `\[tmpl\]/**`}},
+		{name: "source", dest: root, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DestIgnore(root, tt.dest)
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestExtract_DestInsideSource(t *testing.T) {
+	src := t.TempDir()
+	dest := filepath.Join(src, ".scaffold", "app")
+
+	require.NoError(t, os.WriteFile(filepath.Join(src, "main.go"), []byte("package acme\n"), 0o644))
+	// A scaffold from an earlier run is in the destination.
+	require.NoError(t, os.MkdirAll(filepath.Join(dest, "templates"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dest, "templates", "main.go"), []byte("package acme\n"), 0o644))
+
+	ignore, err := DestIgnore(src, dest)
+	require.NoError(t, err)
+
+	res, err := Extract(engine.New(), os.DirFS(src), rwfs.NewOsWFS(dest), Options{
+		Mappings: []Mapping{{Literal: "acme", Var: "pkg"}},
+		Ignore:   ignore,
+	})
+	require.NoError(t, err)
+	assert.Equal(t, 1, res.Files)
+
+	_, err = os.Stat(filepath.Join(dest, "templates", ".scaffold"))
+	assert.ErrorIs(t, err, fs.ErrNotExist, "destination is not extracted into itself")
+}
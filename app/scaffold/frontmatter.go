@@ -0,0 +1,313 @@
+package scaffold
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/rs/zerolog/log"
+	"gopkg.in/yaml.v3"
+)
+
+// maxFrontMatterLen is the number of leading bytes searched for front matter.
+const maxFrontMatterLen = 64 << 10
+
+var (
+	frontMatterOpen  = []byte("---scaffold")
+	frontMatterClose = []byte("---")
+)
+
+type MergeStrategy string
+
+const (
+	// MergeReplace replaces the existing file, this is the default.
+	MergeReplace MergeStrategy = "replace"
+	// MergeAppend adds the rendered output to the end of the existing file.
+	MergeAppend MergeStrategy = "append"
+	// MergePrepend adds the rendered output to the start of the existing file.
+	MergePrepend MergeStrategy = "prepend"
+)
+
+type OverwritePolicy string
+
+const (
+	// OverwriteAlways overwrites existing files, even when no-clobber is set.
+	OverwriteAlways OverwritePolicy = "always"
+	// OverwriteNever leaves existing files untouched.
+	OverwriteNever OverwritePolicy = "never"
+	// OverwritePrompt asks before overwriting an existing file.
+	OverwritePrompt OverwritePolicy = "prompt"
+)
+
+// FrontMatter holds the per-file options set in a template's front matter.
+// Front matter is a YAML block at the start of a template, opened with
+// "---scaffold" and closed with "---", that is removed before rendering.
+type FrontMatter struct {
+	// Path overrides the output path, relative to the output directory. It is
+	// rendered as a template and an empty result skips the file.
+	Path string `yaml:"path"`
+	// When is a condition, either a pipeline or a template, the file is only
+	// rendered when it is true.
+	When string `yaml:"when"`
+	// Delimiters overrides the template delimiters of the file.
+	Delimiters *Delimiters `yaml:"delimiters"`
+	// Mode is the octal file mode of the output, e.g. "0755".
+	Mode string `yaml:"mode"`
+	// Render copies the file without rendering when false.
+	Render    *bool           `yaml:"render"`
+	Merge     MergeStrategy   `yaml:"merge"`
+	Overwrite OverwritePolicy `yaml:"overwrite"`
+
+	// lines is the number of lines the front matter occupies.
+	lines int
+	// size is the number of bytes the front matter occupies.
+	size int
+}
+
+// FileMode returns the parsed Mode, ok is false when it is not set.
+func (fm *FrontMatter) FileMode() (mode fs.FileMode, ok bool) {
+	if fm.Mode == "" {
+		return 0, false
+	}
+
+	v, _ := strconv.ParseUint(fm.Mode, 8, 32)
+	return fs.FileMode(v).Perm(), true
+}
+
+// Renders reports whether the file is rendered as a template.
+func (fm *FrontMatter) Renders() bool {
+	return fm.Render == nil || *fm.Render
+}
+
+func (fm *FrontMatter) validate() error {
+	if fm.Mode != "" {
+		v, err := strconv.ParseUint(fm.Mode, 8, 32)
+		if err != nil || v > 0o777 {
+			return fmt.Errorf("invalid mode %q: must be an octal permission like \"0644\"", fm.Mode)
+		}
+	}
+
+	if fm.Delimiters != nil && (fm.Delimiters.Left == "" || fm.Delimiters.Right == "") {
+		return fmt.Errorf("delimiters must set both left and right")
+	}
+
+	switch fm.Merge {
+	case "", MergeReplace, MergeAppend, MergePrepend:
+	default:
+		return fmt.Errorf("invalid merge %q: must be one of replace, append, prepend", fm.Merge)
+	}
+
+	switch fm.Overwrite {
+	case "", OverwriteAlways, OverwriteNever, OverwritePrompt:
+	default:
+		return fmt.Errorf("invalid overwrite %q: must be one of always, never, prompt", fm.Overwrite)
+	}
+
+	return nil
+}
+
+// ParseFrontMatter parses the front matter at the start of data. It returns
+// nil when data has no front matter.
+func ParseFrontMatter(data []byte) (*FrontMatter, error) {
+	line, rest, _ := cutLine(data)
+	if !bytes.Equal(bytes.TrimRight(line, " \t"), frontMatterOpen) {
+		return nil, nil
+	}
+
+	lines := 1
+	body := rest
+
+	for len(rest) > 0 {
+		line, next, hasNewline := cutLine(rest)
+		lines++
+
+		if bytes.Equal(bytes.TrimRight(line, " \t"), frontMatterClose) {
+			fm := &FrontMatter{}
+
+			dec := yaml.NewDecoder(bytes.NewReader(body[:len(body)-len(rest)]))
+			dec.KnownFields(true)
+
+			err := dec.Decode(fm)
+			if err != nil && !errors.Is(err, io.EOF) {
+				return nil, fmt.Errorf("front matter: %w", err)
+			}
+
+			err = fm.validate()
+			if err != nil {
+				return nil, fmt.Errorf("front matter: %w", err)
+			}
+
+			fm.size = len(data) - len(next)
+			fm.lines = lines
+			if !hasNewline {
+				fm.lines--
+			}
+
+			return fm, nil
+		}
+
+		rest = next
+	}
+
+	return nil, fmt.Errorf("front matter: missing closing ---")
+}
+
+// cutLine splits data after the first line break, line excludes the line
+// break.
+func cutLine(data []byte) (line, rest []byte, found bool) {
+	line, rest, found = bytes.Cut(data, []byte("\n"))
+	return bytes.TrimSuffix(line, []byte("\r")), rest, found
+}
+
+// frontMatter returns the front matter of the source file at path, or nil
+// when it has none. Results are cached for the duration of the render.
+func (args *RWFSArgs) frontMatter(path string) (*FrontMatter, error) {
+	if fm, ok := args.frontMatters[path]; ok {
+		return fm, nil
+	}
+
+	head, err := readHead(args.ReadFS, path, maxFrontMatterLen)
+	if err != nil {
+		return nil, err
+	}
+
+	fm, err := ParseFrontMatter(head)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	if args.frontMatters == nil {
+		args.frontMatters = map[string]*FrontMatter{}
+	}
+
+	args.frontMatters[path] = fm
+	return fm, nil
+}
+
+// evalWhen evaluates a when condition. Conditions containing the template
+// delimiters are rendered and parsed as a bool, like feature flags, otherwise
+// they are evaluated as a pipeline.
+func evalWhen(eng *engine.Engine, cond string, vars engine.Vars) (bool, error) {
+	if !strings.Contains(cond, "{{") {
+		return evalCondition(eng, cond, vars)
+	}
+
+	out, err := eng.TmplString(cond, vars)
+	if err != nil {
+		return false, err
+	}
+
+	b, _ := strconv.ParseBool(strings.TrimSpace(out))
+	return b, nil
+}
+
+// guardFrontMatter applies the path and when options of the front matter.
+// The path is relative to the output root, not the project directory, and
+// replaces outpath for the guards that follow, feature globs included.
+func guardFrontMatter(e *engine.Engine, args *RWFSArgs, vars engine.Vars) filepathGuard {
+	return func(sourcePath, outpath string, f fs.DirEntry) (string, error) {
+		if f.IsDir() || isSymlink(f) {
+			return outpath, nil
+		}
+
+		fm, err := args.frontMatter(sourcePath)
+		if err != nil || fm == nil {
+			return outpath, err
+		}
+
+		if fm.When != "" {
+			include, err := evalWhen(e, fm.When, vars)
+			if err != nil {
+				return "", fmt.Errorf("%s: evaluating front matter when %q: %w", sourcePath, fm.When, err)
+			}
+
+			if !include {
+				log.Debug().Str("path", sourcePath).Str("when", fm.When).Msg("front matter when is false, skipping")
+				return "", errSkipRender
+			}
+		}
+
+		if fm.Path != "" {
+			out, err := e.TmplString(fm.Path, vars)
+			if err != nil {
+				return "", fmt.Errorf("%s: rendering front matter path: %w", sourcePath, err)
+			}
+
+			out = strings.TrimSpace(out)
+			if out == "" {
+				return "", errSkipRender
+			}
+
+			// The path must stay inside the output directory.
+			clean := path.Clean(filepath.ToSlash(out))
+			if path.IsAbs(clean) || filepath.IsAbs(out) || clean == "." || clean == ".." || strings.HasPrefix(clean, "../") {
+				return "", fmt.Errorf("%s: front matter path %q must be relative to the output directory", sourcePath, out)
+			}
+
+			return clean, nil
+		}
+
+		return outpath, nil
+	}
+}
+
+// guardOverwrite lets files with the overwrite or merge front matter options
+// through to be resolved when they are written, other files fall back to the
+// no-clobber option.
+func guardOverwrite(args *RWFSArgs) filepathGuard {
+	noClobber := guardNoClobber(args)
+
+	return func(sourcePath, outpath string, f fs.DirEntry) (string, error) {
+		if f.IsDir() || isSymlink(f) {
+			return noClobber(sourcePath, outpath, f)
+		}
+
+		fm, err := args.frontMatter(sourcePath)
+		if err != nil {
+			return "", err
+		}
+
+		if fm == nil || (fm.Overwrite == "" && (fm.Merge == "" || fm.Merge == MergeReplace)) {
+			return noClobber(sourcePath, outpath, f)
+		}
+
+		return outpath, nil
+	}
+}
+
+// mergeOutput combines the rendered output with the existing file at outpath
+// according to the merge strategy.
+func mergeOutput(args *RWFSArgs, strategy MergeStrategy, outpath string, data []byte) ([]byte, error) {
+	if strategy != MergeAppend && strategy != MergePrepend {
+		return data, nil
+	}
+
+	existing, err := fs.ReadFile(args.WriteFS, outpath)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return data, nil
+		}
+		return nil, err
+	}
+
+	first, second := existing, data
+	if strategy == MergePrepend {
+		first, second = data, existing
+	}
+
+	out := make([]byte, 0, len(first)+len(second)+1)
+	out = append(out, first...)
+	if len(first) > 0 && len(second) > 0 && first[len(first)-1] != '\n' {
+		out = append(out, '\n')
+	}
+
+	return append(out, second...), nil
+}
@@ -0,0 +1,278 @@
+package scaffold
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseFrontMatter(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    *FrontMatter
+		body    string
+		wantErr bool
+	}{
+		{
+			name: "none",
+			data: "package main\n",
+			body: "package main\n",
+		},
+		{
+			name: "options",
+			data: "---scaffold\npath: cmd/{{ .Project }}/main.go\nmode: \"0755\"\nmerge: append\n---\npackage main\n",
+			want: &FrontMatter{
+				Path:  "cmd/{{ .Project }}/main.go",
+				Mode:  "0755",
+				Merge: MergeAppend,
+				lines: 5,
+				size:  76,
+			},
+			body: "package main\n",
+		},
+		{
+			name: "empty block and crlf",
+			data: "---scaffold\r\n---\r\nbody",
+			want: &FrontMatter{lines: 2, size: 18},
+			body: "body",
+		},
+		{
+			name: "no body",
+			data: "---scaffold\noverwrite: never\n---",
+			want: &FrontMatter{Overwrite: OverwriteNever, lines: 2, size: 32},
+		},
+		{
+			name:    "missing close",
+			data:    "---scaffold\npath: x\n",
+			wantErr: true,
+		},
+		{
+			name:    "unknown key",
+			data:    "---scaffold\npaht: x\n---\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid mode",
+			data:    "---scaffold\nmode: \"0999\"\n---\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid merge",
+			data:    "---scaffold\nmerge: sideways\n---\n",
+			wantErr: true,
+		},
+		{
+			name:    "invalid overwrite",
+			data:    "---scaffold\noverwrite: sometimes\n---\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ParseFrontMatter([]byte(tt.data))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+
+			if got != nil {
+				assert.Equal(t, tt.body, tt.data[got.size:])
+			}
+		})
+	}
+}
+
+func Test_RenderRWFS_FrontMatter(t *testing.T) {
+	readFS := fstest.MapFS{
+		"templates/main.go":      {Data: []byte("---scaffold\npath: cmd/{{ .Project }}/main.go\n---\npackage main\n")},
+		"templates/run.sh":       {Data: []byte("---scaffold\nmode: \"0755\"\n---\necho {{ .Project }}\n")},
+		"templates/Dockerfile":   {Data: []byte("---scaffold\nwhen: .Scaffold.docker\n---\nFROM alpine\n")},
+		"templates/values.yaml":  {Data: []byte("---scaffold\ndelimiters:\n  left: \"[[\"\n  right: \"]]\"\n---\nname: [[ .Project ]]\nimage: {{ .Values.image }}\n")},
+		"templates/raw.tmpl":     {Data: []byte("---scaffold\nrender: false\n---\n{{ raw }}\n")},
+		"templates/.gitignore":   {Data: []byte("---scaffold\nmerge: append\n---\n/{{ .Project }}\n")},
+		"templates/CHANGELOG.md": {Data: []byte("---scaffold\nmerge: prepend\n---\n# {{ .Project }}\n")},
+		"templates/config.yml":   {Data: []byte("---scaffold\noverwrite: never\n---\nnew: true\n")},
+		"templates/VERSION":      {Data: []byte("---scaffold\noverwrite: always\n---\n2\n")},
+	}
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         "app",
+		Conf:         &ProjectScaffoldFile{},
+		Options:      Options{NoClobber: true},
+	}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{"docker": false})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	require.NoError(t, memFS.WriteFile(".gitignore", []byte("*.log"), 0o644))
+	require.NoError(t, memFS.WriteFile("CHANGELOG.md", []byte("## v1\n"), 0o644))
+	require.NoError(t, memFS.WriteFile("config.yml", []byte("new: false\n"), 0o644))
+	require.NoError(t, memFS.WriteFile("VERSION", []byte("1\n"), 0o644))
+
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	got := map[string]string{}
+	err = fs.WalkDir(memFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(memFS, path)
+		got[path] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]string{
+		"cmd/app/main.go": "package main\n",
+		"run.sh":          "echo app\n",
+		"values.yaml":     "name: app\nimage: {{ .Values.image }}\n",
+		"raw.tmpl":        "{{ raw }}\n",
+		".gitignore":      "*.log\n/app\n",
+		"CHANGELOG.md":    "# app\n## v1\n",
+		"config.yml":      "new: false\n",
+		"VERSION":         "2\n",
+	}, got)
+
+	info, err := fs.Stat(memFS, "run.sh")
+	require.NoError(t, err)
+	assert.Equal(t, fs.FileMode(0o755), info.Mode().Perm())
+}
+
+func Test_RenderRWFS_FrontMatterPathEscapes(t *testing.T) {
+	paths := []string{
+		"/etc/{{ .Project }}",
+		"../{{ .Project }}.go",
+		"cmd/../../{{ .Project }}.go",
+		"{{ .Project }}/..",
+	}
+
+	for _, p := range paths {
+		t.Run(p, func(t *testing.T) {
+			readFS := fstest.MapFS{
+				"templates/main.go": {Data: []byte("---scaffold\npath: \"" + p + "\"\n---\npackage main\n")},
+			}
+
+			project := &Project{NameTemplate: TemplateDirName, Name: "app", Conf: &ProjectScaffoldFile{}}
+
+			vars, err := BuildVars(tEngine, project, engine.Vars{})
+			require.NoError(t, err)
+
+			err = RenderRWFS(tEngine, &RWFSArgs{
+				ReadFS:  readFS,
+				WriteFS: rwfs.NewMemoryWFS(),
+				Project: project,
+			}, vars)
+			require.ErrorContains(t, err, "must be relative to the output directory")
+		})
+	}
+}
+
+func Test_RenderRWFS_FrontMatterPathFeatures(t *testing.T) {
+	readFS := fstest.MapFS{
+		"{{ .Project }}/docs.md":  {Data: []byte("---scaffold\npath: \"docs/{{ .Project }}.md\"\n---\n# docs\n"), Mode: 0o644},
+		"{{ .Project }}/main.go":  {Data: []byte("---scaffold\npath: \"{{ .Project }}/cmd/main.go\"\n---\npackage main\n"), Mode: 0o644},
+		"{{ .Project }}/other.go": {Data: []byte("package other\n")},
+	}
+
+	project := &Project{
+		NameTemplate: "{{ .Project }}",
+		Name:         "app",
+		Conf: &ProjectScaffoldFile{
+			Features: []Feature{
+				// Matches the source paths of docs.md and main.go, but not the
+				// paths set by their front matter.
+				{Value: "false", Globs: []string{"app/docs.md", "app/main.go"}},
+				// Matches the front matter path of docs.md.
+				{Value: "{{ .Scaffold.docs }}", Globs: []string{"docs/**"}},
+			},
+		},
+	}
+
+	render := func(docs bool) []string {
+		vars, err := BuildVars(tEngine, project, engine.Vars{"docs": docs})
+		require.NoError(t, err)
+
+		memFS := rwfs.NewMemoryWFS()
+		err = RenderRWFS(tEngine, &RWFSArgs{ReadFS: readFS, WriteFS: memFS, Project: project}, vars)
+		require.NoError(t, err)
+
+		files := []string{}
+		err = fs.WalkDir(memFS, ".", func(path string, d fs.DirEntry, err error) error {
+			if err == nil && !d.IsDir() {
+				files = append(files, path)
+			}
+			return err
+		})
+		require.NoError(t, err)
+
+		return files
+	}
+
+	// The path is relative to the output root, feature globs see it instead
+	// of the source path.
+	assert.ElementsMatch(t, []string{"docs/app.md", "app/cmd/main.go", "app/other.go"}, render(true))
+	assert.ElementsMatch(t, []string{"app/cmd/main.go", "app/other.go"}, render(false))
+}
+
+func Test_RenderRWFS_FrontMatterPrompt(t *testing.T) {
+	readFS := fstest.MapFS{
+		"templates/a.txt": {Data: []byte("---scaffold\noverwrite: prompt\n---\nnew a\n")},
+		"templates/b.txt": {Data: []byte("---scaffold\noverwrite: prompt\n---\nnew b\n")},
+	}
+
+	asked := []string{}
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         TemplateDirName,
+		Conf:         &ProjectScaffoldFile{},
+		Options: Options{
+			NoClobber: true,
+			ResolveConflict: func(c Conflict) (ConflictAction, bool, error) {
+				asked = append(asked, c.Path)
+				if c.Path == "a.txt" {
+					return ConflictOverwrite, false, nil
+				}
+				return ConflictKeep, false, nil
+			},
+		},
+	}
+
+	memFS := rwfs.NewMemoryWFS()
+	require.NoError(t, memFS.WriteFile("a.txt", []byte("old a\n"), 0o644))
+	require.NoError(t, memFS.WriteFile("b.txt", []byte("old b\n"), 0o644))
+
+	err := RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, engine.Vars{})
+	require.NoError(t, err)
+
+	assert.ElementsMatch(t, []string{"a.txt", "b.txt"}, asked)
+
+	a, err := fs.ReadFile(memFS, "a.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "new a\n", string(a))
+
+	b, err := fs.ReadFile(memFS, "b.txt")
+	require.NoError(t, err)
+	assert.Equal(t, "old b\n", string(b))
+}
@@ -0,0 +1,64 @@
+// Package convert contains helpers shared by the packages that create
+// scaffolds from other sources, like cookiecutter templates and existing
+// projects.
+package convert
+
+import (
+	"io/fs"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+)
+
+// Humanize converts a variable name like project_name to "Project name".
+func Humanize(key string) string {
+	s := strings.TrimSpace(strings.ReplaceAll(key, "_", " "))
+	if s == "" {
+		return key
+	}
+
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// FileMode returns the permission bits of the source file, falling back to
+// 0644 when they aren't reported.
+func FileMode(d fs.DirEntry) fs.FileMode {
+	info, err := d.Info()
+	if err != nil || info.Mode().Perm() == 0 {
+		return 0o644
+	}
+
+	return info.Mode().Perm() | 0o600
+}
+
+// WriteFile writes data to p with the given mode, creating the parent
+// directories as needed.
+func WriteFile(dst rwfs.WriteFS, p string, data []byte, mode fs.FileMode) error {
+	err := dst.MkdirAll(path.Dir(p), os.ModePerm)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	err = dst.WriteFile(p, data, mode)
+	if err != nil {
+		return err
+	}
+
+	return dst.Chmod(p, mode)
+}
+
+// EscapeGlob escapes the glob meta characters of p so it can be used as a
+// pattern matching only itself.
+func EscapeGlob(p string) string {
+	var b strings.Builder
+	for _, r := range p {
+		if strings.ContainsRune(`*?[]{}\`, r) {
+			b.WriteByte('\\')
+		}
+		b.WriteRune(r)
+	}
+
+	return b.String()
+}
@@ -0,0 +1,47 @@
+package convert
+
+import (
+	"testing"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHumanize(t *testing.T) {
+	tests := []struct {
+		key  string
+		want string
+	}{
+		{key: "project_name", want: "Project name"},
+		{key: "port", want: "Port"},
+		{key: "_", want: "_"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			assert.Equal(t, tt.want, Humanize(tt.key))
+		})
+	}
+}
+
+func TestEscapeGlob(t *testing.T) {
+	tests := []string{
+		"assets/logo.png",
+		"{{ .ProjectSnake }}/logo.png",
+		"[models]/*.bin",
+		`a\b?.png`,
+	}
+
+	for _, p := range tests {
+		t.Run(p, func(t *testing.T) {
+			match, err := doublestar.Match(EscapeGlob(p), p)
+			require.NoError(t, err)
+			assert.True(t, match)
+		})
+	}
+
+	match, err := doublestar.Match(EscapeGlob("*.png"), "logo.png")
+	require.NoError(t, err)
+	assert.False(t, match)
+}
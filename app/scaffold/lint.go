@@ -0,0 +1,725 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template/parse"
+
+	"github.com/bmatcuk/doublestar/v4"
+	"github.com/hashicorp/go-version"
+	"github.com/hay-kot/scaffold/app/core/detect"
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/internal/jsonschema"
+)
+
+// Severity is the severity of a Diagnostic.
+type Severity string
+
+const (
+	// SeverityError fails the lint.
+	SeverityError Severity = "error"
+	// SeverityWarning is reported without failing the lint.
+	SeverityWarning Severity = "warning"
+)
+
+// Lint rule IDs, they are reported with every diagnostic and can be disabled
+// in the lint section of the scaffold file.
+const (
+	RuleInvalidName       = "invalid-name"
+	RuleInvalidQuestion   = "invalid-question"
+	RuleInvalidGlob       = "invalid-glob"
+	RuleInvalidConfig     = "invalid-config"
+	RuleUnknownRule       = "unknown-rule"
+	RuleParseError        = "parse-error"
+	RuleUndefinedQuestion = "undefined-question"
+	RuleUndefinedComputed = "undefined-computed"
+	RuleEachContext       = "each-context"
+	RuleUnknownFunction   = "unknown-function"
+	RuleMissingPartial    = "missing-partial"
+	RuleUndefinedTemplate = "undefined-template"
+	RuleUnusedQuestion    = "unused-question"
+	RuleMinimumVersion    = "minimum-version"
+)
+
+// LintRule describes a lint rule.
+type LintRule struct {
+	ID          string
+	Severity    Severity
+	Description string
+}
+
+// LintRules lists every rule reported by lint.
+var LintRules = []LintRule{
+	{ID: RuleInvalidName, Severity: SeverityError, Description: "Question and computed variable names must be valid template identifiers"},
+	{ID: RuleInvalidQuestion, Severity: SeverityError, Description: "Questions must have a known prompt type and valid fields"},
+	{ID: RuleInvalidGlob, Severity: SeverityError, Description: "Glob patterns must be valid"},
+	{ID: RuleInvalidConfig, Severity: SeverityError, Description: "Scaffold file settings must be valid"},
+	{ID: RuleUnknownRule, Severity: SeverityWarning, Description: "Disabled lint rules must exist"},
+	{ID: RuleParseError, Severity: SeverityError, Description: "Templates must parse"},
+	{ID: RuleUndefinedQuestion, Severity: SeverityError, Description: "Referenced questions must be declared"},
+	{ID: RuleUndefinedComputed, Severity: SeverityError, Description: "Referenced computed variables must be declared"},
+	{ID: RuleEachContext, Severity: SeverityError, Description: ".Each must only be used in files expanded by each"},
+	{ID: RuleUnknownFunction, Severity: SeverityError, Description: "Called functions must exist"},
+	{ID: RuleMissingPartial, Severity: SeverityError, Description: "Referenced partials must exist"},
+	{ID: RuleUndefinedTemplate, Severity: SeverityError, Description: "Referenced templates must be defined"},
+	{ID: RuleUnusedQuestion, Severity: SeverityWarning, Description: "Questions should be used by a template"},
+	{ID: RuleMinimumVersion, Severity: SeverityWarning, Description: "Scaffold file keys should be supported by metadata.minimum_version"},
+}
+
+// LookupLintRule returns the rule with the given ID.
+func LookupLintRule(id string) (LintRule, bool) {
+	for _, r := range LintRules {
+		if r.ID == id {
+			return r, true
+		}
+	}
+
+	return LintRule{}, false
+}
+
+// LintConfig configures `scaffold lint` for a scaffold.
+type LintConfig struct {
+	// Disable lists rule IDs that are not reported.
+	Disable []string `yaml:"disable" description:"Lint rules that are not reported"`
+}
+
+func (LintConfig) JSONSchemaExtend(s *jsonschema.Schema) {
+	disable, _ := s.Properties.Get("disable")
+	for _, r := range LintRules {
+		disable.Items.Enum = append(disable.Items.Enum, r.ID)
+	}
+}
+
+// Diagnostic is a problem found by lint. Line and Column are 1-based and
+// zero when the position is unknown.
+type Diagnostic struct {
+	File     string   `json:"file"`
+	Line     int      `json:"line,omitempty"`
+	Column   int      `json:"column,omitempty"`
+	Rule     string   `json:"rule"`
+	Severity Severity `json:"severity"`
+	Message  string   `json:"message"`
+}
+
+// NewDiagnostic returns a diagnostic for rule with the rule's severity.
+func NewDiagnostic(rule, file, message string) Diagnostic {
+	r, _ := LookupLintRule(rule)
+	return Diagnostic{File: file, Rule: rule, Severity: r.Severity, Message: message}
+}
+
+// At returns d positioned at p.
+func (d Diagnostic) At(p Position) Diagnostic {
+	d.Line, d.Column = p.Line, p.Column
+	return d
+}
+
+// FilterDiagnostics removes the diagnostics of the rules disabled by conf, and
+// reports disabled rules that don't exist at their position in pos.
+func FilterDiagnostics(diags []Diagnostic, conf LintConfig, file string, pos *ConfPositions) []Diagnostic {
+	disabled := map[string]bool{}
+	for _, id := range conf.Disable {
+		disabled[id] = true
+	}
+
+	out := make([]Diagnostic, 0, len(diags))
+	for _, d := range diags {
+		if !disabled[d.Rule] {
+			out = append(out, d)
+		}
+	}
+
+	for i, id := range conf.Disable {
+		if _, ok := LookupLintRule(id); !ok {
+			d := NewDiagnostic(RuleUnknownRule, file, fmt.Sprintf("lint.disable: unknown rule %q", id))
+			out = append(out, d.At(pos.Of("lint", "disable", i)))
+		}
+	}
+
+	return out
+}
+
+func (d Diagnostic) String() string {
+	switch {
+	case d.Line > 0 && d.Column > 0:
+		return fmt.Sprintf("%s:%d:%d: %s", d.File, d.Line, d.Column, d.Message)
+	case d.Line > 0:
+		return fmt.Sprintf("%s:%d: %s", d.File, d.Line, d.Message)
+	default:
+		return fmt.Sprintf("%s: %s", d.File, d.Message)
+	}
+}
+
+// partialsDir is the directory in a scaffold holding partials.
+const partialsDir = "partials"
+
+// builtinFuncs are the functions predefined by text/template.
+var builtinFuncs = map[string]bool{
+	"and": true, "call": true, "html": true, "index": true, "slice": true,
+	"js": true, "len": true, "not": true, "or": true, "print": true,
+	"printf": true, "println": true, "urlquery": true,
+	"eq": true, "ge": true, "gt": true, "le": true, "lt": true, "ne": true,
+}
+
+// eachFields are the fields of the .Each context.
+var eachFields = map[string]bool{"Item": true, "Index": true, "Parent": true}
+
+// parseErrorPattern matches the position text/template/parse prefixes errors
+// with.
+var parseErrorPattern = regexp.MustCompile(`^template: [^:]*:(\d+):(?:(\d+):)? ?`)
+
+// lintSource is a template checked by the linter.
+type lintSource struct {
+	// file is the path reported in diagnostics.
+	file string
+	// field prefixes messages for templates embedded in the scaffold file,
+	// their diagnostics are reported at pos.
+	field string
+	pos   Position
+	// lineOffset is added to line numbers, for text that was cut from the
+	// start of a file.
+	lineOffset int
+	text       string
+	left       string
+	right      string
+	// flat templates, like question when conditions, are rendered with the
+	// answers as the data so .name refers to a question.
+	flat bool
+	// each is set when .Each is available.
+	each bool
+	// partial templates are rendered with arbitrary data, references are
+	// only recorded as usage.
+	partial bool
+}
+
+type linter struct {
+	eng       *engine.Engine
+	questions map[string]bool
+	computed  map[string]bool
+	partials  map[string]bool
+	used      map[string]bool
+	// usesAll is set when .Scaffold is used as a whole, for example passed
+	// to a function, so every question counts as used.
+	usesAll bool
+	diags   []Diagnostic
+}
+
+// LintProject parses every template of the scaffold with its effective
+// delimiters and reports parse errors, references to undeclared questions,
+// computed and each variables, unknown functions, missing partials and
+// questions that are never used.
+func LintProject(eng *engine.Engine, p *Project) []Diagnostic {
+	l := &linter{
+		eng:       eng,
+		questions: map[string]bool{"Project": true},
+		computed:  map[string]bool{},
+		partials:  map[string]bool{},
+		used:      map[string]bool{},
+	}
+
+	conf := p.Conf
+	for _, q := range conf.Questions {
+		l.questions[q.Name] = true
+	}
+
+	for k := range conf.Computed {
+		l.computed[k] = true
+	}
+
+	for _, ec := range conf.Each {
+		l.used[ec.Var] = true
+	}
+
+	confFile := "scaffold.yaml"
+	if _, err := fs.Stat(p.RootFS, confFile); err != nil {
+		confFile = "scaffold.yml"
+	}
+
+	// Positions are best effort, the scaffold file was already decoded.
+	var pos *ConfPositions
+	if data, err := fs.ReadFile(p.RootFS, confFile); err == nil {
+		pos, _ = ReadConfPositions(data)
+	}
+
+	sources := l.confSources(confFile, conf, pos)
+
+	partialSources, err := l.partialSources(p.RootFS)
+	if err != nil {
+		l.report(NewDiagnostic(RuleInvalidConfig, partialsDir, err.Error()))
+	}
+	sources = append(sources, partialSources...)
+
+	fileSources, err := l.fileSources(p)
+	if err != nil {
+		l.report(NewDiagnostic(RuleInvalidConfig, p.NameTemplate, err.Error()))
+	}
+	sources = append(sources, fileSources...)
+
+	for _, src := range sources {
+		l.lint(src)
+	}
+
+	for _, d := range lintMinimumVersion(confFile, conf, pos, Capabilities) {
+		l.report(d)
+	}
+
+	if !l.usesAll {
+		for i, q := range conf.Questions {
+			if !l.used[q.Name] {
+				d := NewDiagnostic(RuleUnusedQuestion, confFile, fmt.Sprintf("question %q is never used", q.Name))
+				l.report(d.At(pos.Of("questions", i, "name")))
+			}
+		}
+	}
+
+	sort.SliceStable(l.diags, func(i, j int) bool {
+		a, b := l.diags[i], l.diags[j]
+		if a.File != b.File {
+			return a.File < b.File
+		}
+		if a.Line != b.Line {
+			return a.Line < b.Line
+		}
+		return a.Column < b.Column
+	})
+
+	return l.diags
+}
+
+// lintMinimumVersion reports the released capabilities of caps used by conf
+// that metadata.minimum_version doesn't support. Capabilities listed in
+// metadata.requires are skipped, releases that don't know them refuse to run
+// the scaffold. Diagnostics are reported at the first key of the capability.
+func lintMinimumVersion(file string, conf *ProjectScaffoldFile, pos *ConfPositions, caps []Capability) []Diagnostic {
+	var diags []Diagnostic
+
+	declared := conf.Metadata.MinimumVersion
+	minimum, err := version.NewVersion(declared)
+	if err != nil {
+		// Unset, "*" or invalid, the latter is reported as invalid config.
+		minimum = nil
+		declared = "not set"
+	}
+
+	for _, c := range caps {
+		if c.Since == "" || !c.used(conf) || slices.Contains(conf.Metadata.Requires, c.ID) {
+			continue
+		}
+
+		since, err := version.NewVersion(c.Since)
+		if err != nil || (minimum != nil && minimum.GreaterThanOrEqual(since)) {
+			continue
+		}
+
+		d := NewDiagnostic(RuleMinimumVersion, file, fmt.Sprintf(
+			"%s requires scaffold %s or higher (%s) but metadata.minimum_version is %s",
+			strings.Join(c.Keys, ", "), c.Since, c.ID, declared,
+		))
+		diags = append(diags, d.At(pos.Of(keyPath(c.Keys[0])...)))
+	}
+
+	return diags
+}
+
+// keyPath splits a capability key like questions[].prompt.fields into the
+// mapping keys leading to it, sequences stop the path.
+func keyPath(key string) []any {
+	var out []any
+	for _, k := range strings.Split(key, ".") {
+		name, seq := strings.CutSuffix(k, "[]")
+		out = append(out, name)
+		if seq {
+			break
+		}
+	}
+
+	return out
+}
+
+func (l *linter) report(d Diagnostic) {
+	l.diags = append(l.diags, d)
+}
+
+// condition wraps a when condition so it parses as a template. Conditions
+// containing delimiters are templates, everything else is a pipeline.
+func condition(cond string) string {
+	if strings.Contains(cond, "{{") {
+		return cond
+	}
+
+	return "{{ if " + cond + " }}{{ end }}"
+}
+
+// confSources returns the templates embedded in the scaffold file.
+func (l *linter) confSources(file string, conf *ProjectScaffoldFile, pos *ConfPositions) []lintSource {
+	var out []lintSource
+
+	add := func(field string, at Position, text string, flat bool) {
+		out = append(out, lintSource{file: file, field: field, pos: at, text: text, left: "{{", right: "}}", flat: flat})
+	}
+
+	for i, q := range conf.Questions {
+		if q.When != "" {
+			add("questions."+q.Name+".when", pos.Of("questions", i, "when"), q.When, true)
+		}
+	}
+
+	for k, v := range conf.Computed {
+		add("computed."+k, pos.Of("computed", k), v, false)
+	}
+
+	for i, f := range conf.Features {
+		add(fmt.Sprintf("features[%d].value", i), pos.Of("features", i, "value"), f.Value, false)
+	}
+
+	for i, inj := range conf.Inject {
+		add(fmt.Sprintf("inject[%d].path", i), pos.Of("inject", i, "path"), inj.Path, false)
+		add(fmt.Sprintf("inject[%d].template", i), pos.Of("inject", i, "template"), inj.Template, false)
+	}
+
+	for i, c := range conf.Checks {
+		if c.When != "" {
+			add(fmt.Sprintf("checks[%d].when", i), pos.Of("checks", i, "when"), condition(c.When), false)
+		}
+	}
+
+	add("messages.post", pos.Of("messages", "post"), conf.Messages.Post, false)
+
+	// Map iteration order is random, keep the diagnostics stable.
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].field < out[j].field
+	})
+
+	return out
+}
+
+// partialSources registers the partials of the scaffold and returns their
+// templates.
+func (l *linter) partialSources(fsys fs.FS) ([]lintSource, error) {
+	var out []lintSource
+
+	err := fs.WalkDir(fsys, partialsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == partialsDir {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		if d.IsDir() {
+			return nil
+		}
+
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+
+		name := strings.TrimPrefix(p, partialsDir+"/")
+		l.partials[strings.TrimSuffix(name, path.Ext(name))] = true
+
+		out = append(out, lintSource{file: p, text: string(data), left: "{{", right: "}}", partial: true})
+		return nil
+	})
+
+	return out, err
+}
+
+// fileSources returns the paths and contents of the template files, using
+// the delimiters, front matter and skip patterns that apply when rendering.
+func (l *linter) fileSources(p *Project) ([]lintSource, error) {
+	var out []lintSource
+
+	args := &RWFSArgs{ReadFS: p.RootFS, Project: p}
+
+	hooks, err := fs.ReadDir(p.RootFS, HooksDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, h := range hooks {
+		if h.IsDir() {
+			continue
+		}
+
+		file := path.Join(HooksDir, h.Name())
+		data, err := fs.ReadFile(p.RootFS, file)
+		if err != nil {
+			return nil, err
+		}
+
+		out = append(out, lintSource{file: file, text: string(data), left: "{{", right: "}}"})
+	}
+
+	err = fs.WalkDir(p.RootFS, p.NameTemplate, func(sourcePath string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		each := false
+		for _, tok := range detectEachPatterns(sourcePath) {
+			if _, ok := isEachVar(p.Conf.Each, tok.Var); ok {
+				each = true
+			}
+		}
+
+		// Parent directories are linted on their own, only the last segment
+		// is new.
+		out = append(out, lintSource{file: sourcePath, field: "path", text: path.Base(sourcePath), left: "{{", right: "}}", each: each})
+
+		if d.IsDir() || isSymlink(d) {
+			return nil
+		}
+
+		relativePath := strings.TrimPrefix(sourcePath, p.NameTemplate+"/")
+		for _, pattern := range p.Conf.Skip {
+			if match, _ := doublestar.PathMatch(pattern, relativePath); match {
+				return nil
+			}
+		}
+
+		data, err := fs.ReadFile(p.RootFS, sourcePath)
+		if err != nil {
+			return err
+		}
+
+		head := data
+		if len(head) > detect.SniffLen {
+			head = head[:detect.SniffLen]
+		}
+
+		if detect.IsBinary(head) {
+			return nil
+		}
+
+		left, right, err := fileDelims(args, sourcePath)
+		if err != nil {
+			l.report(NewDiagnostic(RuleInvalidConfig, sourcePath, err.Error()))
+			return nil
+		}
+
+		fm, _ := args.frontMatter(sourcePath)
+
+		src := lintSource{file: sourcePath, left: left, right: right, each: each}
+		switch {
+		case fm != nil:
+			if fm.Path != "" {
+				out = append(out, lintSource{file: sourcePath, field: "front matter path", text: fm.Path, left: "{{", right: "}}", each: each})
+			}
+
+			if fm.When != "" {
+				out = append(out, lintSource{file: sourcePath, field: "front matter when", text: condition(fm.When), left: "{{", right: "}}", each: each})
+			}
+
+			if !fm.Renders() {
+				return nil
+			}
+
+			src.text = string(data[fm.size:])
+			src.lineOffset = fm.lines
+		default:
+			src.text = string(data)
+			if cond, n, ok := parseWhenDirective(data, left, right); ok {
+				out = append(out, lintSource{file: sourcePath, field: "when directive", text: condition(cond), left: "{{", right: "}}", each: each})
+				src.text = string(data[n:])
+				src.lineOffset = 1
+			}
+		}
+
+		out = append(out, src)
+		return nil
+	})
+
+	return out, err
+}
+
+// lint parses src and walks its templates.
+func (l *linter) lint(src lintSource) {
+	if !strings.Contains(src.text, src.left) {
+		return
+	}
+
+	report := func(pos parse.Pos, rule, msg string) {
+		line, col := src.pos.Line, src.pos.Column
+		if pos >= 0 && src.field == "" {
+			line = 1 + strings.Count(src.text[:pos], "\n") + src.lineOffset
+			col = int(pos) - strings.LastIndex(src.text[:pos], "\n")
+		}
+
+		if src.field != "" {
+			msg = src.field + ": " + msg
+		}
+
+		d := NewDiagnostic(rule, src.file, msg)
+		d.Line, d.Column = line, col
+		l.report(d)
+	}
+
+	trees := map[string]*parse.Tree{}
+	t := parse.New(src.file)
+	t.Mode = parse.SkipFuncCheck
+	_, err := t.Parse(src.text, src.left, src.right, trees)
+	if err != nil {
+		msg := err.Error()
+		line := 0
+		if m := parseErrorPattern.FindStringSubmatch(msg); m != nil {
+			line, _ = strconv.Atoi(m[1])
+			msg = msg[len(m[0]):]
+		}
+
+		if line > 0 && src.field == "" {
+			d := NewDiagnostic(RuleParseError, src.file, msg)
+			d.Line = line + src.lineOffset
+			l.report(d)
+		} else {
+			report(-1, RuleParseError, msg)
+		}
+		return
+	}
+
+	names := make([]string, 0, len(trees))
+	for name := range trees {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		w := &treeWalker{l: l, src: src, trees: trees, report: report}
+		w.walk(trees[name].Root, false)
+	}
+}
+
+// treeWalker walks a template parse tree. nested is set inside range and
+// with blocks, where dot no longer refers to the template data.
+type treeWalker struct {
+	l      *linter
+	src    lintSource
+	trees  map[string]*parse.Tree
+	report func(pos parse.Pos, rule, msg string)
+}
+
+func (w *treeWalker) walk(node parse.Node, nested bool) {
+	switch n := node.(type) {
+	case nil:
+	case *parse.ListNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Nodes {
+			w.walk(c, nested)
+		}
+	case *parse.ActionNode:
+		w.walk(n.Pipe, nested)
+	case *parse.IfNode:
+		w.walk(n.Pipe, nested)
+		w.walk(n.List, nested)
+		w.walk(n.ElseList, nested)
+	case *parse.RangeNode:
+		w.walk(n.Pipe, nested)
+		w.walk(n.List, true)
+		w.walk(n.ElseList, nested)
+	case *parse.WithNode:
+		w.walk(n.Pipe, nested)
+		w.walk(n.List, true)
+		w.walk(n.ElseList, nested)
+	case *parse.TemplateNode:
+		if _, ok := w.trees[n.Name]; !ok {
+			w.report(n.Position(), RuleUndefinedTemplate, fmt.Sprintf("template %q is not defined", n.Name))
+		}
+		w.walk(n.Pipe, nested)
+	case *parse.PipeNode:
+		if n == nil {
+			return
+		}
+		for _, c := range n.Cmds {
+			w.command(c, nested)
+		}
+	case *parse.ChainNode:
+		w.walk(n.Node, nested)
+	case *parse.FieldNode:
+		if !nested {
+			w.reference(n.Position(), n.Ident)
+		}
+	case *parse.VariableNode:
+		if len(n.Ident) > 1 && n.Ident[0] == "$" {
+			w.reference(n.Position(), n.Ident[1:])
+		}
+	case *parse.IdentifierNode:
+		if !builtinFuncs[n.Ident] && !w.l.eng.HasFunc(n.Ident) {
+			w.report(n.Position(), RuleUnknownFunction, fmt.Sprintf("unknown function %q", n.Ident))
+		}
+	}
+}
+
+func (w *treeWalker) command(cmd *parse.CommandNode, nested bool) {
+	if len(cmd.Args) >= 2 {
+		if fn, ok := cmd.Args[0].(*parse.IdentifierNode); ok {
+			switch fn.Ident {
+			case "partial":
+				if name, ok := cmd.Args[1].(*parse.StringNode); ok && !w.l.partials[name.Text] {
+					w.report(name.Position(), RuleMissingPartial, fmt.Sprintf("partial %q not found", name.Text))
+				}
+			case "index":
+				// index .Scaffold "name" is a reference to the question.
+				field, isField := cmd.Args[1].(*parse.FieldNode)
+				key, isString := cmd.Args[len(cmd.Args)-1].(*parse.StringNode)
+				if isField && isString && len(cmd.Args) == 3 && !nested && len(field.Ident) == 1 && field.Ident[0] == "Scaffold" {
+					w.walk(fn, nested)
+					w.reference(field.Position(), []string{"Scaffold", key.Text})
+					return
+				}
+			}
+		}
+	}
+
+	for _, arg := range cmd.Args {
+		w.walk(arg, nested)
+	}
+}
+
+// reference checks a field chain on the template data.
+func (w *treeWalker) reference(pos parse.Pos, ident []string) {
+	if w.src.flat {
+		w.l.used[ident[0]] = true
+		if !w.l.questions[ident[0]] && !w.src.partial {
+			w.report(pos, RuleUndefinedQuestion, fmt.Sprintf("undefined question %q", ident[0]))
+		}
+		return
+	}
+
+	switch ident[0] {
+	case "Scaffold":
+		if len(ident) == 1 {
+			w.l.usesAll = true
+			return
+		}
+
+		w.l.used[ident[1]] = true
+		if !w.l.questions[ident[1]] && !w.src.partial {
+			w.report(pos, RuleUndefinedQuestion, fmt.Sprintf("undefined question %q (.Scaffold.%s)", ident[1], ident[1]))
+		}
+	case "Computed":
+		if len(ident) > 1 && !w.l.computed[ident[1]] && !w.src.partial {
+			w.report(pos, RuleUndefinedComputed, fmt.Sprintf("undefined computed variable %q (.Computed.%s)", ident[1], ident[1]))
+		}
+	case "Each":
+		if w.src.partial {
+			return
+		}
+
+		if !w.src.each {
+			w.report(pos, RuleEachContext, ".Each is only available in files expanded by each")
+			return
+		}
+
+		if len(ident) > 1 && !eachFields[ident[1]] {
+			w.report(pos, RuleEachContext, fmt.Sprintf("unknown .Each field %q (must be one of Item, Index, Parent)", ident[1]))
+		}
+	}
+}
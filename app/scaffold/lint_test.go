@@ -0,0 +1,114 @@
+package scaffold
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLintProject(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scaffold.yaml": {Data: []byte(`metadata:
+  minimum_version: "0.13.1"
+questions:
+  - name: docker
+    prompt:
+      confirm: Docker?
+  - name: unused
+    prompt:
+      message: Unused
+  - name: services
+    prompt:
+      message: Services
+      loop: true
+  - name: extra
+    when: "{{ .docker }} {{ .missing }}"
+    prompt:
+      message: Extra
+computed:
+  slug: "{{ .Project | kebabcase }}"
+features:
+  - value: "{{ .Scaffold.docker "
+each:
+  - services
+skip:
+  - "*.skip"
+delimiters:
+  - glob: "*.tpl"
+    left: "[["
+    right: "]]"
+`)},
+		"partials/header.tmpl": {Data: []byte("{{ .Anything }}\n")},
+		"{{ .Project }}/main.go": {Data: []byte(`---scaffold
+when: .Scaffold.nope
+---
+{{ partial "header" . }}{{ partial "footer" . }}
+{{ .Scaffold.extra }} {{ .Computed.slug }} {{ .Computed.missing }}
+{{ range .Scaffold.services }}{{ .Name }}{{ $.Scaffold.docker }}{{ end }}
+{{ nosuchfunc .Project }} {{ .Each.Item }}
+{{ template "x" }}
+`)},
+		"{{ .Project }}/[services]/svc.go":    {Data: []byte("{{ .Each.Item }} {{ .Each.Foo }}\n")},
+		"{{ .Project }}/{{ .Scaffold.x }}.md": {Data: []byte("text\n")},
+		"{{ .Project }}/ignored.skip":         {Data: []byte("{{ broken\n")},
+		"{{ .Project }}/custom.tpl":           {Data: []byte("{{ not a template }} [[ .Scaffold.y ]]\n")},
+	}
+
+	p, err := LoadProject(fsys, Options{})
+	require.NoError(t, err)
+
+	got := []string{}
+	for _, d := range LintProject(engine.New(), p) {
+		got = append(got, d.String())
+		assert.NotEmpty(t, d.Rule, d.String())
+	}
+
+	assert.Equal(t, []string{
+		`scaffold.yaml:7:5: question "unused" is never used`,
+		`scaffold.yaml:15:5: questions.extra.when: undefined question "missing"`,
+		`scaffold.yaml:21:5: features[0].value: unclosed action`,
+		`{{ .Project }}/[services]/svc.go:1:26: unknown .Each field "Foo" (must be one of Item, Index, Parent)`,
+		`{{ .Project }}/custom.tpl:1:34: undefined question "y" (.Scaffold.y)`,
+		`{{ .Project }}/main.go: front matter when: undefined question "nope" (.Scaffold.nope)`,
+		`{{ .Project }}/main.go:4:36: partial "footer" not found`,
+		`{{ .Project }}/main.go:5:56: undefined computed variable "missing" (.Computed.missing)`,
+		`{{ .Project }}/main.go:7:4: unknown function "nosuchfunc"`,
+		`{{ .Project }}/main.go:7:35: .Each is only available in files expanded by each`,
+		`{{ .Project }}/main.go:8:13: template "x" is not defined`,
+		`{{ .Project }}/{{ .Scaffold.x }}.md: path: undefined question "x" (.Scaffold.x)`,
+	}, got)
+}
+
+func TestLintProject_ParseErrorLine(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scaffold.yaml":         {Data: []byte("questions: []\n")},
+		"templates/bad.txt":     {Data: []byte("{{/* scaffold:when true */}}\nline\n{{ if }}\n")},
+		"templates/scaffold.go": {Data: []byte("{{ .Scaffold | toJson }}\n")},
+	}
+
+	p, err := LoadProject(fsys, Options{})
+	require.NoError(t, err)
+
+	diags := LintProject(engine.New(), p)
+	require.Len(t, diags, 1)
+	assert.Equal(t, "templates/bad.txt:3: missing value for if", diags[0].String())
+}
+
+func TestFilterDiagnostics(t *testing.T) {
+	diags := []Diagnostic{
+		NewDiagnostic(RuleUnusedQuestion, "scaffold.yaml", "question \"a\" is never used"),
+		NewDiagnostic(RuleParseError, "templates/a.txt", "unclosed action"),
+	}
+
+	assert.Equal(t, SeverityWarning, diags[0].Severity)
+	assert.Equal(t, SeverityError, diags[1].Severity)
+
+	got := FilterDiagnostics(diags, LintConfig{Disable: []string{RuleUnusedQuestion, "nope"}}, "scaffold.yaml", nil)
+	assert.Equal(t, []Diagnostic{
+		diags[1],
+		{File: "scaffold.yaml", Rule: RuleUnknownRule, Severity: SeverityWarning, Message: `lint.disable: unknown rule "nope"`},
+	}, got)
+}
@@ -0,0 +1,259 @@
+package scaffold
+
+import (
+	"fmt"
+	"math/rand"
+	"sort"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+)
+
+// DefaultMatrixMax is the number of combinations rendered by matrix testing
+// when no maximum is set.
+const DefaultMatrixMax = 256
+
+// MatrixDimension is a question whose answers are varied by matrix testing.
+type MatrixDimension struct {
+	Name string
+	// Values are the possible answers, the first is the default.
+	Values []any
+}
+
+// Matrix holds the answer combinations for a scaffold.
+type Matrix struct {
+	Dimensions []MatrixDimension
+	// Base holds the defaults of the questions that aren't varied, questions
+	// without a default are answered with the zero value of their type.
+	Base map[string]any
+
+	eng    *engine.Engine
+	groups [][]Question
+}
+
+// MatrixOptions controls how combinations are selected.
+type MatrixOptions struct {
+	// Max is the maximum number of combinations, when the matrix has more
+	// combinations a random sample is returned.
+	Max int
+	// Seed seeds the sampling so a run can be reproduced.
+	Seed int64
+}
+
+// NewMatrix builds the matrix of the scaffold's confirm and select questions.
+// Every other question is answered with its default, or the zero value of
+// its type when it has none.
+func NewMatrix(eng *engine.Engine, conf *ProjectScaffoldFile) Matrix {
+	m := Matrix{
+		Base:   map[string]any{"Project": DefaultTestProject},
+		eng:    eng,
+		groups: QuestionGroupBy(conf.Questions),
+	}
+
+	for _, q := range conf.Questions {
+		switch {
+		case q.Prompt.IsConfirm():
+			def := parseDefaultBool(q.Prompt.Default)
+			m.Dimensions = append(m.Dimensions, MatrixDimension{Name: q.Name, Values: []any{def, !def}})
+		case q.Prompt.IsSelect() && !q.Prompt.IsMultiSelect() && len(*q.Prompt.Options) > 0:
+			def := parseDefaultString(q.Prompt.Default)
+			values := []any{}
+			if def != "" {
+				values = append(values, def)
+			}
+			for _, opt := range *q.Prompt.Options {
+				if opt != def {
+					values = append(values, opt)
+				}
+			}
+			m.Dimensions = append(m.Dimensions, MatrixDimension{Name: q.Name, Values: values})
+		case q.Prompt.Default != nil:
+			m.Base[q.Name] = q.Prompt.Default
+		default:
+			m.Base[q.Name] = zeroAnswer(q)
+		}
+	}
+
+	return m
+}
+
+// zeroAnswer returns the zero value of the answer type of q.
+func zeroAnswer(q Question) any {
+	switch {
+	case q.Prompt.IsConfirm():
+		return false
+	case q.Prompt.IsObjectLoop():
+		return []map[string]any{}
+	case q.Prompt.IsMultiSelect(), q.Prompt.IsInputLoop():
+		return []string{}
+	default:
+		return ""
+	}
+}
+
+// Total returns the number of combinations in the matrix, saturating at
+// limit to avoid overflowing on large matrices.
+func (m Matrix) Total(limit int) int {
+	total := 1
+	for _, d := range m.Dimensions {
+		total *= len(d.Values)
+		if total > limit {
+			return limit + 1
+		}
+	}
+
+	return total
+}
+
+// Combinations returns the answer combinations to render. Every combination
+// is returned when there are at most opts.Max, otherwise the defaults and a
+// random sample of distinct combinations is returned.
+func (m Matrix) Combinations(opts MatrixOptions) [][]int {
+	limit := opts.Max
+	if limit <= 0 {
+		limit = DefaultMatrixMax
+	}
+
+	total := m.Total(limit)
+	if total <= limit {
+		out := make([][]int, 0, total)
+		for i := 0; i < total; i++ {
+			combo := make([]int, len(m.Dimensions))
+			n := i
+			for d := len(m.Dimensions) - 1; d >= 0; d-- {
+				size := len(m.Dimensions[d].Values)
+				combo[d] = n % size
+				n /= size
+			}
+			out = append(out, combo)
+		}
+		return out
+	}
+
+	rng := rand.New(rand.NewSource(opts.Seed)) // nolint: gosec
+	seen := map[string]bool{}
+	out := make([][]int, 0, limit)
+
+	add := func(combo []int) {
+		key := fmt.Sprint(combo)
+		if !seen[key] {
+			seen[key] = true
+			out = append(out, combo)
+		}
+	}
+
+	add(make([]int, len(m.Dimensions)))
+
+	// The matrix has more than limit combinations, so duplicates are rare and
+	// the attempts are only bounded to guarantee termination.
+	for attempts := 0; len(out) < limit && attempts < limit*10; attempts++ {
+		combo := make([]int, len(m.Dimensions))
+		for d, dim := range m.Dimensions {
+			combo[d] = rng.Intn(len(dim.Values))
+		}
+		add(combo)
+	}
+
+	return out
+}
+
+// Vars returns the question values for a combination. Questions that would
+// not be asked, because the when condition of their group is false, are left
+// out like they are when prompting.
+func (m Matrix) Vars(combo []int) map[string]any {
+	vars := MergeMaps(m.Base)
+	for d, dim := range m.Dimensions {
+		vars[dim.Name] = dim.Values[combo[d]]
+	}
+
+	for _, group := range m.groups {
+		if !isHiddenGroup(m.eng, group, vars) {
+			continue
+		}
+
+		for _, q := range group {
+			delete(vars, q.Name)
+		}
+	}
+
+	return vars
+}
+
+// Describe returns the answers of a combination that differ from the
+// defaults, for example "docker=true, db=postgres".
+func (m Matrix) Describe(combo []int) string {
+	parts := []string{}
+	for d, dim := range m.Dimensions {
+		if combo[d] != 0 {
+			parts = append(parts, fmt.Sprintf("%s=%v", dim.Name, dim.Values[combo[d]]))
+		}
+	}
+
+	if len(parts) == 0 {
+		return "defaults"
+	}
+
+	return strings.Join(parts, ", ")
+}
+
+// Minimize reduces a failing combination to the smallest set of non-default
+// answers that still fails. Each answer is reset to its default in turn and
+// the reset is kept when fails still reports true.
+func (m Matrix) Minimize(combo []int, fails func(combo []int) bool) []int {
+	out := append([]int(nil), combo...)
+
+	for d := range out {
+		if out[d] == 0 {
+			continue
+		}
+
+		prev := out[d]
+		out[d] = 0
+		if !fails(out) {
+			out[d] = prev
+		}
+	}
+
+	return out
+}
+
+// MatrixFailure groups the combinations that failed with the same error.
+type MatrixFailure struct {
+	Err string
+	// Minimal is the smallest reproducing set of answers.
+	Minimal []int
+	// Count is the number of combinations that failed with Err.
+	Count int
+}
+
+// GroupMatrixFailures groups failing combinations by their error and sorts
+// the groups by how often they occurred.
+func GroupMatrixFailures(combos [][]int, errs []string) []MatrixFailure {
+	groups := map[string]*MatrixFailure{}
+	order := []string{}
+
+	for i, err := range errs {
+		if err == "" {
+			continue
+		}
+
+		g, ok := groups[err]
+		if !ok {
+			g = &MatrixFailure{Err: err, Minimal: combos[i]}
+			groups[err] = g
+			order = append(order, err)
+		}
+		g.Count++
+	}
+
+	out := make([]MatrixFailure, 0, len(order))
+	for _, err := range order {
+		out = append(out, *groups[err])
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		return out[i].Count > out[j].Count
+	})
+
+	return out
+}
@@ -0,0 +1,114 @@
+package scaffold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func matrixConf() *ProjectScaffoldFile {
+	confirm := "Docker?"
+	message := "Database"
+	options := []string{"sqlite", "postgres", "mysql"}
+
+	return &ProjectScaffoldFile{
+		Questions: []Question{
+			{Name: "docker", Prompt: AnyPrompt{Confirm: &confirm}},
+			{Name: "db", Prompt: AnyPrompt{Message: &message, Options: &options, Default: "postgres"}},
+			{Name: "description", Prompt: AnyPrompt{Message: &message, Default: "an app"}},
+		},
+	}
+}
+
+func TestNewMatrix(t *testing.T) {
+	m := NewMatrix(tEngine, matrixConf())
+
+	assert.Equal(t, []MatrixDimension{
+		{Name: "docker", Values: []any{false, true}},
+		{Name: "db", Values: []any{"postgres", "sqlite", "mysql"}},
+	}, m.Dimensions)
+	assert.Equal(t, map[string]any{"Project": DefaultTestProject, "description": "an app"}, m.Base)
+	assert.Equal(t, 6, m.Total(100))
+	assert.Equal(t, 5, m.Total(4), "saturates above the limit")
+}
+
+func TestMatrix_Vars(t *testing.T) {
+	confirm := "Docker?"
+	message := "Name"
+	options := []string{"a", "b"}
+
+	conf := &ProjectScaffoldFile{
+		Questions: []Question{
+			{Name: "docker", Prompt: AnyPrompt{Confirm: &confirm}},
+			{Name: "image", When: "{{ .docker }}", Group: "docker", Prompt: AnyPrompt{Message: &message}},
+			{Name: "registry", Group: "docker", Prompt: AnyPrompt{Message: &message, Default: "ghcr.io"}},
+			{Name: "tags", Prompt: AnyPrompt{Message: &message, Options: &options, Multi: true}},
+			{Name: "names", Prompt: AnyPrompt{Message: &message, Loop: true}},
+			{Name: "author", Prompt: AnyPrompt{Message: &message}},
+		},
+	}
+
+	m := NewMatrix(tEngine, conf)
+
+	assert.Equal(t, map[string]any{
+		"Project": DefaultTestProject,
+		"docker":  false,
+		"tags":    []string{},
+		"names":   []string{},
+		"author":  "",
+	}, m.Vars([]int{0}), "questions without a default are zero, hidden groups are left out")
+
+	assert.Equal(t, map[string]any{
+		"Project":  DefaultTestProject,
+		"docker":   true,
+		"image":    "",
+		"registry": "ghcr.io",
+		"tags":     []string{},
+		"names":    []string{},
+		"author":   "",
+	}, m.Vars([]int{1}))
+}
+
+func TestMatrix_Combinations(t *testing.T) {
+	m := NewMatrix(tEngine, matrixConf())
+
+	all := m.Combinations(MatrixOptions{})
+	require.Len(t, all, 6)
+	assert.Equal(t, []int{0, 0}, all[0])
+	assert.Equal(t, []int{1, 2}, all[5])
+
+	sampled := m.Combinations(MatrixOptions{Max: 4, Seed: 1})
+	require.Len(t, sampled, 4)
+	assert.Equal(t, []int{0, 0}, sampled[0], "defaults are always included")
+	assert.Equal(t, sampled, m.Combinations(MatrixOptions{Max: 4, Seed: 1}), "sampling is reproducible")
+
+	assert.Equal(t, map[string]any{
+		"Project":     DefaultTestProject,
+		"description": "an app",
+		"docker":      true,
+		"db":          "mysql",
+	}, m.Vars([]int{1, 2}))
+}
+
+func TestMatrix_Minimize(t *testing.T) {
+	m := NewMatrix(tEngine, matrixConf())
+
+	// Fails whenever mysql is selected, regardless of docker.
+	fails := func(combo []int) bool { return combo[1] == 2 }
+
+	minimal := m.Minimize([]int{1, 2}, fails)
+	assert.Equal(t, []int{0, 2}, minimal)
+	assert.Equal(t, "db=mysql", m.Describe(minimal))
+	assert.Equal(t, "defaults", m.Describe([]int{0, 0}))
+}
+
+func TestGroupMatrixFailures(t *testing.T) {
+	combos := [][]int{{0, 0}, {0, 2}, {1, 2}, {1, 1}}
+	errs := []string{"", "boom", "boom", "bad"}
+
+	assert.Equal(t, []MatrixFailure{
+		{Err: "boom", Minimal: []int{0, 2}, Count: 2},
+		{Err: "bad", Minimal: []int{1, 1}, Count: 1},
+	}, GroupMatrixFailures(combos, errs))
+}
@@ -2,4 +2,12 @@ package scaffold
 
 type Options struct {
 	NoClobber bool `yaml:"no_clobber"`
+
+	// OnConflict is the action for rendered files that already exist in the
+	// output. When empty, existing files are handled by NoClobber.
+	OnConflict ConflictAction `yaml:"on_conflict"`
+
+	// ResolveConflict is called for every conflict when the action is
+	// ConflictPrompt. When nil existing files are kept.
+	ResolveConflict ConflictResolver `yaml:"-"`
 }
@@ -0,0 +1,177 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+)
+
+// Plan describes what a scaffold produces for a set of answers.
+type Plan struct {
+	// Files are the output paths of the rendered files after rewrites,
+	// features and each expansion, sorted.
+	Files []string
+	// Dropped are the output paths of files that render empty and aren't
+	// written.
+	Dropped []string
+	// Injections are the scaffold's injections with their paths rendered.
+	Injections []PlannedInjection
+	// Hooks are the files in the scaffold's hooks directory.
+	Hooks []string
+	// Partials are the names of the scaffold's partials.
+	Partials []string
+}
+
+// PlannedInjection is an injection into an existing file of the target
+// project.
+type PlannedInjection struct {
+	Name string
+	Path string
+	At   string
+	Mode Mode
+	// Skipped is true when the template renders empty, the file isn't
+	// changed.
+	Skipped bool
+}
+
+// DefaultAnswers returns the default answers of the scaffold's questions,
+// with the project named DefaultTestProject. Questions without a default are
+// answered with the zero value of their type.
+func DefaultAnswers(eng *engine.Engine, conf *ProjectScaffoldFile) map[string]any {
+	m := NewMatrix(eng, conf)
+	return m.Vars(make([]int, len(m.Dimensions)))
+}
+
+// BuildPlan renders the project into memory with vars, the answers to its
+// questions and optionally the project name, and returns the files it
+// produces. Hooks aren't run and injections are rendered but not applied,
+// their targets are in the project the scaffold is run in.
+func BuildPlan(eng *engine.Engine, p *Project, vars engine.Vars) (*Plan, error) {
+	conf := *p.Conf
+	conf.Inject = nil
+
+	rp := *p
+	rp.Conf = &conf
+	if name, ok := vars["Project"].(string); ok && name != "" {
+		rp.Name = name
+	}
+
+	built, err := BuildVars(eng, &rp, vars)
+	if err != nil {
+		return nil, err
+	}
+
+	outfs := rwfs.NewMemoryWFS()
+	report := &Report{}
+
+	err = RenderRWFS(eng, &RWFSArgs{
+		Project: &rp,
+		ReadFS:  p.RootFS,
+		WriteFS: outfs,
+		Report:  report,
+	}, built)
+	if err != nil {
+		return nil, err
+	}
+
+	plan := &Plan{
+		Files:      []string{},
+		Dropped:    append([]string{}, report.DroppedEmpty...),
+		Injections: []PlannedInjection{},
+	}
+
+	err = fs.WalkDir(outfs, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+
+		if !d.IsDir() {
+			plan.Files = append(plan.Files, p)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	sort.Strings(plan.Files)
+	sort.Strings(plan.Dropped)
+
+	for _, inj := range p.Conf.Inject {
+		target, err := eng.TmplString(inj.Path, built)
+		if err != nil {
+			return nil, fmt.Errorf("inject %s: %w", inj.Name, err)
+		}
+
+		out, err := eng.TmplString(inj.Template, built)
+		if err != nil {
+			return nil, fmt.Errorf("inject %s: %w", inj.Name, err)
+		}
+
+		plan.Injections = append(plan.Injections, PlannedInjection{
+			Name:    inj.Name,
+			Path:    target,
+			At:      inj.At,
+			Mode:    inj.Mode,
+			Skipped: strings.TrimSpace(out) == "",
+		})
+	}
+
+	plan.Hooks, err = hookFiles(p.RootFS)
+	if err != nil {
+		return nil, err
+	}
+
+	plan.Partials, err = partialNames(p.RootFS)
+	if err != nil {
+		return nil, err
+	}
+
+	return plan, nil
+}
+
+// hookFiles returns the names of the files in the hooks directory.
+func hookFiles(fsys fs.FS) ([]string, error) {
+	entries, err := fs.ReadDir(fsys, HooksDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	hooks := []string{}
+	for _, e := range entries {
+		if !e.IsDir() {
+			hooks = append(hooks, e.Name())
+		}
+	}
+
+	return hooks, nil
+}
+
+// partialNames returns the names partials are called by, sorted.
+func partialNames(fsys fs.FS) ([]string, error) {
+	names := []string{}
+
+	err := fs.WalkDir(fsys, partialsDir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if errors.Is(err, fs.ErrNotExist) && p == partialsDir {
+				return fs.SkipAll
+			}
+			return err
+		}
+
+		if !d.IsDir() {
+			name := strings.TrimPrefix(p, partialsDir+"/")
+			names = append(names, strings.TrimSuffix(name, path.Ext(name)))
+		}
+		return nil
+	})
+
+	sort.Strings(names)
+	return names, err
+}
@@ -0,0 +1,89 @@
+package scaffold
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuildPlan(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scaffold.yaml": {Data: []byte(`questions:
+  - name: docker
+    prompt:
+      confirm: Docker?
+  - name: services
+    prompt:
+      message: Services
+      loop: true
+  - name: license
+    prompt:
+      message: License
+features:
+  - value: "{{ .Scaffold.docker }}"
+    globs:
+      - "**/Dockerfile"
+each:
+  - services
+inject:
+  - name: routes
+    path: "{{ .Project }}/routes.go"
+    at: "// routes"
+    template: "{{ range .Scaffold.services }}{{ . }}{{ end }}"
+presets:
+  full:
+    docker: true
+    services: [auth, users]
+`)},
+		"partials/header.tmpl":             {Data: []byte("// header\n")},
+		"partials/footer.tmpl":             {Data: []byte("// footer\n")},
+		"hooks/post_scaffold":              {Data: []byte("echo done\n")},
+		"{{ .Project }}/main.go":           {Data: []byte("package main\n")},
+		"{{ .Project }}/Dockerfile":        {Data: []byte("FROM scratch\n")},
+		"{{ .Project }}/LICENSE":           {Data: []byte("{{ .Scaffold.license }}")},
+		"{{ .Project }}/[services]/svc.go": {Data: []byte("package {{ .Each.Item }}\n")},
+	}
+
+	p, err := LoadProject(fsys, Options{})
+	require.NoError(t, err)
+
+	t.Run("defaults", func(t *testing.T) {
+		vars := DefaultAnswers(tEngine, p.Conf)
+		assert.Equal(t, map[string]any{
+			"Project":  DefaultTestProject,
+			"docker":   false,
+			"services": []string{},
+			"license":  "",
+		}, vars)
+
+		plan, err := BuildPlan(engine.New(), p, vars)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{"scaffold-test/main.go"}, plan.Files)
+		assert.Equal(t, []string{"scaffold-test/LICENSE"}, plan.Dropped)
+		assert.Equal(t, []PlannedInjection{
+			{Name: "routes", Path: "scaffold-test/routes.go", At: "// routes", Skipped: true},
+		}, plan.Injections)
+		assert.Equal(t, []string{"post_scaffold"}, plan.Hooks)
+		assert.Equal(t, []string{"footer", "header"}, plan.Partials)
+	})
+
+	t.Run("preset", func(t *testing.T) {
+		vars := MergeMaps(DefaultAnswers(tEngine, p.Conf), p.Conf.Presets["full"], map[string]any{"Project": "app"})
+
+		plan, err := BuildPlan(engine.New(), p, vars)
+		require.NoError(t, err)
+
+		assert.Equal(t, []string{
+			"app/Dockerfile",
+			"app/auth/svc.go",
+			"app/main.go",
+			"app/users/svc.go",
+		}, plan.Files)
+		assert.Equal(t, "app/routes.go", plan.Injections[0].Path)
+		assert.False(t, plan.Injections[0].Skipped)
+	})
+}
@@ -0,0 +1,91 @@
+package scaffold
+
+import (
+	"gopkg.in/yaml.v3"
+)
+
+// Position is a 1-based line and column in a file, zero when unknown.
+type Position struct {
+	Line   int
+	Column int
+}
+
+// ConfPositions looks up the positions of values in a scaffold file. A nil
+// ConfPositions returns zero positions.
+type ConfPositions struct {
+	root *yaml.Node
+}
+
+// ReadConfPositions parses the scaffold file in data for position lookups.
+func ReadConfPositions(data []byte) (*ConfPositions, error) {
+	var doc yaml.Node
+
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return &ConfPositions{}, nil
+	}
+
+	return &ConfPositions{root: doc.Content[0]}, nil
+}
+
+// Of returns the position of the value at path, made of mapping keys
+// (strings) and sequence indexes (ints). A trailing mapping key resolves to
+// the key so the position points at the setting. When path doesn't exist the
+// position of the deepest node found is returned.
+func (c *ConfPositions) Of(path ...any) Position {
+	if c == nil || c.root == nil {
+		return Position{}
+	}
+
+	n := c.root
+	pos := Position{Line: n.Line, Column: n.Column}
+
+	for i, elem := range path {
+		n = resolveAlias(n)
+
+		var next, at *yaml.Node
+		switch elem := elem.(type) {
+		case string:
+			if n.Kind != yaml.MappingNode {
+				return pos
+			}
+
+			for j := 0; j+1 < len(n.Content); j += 2 {
+				if n.Content[j].Value == elem {
+					next = n.Content[j+1]
+					at = next
+					if i == len(path)-1 {
+						at = n.Content[j]
+					}
+					break
+				}
+			}
+		case int:
+			if n.Kind == yaml.SequenceNode && elem >= 0 && elem < len(n.Content) {
+				next = n.Content[elem]
+				at = next
+			}
+		}
+
+		if next == nil {
+			return pos
+		}
+
+		n = next
+		pos = Position{Line: at.Line, Column: at.Column}
+	}
+
+	return pos
+}
+
+func resolveAlias(n *yaml.Node) *yaml.Node {
+	for n.Kind == yaml.AliasNode && n.Alias != nil {
+		n = n.Alias
+	}
+
+	return n
+}
@@ -0,0 +1,48 @@
+package scaffold
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestConfPositions_Of(t *testing.T) {
+	pos, err := ReadConfPositions([]byte(`questions:
+  - name: a
+    prompt:
+      message: A
+skip:
+  - "*.go"
+  - "*.md"
+base: &base
+  glob: "*.txt"
+format:
+  - *base
+`))
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		path []any
+		want Position
+	}{
+		{name: "root", want: Position{Line: 1, Column: 1}},
+		{name: "key", path: []any{"skip"}, want: Position{Line: 5, Column: 1}},
+		{name: "sequence item", path: []any{"skip", 1}, want: Position{Line: 7, Column: 5}},
+		{name: "nested key", path: []any{"questions", 0, "prompt", "message"}, want: Position{Line: 4, Column: 7}},
+		{name: "alias", path: []any{"format", 0, "glob"}, want: Position{Line: 9, Column: 3}},
+		{name: "missing key", path: []any{"questions", 0, "when"}, want: Position{Line: 2, Column: 5}},
+		{name: "missing index", path: []any{"skip", 5}, want: Position{Line: 6, Column: 3}},
+		{name: "index on mapping", path: []any{"questions", 0, 1}, want: Position{Line: 2, Column: 5}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, pos.Of(tt.path...))
+		})
+	}
+
+	var none *ConfPositions
+	assert.Equal(t, Position{}, none.Of("skip"))
+}
@@ -5,7 +5,6 @@ import (
 	"fmt"
 	"io/fs"
 	"maps"
-	"strconv"
 
 	"github.com/charmbracelet/huh"
 	"github.com/hay-kot/scaffold/app/core/engine"
@@ -87,6 +86,10 @@ func (p *Project) validate() (str string, err error) {
 	// Ensure there is a scaffold.yaml file
 	_, err = readFirst(p.RootFS, "scaffold.yaml", "scaffold.yml")
 	if err != nil {
+		if _, cerr := readFirst(p.RootFS, "cookiecutter.json"); cerr == nil {
+			return "", fmt.Errorf("scaffold.{yml,yaml} does not exist, this looks like a cookiecutter template: convert it with 'scaffold import cookiecutter'")
+		}
+
 		return "", fmt.Errorf("scaffold.{yml,yaml} does not exist")
 	}
 
@@ -153,58 +156,105 @@ func (p *Project) AskQuestions(def map[string]any, e *engine.Engine, theme *huh.
 	var form *huh.Form
 	formgroups := []*huh.Group{}
 
+	// runForm asks the pending groups. Object loops run their own forms so
+	// the questions before them are asked first.
+	runForm := func() error {
+		if len(formgroups) == 0 {
+			return nil
+		}
+
+		form = huh.NewForm(formgroups...).WithTheme(theme)
+		formgroups = []*huh.Group{}
+
+		err := runHuhForm(form)
+		if err != nil {
+			return err
+		}
+
+		// Ensure properts are set on vars
+		return patchvars()
+	}
+
+	// isHidden evaluates the when condition of the first question in a group.
+	isHidden := func(first Question) bool {
+		if first.When == "" {
+			return false
+		}
+
+		// extract existing properties
+		_ = patchvars()
+
+		return isHiddenGroup(e, []Question{first}, vars)
+	}
+
 	for _, qgroup := range qgroups {
 		fields := []huh.Field{}
 
-		for _, q := range qgroup {
-			question := q.ToAskable(vars[q.Name])
-			fields = append(fields, question.Field)
-			askables = append(askables, question)
+		// flush adds the fields gathered so far to the pending groups, the
+		// when condition of the first question hides all of them.
+		flush := func() {
+			if len(fields) == 0 {
+				return
+			}
+
+			group := huh.NewGroup(fields...)
+			fields = []huh.Field{}
+
+			if qgroup[0].When != "" {
+				group.WithHideFunc(func() bool {
+					if form == nil {
+						return false
+					}
+
+					// we check the first question in the group to see if it has a when
+					// and if so, we evaluate it and skip the group if it's false
+					return isHidden(qgroup[0])
+				})
+			}
+
+			formgroups = append(formgroups, group)
 		}
 
-		group := huh.NewGroup(fields...)
+		for _, q := range qgroup {
+			if q.Prompt.IsObjectLoop() {
+				flush()
 
-		firstq := qgroup[0]
-		if firstq.When != "" {
-			group.WithHideFunc(func() bool {
-				if form == nil {
-					return false
+				err := runForm()
+				if err != nil {
+					return nil, err
 				}
 
-				// extract existing properties
-				_ = patchvars()
-
-				first := qgroup[0]
+				// Values provided up front, e.g. from the command line, are
+				// used as is.
+				if _, ok := vars[q.Name]; ok || isHiddenGroup(e, qgroup, vars) {
+					continue
+				}
 
-				// we check the first question in the group to see if it has a when
-				// and if so, we evaluate it and skip the group if it's false
-				if first.When != "" {
-					result, err := e.TmplString(first.When, vars)
-					if err != nil {
-						return true
-					}
+				question := q.objectLoopAskable(theme)
+				err = question.run()
+				if err != nil {
+					return nil, err
+				}
 
-					resultBool, _ := strconv.ParseBool(result)
-					if !resultBool {
-						return true
-					}
+				// Set the answer now, no form may run after the last loop.
+				err = question.Hook(vars)
+				if err != nil {
+					return nil, err
 				}
-				return false
-			})
-		}
 
-		formgroups = append(formgroups, group)
-	}
+				askables = append(askables, question)
+				continue
+			}
 
-	form = huh.NewForm(formgroups...).WithTheme(theme)
+			question := q.ToAskable(vars[q.Name])
+			fields = append(fields, question.Field)
+			askables = append(askables, question)
+		}
 
-	err := form.Run()
-	if err != nil {
-		return nil, err
+		flush()
 	}
 
-	// Ensure properts are set on vars
-	err = patchvars()
+	err := runForm()
 	if err != nil {
 		return nil, err
 	}
@@ -3,28 +3,63 @@ package scaffold
 import (
 	"io"
 
+	"github.com/hay-kot/scaffold/app/core/formatters"
+	"github.com/hay-kot/scaffold/internal/jsonschema"
 	"gopkg.in/yaml.v3"
 )
 
 type ProjectScaffoldFile struct {
-	Metadata   Metadata                  `yaml:"metadata"`
-	Skip       []string                  `yaml:"skip"`
-	Questions  []Question                `yaml:"questions"`
-	Rewrites   []Rewrite                 `yaml:"rewrites"`
-	Computed   map[string]string         `yaml:"computed"`
-	Messages   Messages                  `yaml:"messages"`
-	Inject     []Injectable              `yaml:"inject"`
-	Features   []Feature                 `yaml:"features"`
-	Presets    map[string]map[string]any `yaml:"presets"`
-	Delimiters []Delimiters              `yaml:"delimiters"`
-	Each       []EachConfig              `yaml:"each"`
+	Metadata   Metadata                  `yaml:"metadata" description:"Metadata about the scaffold"`
+	Skip       []string                  `yaml:"skip" description:"Globs that will be used to skip template rendering for files that match"`
+	Questions  []Question                `yaml:"questions" description:"Prompts that will be used to gather information from the user before generating the project"`
+	Rewrites   []Rewrite                 `yaml:"rewrites" description:"Rules for rewriting output file paths"`
+	Computed   map[string]string         `yaml:"computed" description:"Map of values that will be computed based on the answers provided by the user"`
+	Messages   Messages                  `yaml:"messages" description:"Messages displayed before and after scaffolding"`
+	Inject     []Injectable              `yaml:"inject" description:"Rules for injecting content into existing files"`
+	Features   []Feature                 `yaml:"features" description:"Feature flags that conditionally include/exclude files"`
+	Presets    map[string]map[string]any `yaml:"presets" description:"Named presets that provide default values for questions"`
+	Delimiters []Delimiters              `yaml:"delimiters" description:"Custom template delimiters for specific file patterns"`
+	Each       []EachConfig              `yaml:"each" description:"Variables to expand for multi-file output. Path segments containing [varname] will produce one output per list item. Multiple tokens in a path are expanded as nested loops."`
+	Empty      EmptyMode                 `yaml:"empty" description:"What to do with files that render empty or whitespace-only output, and with empty directories"`
+	KeepEmpty  []string                  `yaml:"keep_empty" description:"Glob patterns of files and directories that are kept when empty, regardless of the empty setting"`
+	Format     []Format                  `yaml:"format" description:"Built-in formatters applied to rendered files matching a pattern"`
+	Whitespace []Whitespace              `yaml:"whitespace" description:"Whitespace control for all files, or files matching a pattern. Later entries override earlier ones"`
+	Checks     []Check                   `yaml:"checks" description:"Assertions about the output, run by 'scaffold test' and 'scaffold new --verify'"`
+	Lint       LintConfig                `yaml:"lint" description:"Configuration for 'scaffold lint'"`
+}
+
+// EmptyMode controls what happens to files that render to empty or
+// whitespace-only output, and to empty directories.
+type EmptyMode string
+
+const (
+	// EmptyDelete drops empty files and directories, this is the default.
+	EmptyDelete EmptyMode = "delete"
+	// EmptyKeep writes empty files and creates empty directories.
+	EmptyKeep EmptyMode = "keep"
+)
+
+func (EmptyMode) JSONSchemaExtend(s *jsonschema.Schema) {
+	s.Enum = []any{string(EmptyDelete), string(EmptyKeep)}
+	s.Default = string(EmptyDelete)
 }
 
 // EachConfig declares a variable for multi-file expansion. It supports both
 // a string shorthand ("services") and an object form ({var: "models", as: "..."}).
 type EachConfig struct {
-	Var string
-	As  string
+	Var string `yaml:"var" required:"true" description:"Variable name to expand over"`
+	As  string `yaml:"as" description:"Template expression to transform the item value for path substitution (e.g., '{{ .Each.Item | toPascalCase }}'). Required for object items without a name key"`
+}
+
+// JSONSchemaExtend describes the string shorthand of EachConfig.
+func (EachConfig) JSONSchemaExtend(s *jsonschema.Schema) {
+	object := *s
+	*s = jsonschema.Schema{
+		OneOf: []*jsonschema.Schema{
+			{Type: "string", Description: "Variable name shorthand"},
+			&object,
+		},
+	}
 }
 
 func (e *EachConfig) UnmarshalYAML(value *yaml.Node) error {
@@ -45,10 +80,32 @@ func (e *EachConfig) UnmarshalYAML(value *yaml.Node) error {
 	return nil
 }
 
+// Format applies a built-in formatter to rendered files matching Glob.
+type Format struct {
+	Glob      string `yaml:"glob" required:"true" description:"File pattern to apply the formatter to"`
+	Formatter string `yaml:"formatter" required:"true" description:"Built-in formatter to apply"`
+}
+
+func (Format) JSONSchemaExtend(s *jsonschema.Schema) {
+	formatter, _ := s.Properties.Get("formatter")
+	for _, name := range formatters.Names() {
+		formatter.Enum = append(formatter.Enum, name)
+	}
+}
+
+// Whitespace configures whitespace control for files matching Glob, or every
+// file when Glob is empty. Unset options keep the value of earlier entries.
+type Whitespace struct {
+	Glob               string `yaml:"glob" description:"File pattern to apply the settings to, all files when omitted"`
+	TrimBlocks         *bool  `yaml:"trim_blocks" description:"Remove the first line break after a block action"`
+	LStripBlocks       *bool  `yaml:"lstrip_blocks" description:"Remove spaces and tabs before a block action at the start of a line"`
+	CollapseBlankLines *bool  `yaml:"collapse_blank_lines" description:"Collapse consecutive blank lines in the output into one"`
+}
+
 type Delimiters struct {
-	Glob  string `yaml:"glob"`
-	Left  string `yaml:"left"`
-	Right string `yaml:"right"`
+	Glob  string `yaml:"glob" required:"true" description:"File pattern to apply custom delimiters to"`
+	Left  string `yaml:"left" required:"true" description:"Left delimiter (e.g., '{{', '[[')"`
+	Right string `yaml:"right" required:"true" description:"Right delimiter (e.g., '}}', ']]')"`
 }
 
 func ReadScaffoldFile(reader io.Reader) (*ProjectScaffoldFile, error) {
@@ -63,13 +120,13 @@ func ReadScaffoldFile(reader io.Reader) (*ProjectScaffoldFile, error) {
 }
 
 type Messages struct {
-	Pre  string `yaml:"pre"`
-	Post string `yaml:"post"`
+	Pre  string `yaml:"pre" description:"Message displayed before the questions are asked. Supports markdown."`
+	Post string `yaml:"post" description:"Message displayed after the template has been generated. Supports markdown and template syntax."`
 }
 
 type Rewrite struct {
-	From string `yaml:"from"`
-	To   string `yaml:"to"`
+	From string `yaml:"from" required:"true" description:"Glob pattern to match source paths"`
+	To   string `yaml:"to" required:"true" description:"Destination path (supports template syntax)"`
 }
 
 type Mode string
@@ -79,15 +136,19 @@ const (
 	After  Mode = "after"
 )
 
+func (Mode) JSONSchemaExtend(s *jsonschema.Schema) {
+	s.Enum = []any{string(Before), string(After)}
+}
+
 type Injectable struct {
-	Name     string `yaml:"name"`
-	Path     string `yaml:"path"`
-	At       string `yaml:"at"`
-	Mode     Mode   `yaml:"mode"`
-	Template string `yaml:"template"`
+	Name     string `yaml:"name" required:"true" description:"Name identifier for the injection"`
+	Path     string `yaml:"path" required:"true" description:"Relative path to the file to inject into (supports template syntax)"`
+	At       string `yaml:"at" required:"true" description:"Location marker where content will be injected"`
+	Mode     Mode   `yaml:"mode" description:"Whether to inject before or after the marker"`
+	Template string `yaml:"template" required:"true" description:"Content to inject (supports template syntax)"`
 }
 
 type Feature struct {
-	Value string   `yaml:"value"`
-	Globs []string `yaml:"globs"`
+	Value string   `yaml:"value" required:"true" description:"Template expression that when true enables the feature"`
+	Globs []string `yaml:"globs" required:"true" description:"File patterns that are part of this feature"`
 }
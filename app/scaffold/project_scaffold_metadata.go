@@ -1,35 +1,192 @@
 package scaffold
 
 import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"slices"
+	"strings"
+
 	"github.com/hashicorp/go-version"
-	"github.com/rs/zerolog"
+	"github.com/hay-kot/scaffold/internal/jsonschema"
+	"gopkg.in/yaml.v3"
 )
 
+// Metadata describes a scaffold. Apart from the version constraints and
+// required capabilities it's only used to present the scaffold in `scaffold
+// list`, the scaffold picker and `scaffold inspect`.
 type Metadata struct {
-	MinimumVersion string `yaml:"minimum_version"`
+	MinimumVersion string   `yaml:"minimum_version" description:"Minimum scaffold CLI version required"`
+	MaximumVersion string   `yaml:"maximum_version" description:"Maximum scaffold CLI version supported"`
+	Constraints    string   `yaml:"constraints" description:"Version constraints the scaffold CLI must satisfy, for example '>=0.9, <2.0'"`
+	Requires       []string `yaml:"requires" description:"Capabilities the scaffold CLI must support, for example 'each'"`
+	Name           string   `yaml:"name" description:"Display name of the scaffold"`
+	Description    string   `yaml:"description" description:"Short description of what the scaffold generates"`
+	Category       string   `yaml:"category" description:"Category the scaffold is grouped under"`
+	Tags           []string `yaml:"tags" description:"Tags used to filter scaffolds"`
+	Maintainers    []string `yaml:"maintainers" description:"Maintainers of the scaffold, for example 'Name <email>'"`
+	Homepage       string   `yaml:"homepage" description:"URL of the scaffold's documentation or repository"`
+}
+
+func (Metadata) JSONSchemaExtend(s *jsonschema.Schema) {
+	requires, _ := s.Properties.Get("requires")
+	for _, c := range Capabilities {
+		requires.Items.Enum = append(requires.Items.Enum, c.ID)
+	}
 }
 
-func (m Metadata) IsCompatible(l zerolog.Logger, current string) (bool, error) {
-	if m.MinimumVersion == "" || m.MinimumVersion == "*" {
-		return true, nil
+// ReadMetadata reads the metadata of the scaffold file in the root of fsys
+// without decoding the rest of the file.
+func ReadMetadata(fsys fs.FS) (Metadata, error) {
+	f, err := readFirst(fsys, "scaffold.yaml", "scaffold.yml")
+	if err != nil {
+		return Metadata{}, err
+	}
+	defer f.Close() //nolint:errcheck
+
+	var out struct {
+		Metadata Metadata `yaml:"metadata"`
+	}
+
+	err = yaml.NewDecoder(f).Decode(&out)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return Metadata{}, err
 	}
 
-	if current == "dev" {
-		l.Debug().Msg("current version is dev, skipping version check")
-		return true, nil
+	return out.Metadata, nil
+}
+
+// HasTags reports whether the scaffold is tagged with every tag of tags,
+// ignoring case. The category counts as a tag.
+func (m Metadata) HasTags(tags ...string) bool {
+	for _, tag := range tags {
+		match := func(t string) bool { return strings.EqualFold(t, tag) }
+		if !match(m.Category) && !slices.ContainsFunc(m.Tags, match) {
+			return false
+		}
 	}
 
+	return true
+}
+
+// CheckCompatibility returns a *CompatibilityError when the release current
+// doesn't support the capabilities the scaffold requires or doesn't satisfy
+// its minimum version, maximum version or constraints. Version checks are
+// skipped for dev builds, required capabilities are always checked. Other
+// errors are returned when a version or constraint can't be parsed.
+func (m Metadata) CheckCompatibility(current string) error {
+	cerr := &CompatibilityError{Current: current}
+
+	for _, id := range m.Requires {
+		if _, ok := LookupCapability(id); !ok {
+			cerr.Reasons = append(cerr.Reasons, fmt.Sprintf("requires the %q capability, which this release doesn't support", id))
+			cerr.Upgrade = true
+		}
+	}
+
+	minimum := m.MinimumVersion
+	if minimum == "*" {
+		minimum = ""
+	}
+
+	if (minimum != "" || m.MaximumVersion != "" || m.Constraints != "") && current != "dev" {
+		err := m.checkVersion(cerr, current, minimum)
+		if err != nil {
+			return err
+		}
+	}
+
+	if len(cerr.Reasons) > 0 {
+		return cerr
+	}
+
+	return nil
+}
+
+func (m Metadata) checkVersion(cerr *CompatibilityError, current, minimum string) error {
 	currentVersion, err := version.NewVersion(current)
 	if err != nil {
-		l.Error().Err(err).Msg("failed to parse current version")
-		return false, err
+		return fmt.Errorf("invalid scaffold version %q: %w", current, err)
 	}
 
-	minimumVersion, err := version.NewVersion(m.MinimumVersion)
-	if err != nil {
-		l.Error().Err(err).Msg("failed to parse minimum version")
-		return false, err
+	if minimum != "" {
+		v, err := version.NewVersion(minimum)
+		if err != nil {
+			return fmt.Errorf("invalid metadata.minimum_version %q: %w", minimum, err)
+		}
+
+		if currentVersion.LessThan(v) {
+			cerr.Reasons = append(cerr.Reasons, fmt.Sprintf("requires version %s or higher", minimum))
+			cerr.Upgrade = true
+		}
+	}
+
+	if m.MaximumVersion != "" {
+		v, err := version.NewVersion(m.MaximumVersion)
+		if err != nil {
+			return fmt.Errorf("invalid metadata.maximum_version %q: %w", m.MaximumVersion, err)
+		}
+
+		if currentVersion.GreaterThan(v) {
+			cerr.Reasons = append(cerr.Reasons, fmt.Sprintf("supports versions up to %s", m.MaximumVersion))
+		}
+	}
+
+	if m.Constraints != "" {
+		c, err := version.NewConstraint(m.Constraints)
+		if err != nil {
+			return fmt.Errorf("invalid metadata.constraints %q: %w", m.Constraints, err)
+		}
+
+		if !c.Check(currentVersion) {
+			cerr.Reasons = append(cerr.Reasons, fmt.Sprintf("requires a version matching %s", m.Constraints))
+			// Upgrading helps when the current version is below every
+			// bound of the constraints.
+			cerr.Upgrade = cerr.Upgrade || belowConstraints(c, currentVersion)
+		}
+	}
+
+	return nil
+}
+
+// belowConstraints reports whether v fails c only because it's too old, that
+// is every failing constraint is a lower bound.
+func belowConstraints(c version.Constraints, v *version.Version) bool {
+	for _, cs := range c {
+		if cs.Check(v) {
+			continue
+		}
+
+		if !strings.HasPrefix(strings.TrimSpace(cs.String()), ">") {
+			return false
+		}
+	}
+
+	return true
+}
+
+// CompatibilityError is returned when a scaffold can't be run by this release
+// of scaffold.
+type CompatibilityError struct {
+	Current string
+	Reasons []string
+	// Upgrade is true when a newer release can run the scaffold.
+	Upgrade bool
+}
+
+func (e *CompatibilityError) Error() string {
+	bldr := strings.Builder{}
+	fmt.Fprintf(&bldr, "scaffold %s can't run this scaffold:", e.Current)
+	for _, r := range e.Reasons {
+		bldr.WriteString("\n  - the scaffold " + r)
+	}
+
+	if e.Upgrade {
+		bldr.WriteString("\nupgrade scaffold with 'brew upgrade scaffold' or 'go install github.com/hay-kot/scaffold@latest', or download a release from https://github.com/hay-kot/scaffold/releases")
+	} else {
+		bldr.WriteString("\ninstall a supported release from https://github.com/hay-kot/scaffold/releases")
 	}
 
-	return currentVersion.GreaterThanOrEqual(minimumVersion), nil
+	return bldr.String()
 }
@@ -1,83 +1,137 @@
 package scaffold
 
 import (
+	"errors"
 	"testing"
+	"testing/fstest"
 
-	"github.com/rs/zerolog/log"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 )
 
-func TestMetadata_IsCompatible(t *testing.T) {
+func TestReadMetadata(t *testing.T) {
+	fsys := fstest.MapFS{
+		"scaffold.yml": {Data: []byte(`
+metadata:
+  name: Go API
+  description: REST API service
+  category: backend
+  tags: [go, api]
+  maintainers: ["Jane <jane@example.com>"]
+  homepage: https://example.com
+questions:
+  - name: unused
+    prompt: {message: ignored}
+`)},
+	}
+
+	got, err := ReadMetadata(fsys)
+	require.NoError(t, err)
+	assert.Equal(t, Metadata{
+		Name:        "Go API",
+		Description: "REST API service",
+		Category:    "backend",
+		Tags:        []string{"go", "api"},
+		Maintainers: []string{"Jane <jane@example.com>"},
+		Homepage:    "https://example.com",
+	}, got)
+
+	got, err = ReadMetadata(fstest.MapFS{"scaffold.yaml": {Data: []byte("")}})
+	require.NoError(t, err)
+	assert.Equal(t, Metadata{}, got)
+
+	_, err = ReadMetadata(fstest.MapFS{})
+	require.Error(t, err)
+}
+
+func TestMetadata_HasTags(t *testing.T) {
+	m := Metadata{Category: "backend", Tags: []string{"go", "API"}}
+
+	assert.True(t, m.HasTags())
+	assert.True(t, m.HasTags("go"))
+	assert.True(t, m.HasTags("api", "Backend"))
+	assert.False(t, m.HasTags("go", "rust"))
+	assert.False(t, Metadata{}.HasTags("go"))
+}
+
+func TestMetadata_CheckCompatibility(t *testing.T) {
 	tests := []struct {
-		name           string
-		minimumVersion string
-		currentVersion string
-		expected       bool
-		expectError    bool
+		name    string
+		meta    Metadata
+		current string
+		reasons []string
+		upgrade bool
+		wantErr bool
 	}{
+		{name: "no constraints", meta: Metadata{}, current: "1.0.0"},
+		{name: "wildcard minimum", meta: Metadata{MinimumVersion: "*"}, current: "1.0.0"},
+		{name: "minimum on dev", meta: Metadata{MinimumVersion: "1.0.0"}, current: "dev"},
+		{name: "minimum satisfied", meta: Metadata{MinimumVersion: "1.0.0"}, current: "1.0.0"},
 		{
-			name:           "Compatible with wildcard",
-			minimumVersion: "*",
-			currentVersion: "1.0.0",
-			expected:       true,
-			expectError:    false,
-		},
-		{
-			name:           "Compatible with empty minimum version",
-			minimumVersion: "",
-			currentVersion: "1.0.0",
-			expected:       true,
-			expectError:    false,
+			name:    "minimum too old",
+			meta:    Metadata{MinimumVersion: "2.0.0"},
+			current: "1.0.0",
+			reasons: []string{"requires version 2.0.0 or higher"},
+			upgrade: true,
 		},
+		{name: "invalid current version", meta: Metadata{MinimumVersion: "1.0.0"}, current: "invalid", wantErr: true},
+		{name: "invalid minimum version", meta: Metadata{MinimumVersion: "invalid"}, current: "1.0.0", wantErr: true},
+		{name: "constraints satisfied", meta: Metadata{Constraints: ">=0.9, <2.0"}, current: "1.4.0"},
 		{
-			name:           "Compatible with dev version",
-			minimumVersion: "1.0.0",
-			currentVersion: "dev",
-			expected:       true,
-			expectError:    false,
+			name:    "constraints too old",
+			meta:    Metadata{Constraints: ">=0.9, <2.0"},
+			current: "0.8.1",
+			reasons: []string{"requires a version matching >=0.9, <2.0"},
+			upgrade: true,
 		},
 		{
-			name:           "Compatible version",
-			minimumVersion: "1.0.0",
-			currentVersion: "1.0.0",
-			expected:       true,
-			expectError:    false,
+			name:    "constraints too new",
+			meta:    Metadata{Constraints: ">=0.9, <2.0"},
+			current: "2.1.0",
+			reasons: []string{"requires a version matching >=0.9, <2.0"},
 		},
 		{
-			name:           "Incompatible version",
-			minimumVersion: "2.0.0",
-			currentVersion: "1.0.0",
-			expected:       false,
-			expectError:    false,
+			name:    "maximum version",
+			meta:    Metadata{MaximumVersion: "1.9.9"},
+			current: "2.0.0",
+			reasons: []string{"supports versions up to 1.9.9"},
 		},
 		{
-			name:           "Error parsing current version",
-			minimumVersion: "1.0.0",
-			currentVersion: "invalid",
-			expected:       false,
-			expectError:    true,
+			name:    "minimum and maximum",
+			meta:    Metadata{MinimumVersion: "1.2.0", MaximumVersion: "1.9.9"},
+			current: "1.1.0",
+			reasons: []string{"requires version 1.2.0 or higher"},
+			upgrade: true,
 		},
+		{name: "known capability", meta: Metadata{Requires: []string{"each"}}, current: "1.0.0"},
 		{
-			name:           "Error parsing minimum version",
-			minimumVersion: "invalid",
-			currentVersion: "1.0.0",
-			expected:       false,
-			expectError:    true,
+			name:    "unknown capability on dev",
+			meta:    Metadata{Requires: []string{"each", "teleport"}, MinimumVersion: "99.0.0"},
+			current: "dev",
+			reasons: []string{`requires the "teleport" capability, which this release doesn't support`},
+			upgrade: true,
 		},
+		{name: "invalid constraints", meta: Metadata{Constraints: "about 1"}, current: "1.0.0", wantErr: true},
+		{name: "invalid maximum version", meta: Metadata{MaximumVersion: "latest"}, current: "1.0.0", wantErr: true},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			m := Metadata{MinimumVersion: tt.minimumVersion}
-			logger := log.With().Logger()
-			result, err := m.IsCompatible(logger, tt.currentVersion)
+			err := tt.meta.CheckCompatibility(tt.current)
 
-			if tt.expectError {
-				assert.Error(t, err)
-			} else {
-				assert.NoError(t, err)
+			var cerr *CompatibilityError
+			switch {
+			case tt.wantErr:
+				require.Error(t, err)
+				assert.False(t, errors.As(err, &cerr))
+			case len(tt.reasons) == 0:
+				require.NoError(t, err)
+			default:
+				require.ErrorAs(t, err, &cerr)
+				assert.Equal(t, tt.reasons, cerr.Reasons)
+				assert.Equal(t, tt.upgrade, cerr.Upgrade)
+				assert.Contains(t, err.Error(), tt.reasons[0])
 			}
-			assert.Equal(t, tt.expected, result)
 		})
 	}
 }
@@ -1,11 +1,17 @@
 package scaffold
 
 import (
+	"bytes"
+	"io"
 	"io/fs"
+	"maps"
+	"strings"
 	"testing"
 
+	"github.com/charmbracelet/huh"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func TestLoadProject(t *testing.T) {
@@ -52,3 +58,124 @@ func TestLoadProject(t *testing.T) {
 		})
 	}
 }
+
+// lineReader returns one line per read so each accessible prompt, which wraps
+// the reader in its own scanner, only consumes its own answer.
+type lineReader struct {
+	lines []string
+}
+
+func (r *lineReader) Read(p []byte) (int, error) {
+	if len(r.lines) == 0 {
+		return 0, io.EOF
+	}
+
+	n := copy(p, r.lines[0]+"\n")
+	r.lines = r.lines[1:]
+	return n, nil
+}
+
+func TestProject_AskQuestions_ObjectLoopInGroup(t *testing.T) {
+	tests := []struct {
+		name      string
+		questions string
+		def       map[string]any
+		answers   []string
+		order     []string
+		want      map[string]any
+	}{
+		{
+			name: "questions before the loop are asked first",
+			questions: `
+- name: name
+  group: g
+  prompt:
+    message: Name
+- name: items
+  group: g
+  prompt:
+    message: Items
+    loop: true
+    fields:
+      - name: item
+        prompt:
+          message: Item
+`,
+			answers: []string{"app", "one", "n"},
+			order:   []string{"Name", "Item", "Add another to Items?"},
+			want: map[string]any{
+				"name":  "app",
+				"items": []map[string]any{{"item": "one"}},
+			},
+		},
+		{
+			name: "loop in a hidden group is skipped",
+			questions: `
+- name: name
+  group: g
+  when: "{{ .Enabled }}"
+  prompt:
+    message: Name
+- name: items
+  group: g
+  prompt:
+    message: Items
+    loop: true
+    fields:
+      - name: item
+        prompt:
+          message: Item
+`,
+			def:     map[string]any{"Enabled": false},
+			answers: []string{"app"},
+			order:   []string{"Name"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var questions []Question
+			require.NoError(t, yaml.Unmarshal([]byte(tt.questions), &questions))
+
+			in := &lineReader{lines: tt.answers}
+			var out bytes.Buffer
+
+			prev := runHuhForm
+			runHuhForm = func(f *huh.Form) error {
+				return f.WithAccessible(true).WithInput(in).WithOutput(&out).Run()
+			}
+			t.Cleanup(func() { runHuhForm = prev })
+
+			p := &Project{
+				NameTemplate: TemplateDirName,
+				Conf:         &ProjectScaffoldFile{Questions: questions},
+			}
+
+			def := map[string]any{}
+			maps.Copy(def, tt.def)
+
+			vars, err := p.AskQuestions(def, tEngine, huh.ThemeBase())
+			require.NoError(t, err)
+			assert.Empty(t, in.lines, "all answers are read")
+
+			// Titles are asked in order, later titles are searched after the
+			// previous one.
+			rest := out.String()
+			for _, title := range tt.order {
+				i := strings.Index(rest, title)
+				require.GreaterOrEqual(t, i, 0, "%q asked after the previous title in:\n%s", title, out.String())
+				rest = rest[i+len(title):]
+			}
+
+			if tt.want == nil {
+				assert.NotContains(t, vars, "items")
+				assert.NotContains(t, out.String(), "Item")
+				return
+			}
+
+			for k, v := range tt.want {
+				assert.Equal(t, v, vars[k], k)
+			}
+		})
+	}
+}
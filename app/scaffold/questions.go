@@ -1,9 +1,13 @@
 package scaffold
 
 import (
+	"fmt"
+	"strconv"
+
 	"github.com/charmbracelet/huh"
 	"github.com/hay-kot/scaffold/app/core/engine"
 	"github.com/hay-kot/scaffold/internal/huhext"
+	"github.com/hay-kot/scaffold/internal/jsonschema"
 	"github.com/hay-kot/scaffold/internal/validators"
 	"github.com/rs/zerolog/log"
 )
@@ -31,13 +35,30 @@ outer:
 	return grouped
 }
 
+// isHiddenGroup reports whether a group of questions is skipped. The when
+// condition of the first question applies to the whole group, conditions that
+// fail to render hide the group.
+func isHiddenGroup(e *engine.Engine, group []Question, vars map[string]any) bool {
+	if group[0].When == "" {
+		return false
+	}
+
+	result, err := e.TmplString(group[0].When, vars)
+	if err != nil {
+		return true
+	}
+
+	resultBool, _ := strconv.ParseBool(result)
+	return !resultBool
+}
+
 type Question struct {
-	Name     string              `yaml:"name"`
-	Group    string              `yaml:"group"`
-	Prompt   AnyPrompt           `yaml:"prompt"`
-	When     string              `yaml:"when"`
-	Required bool                `yaml:"required"`
-	Validate validators.Validate `yaml:"validate"`
+	Name     string              `yaml:"name" required:"true" description:"Key used to store the answer and reference it in templates"`
+	Group    string              `yaml:"group" description:"Optional key to group questions together in a shared view"`
+	Prompt   AnyPrompt           `yaml:"prompt" required:"true" description:"How the question is asked, the set fields select the kind of prompt"`
+	When     string              `yaml:"when" description:"Conditional template that determines if the question should be asked"`
+	Required bool                `yaml:"required" description:"Whether the question requires an answer (deprecated, use validate.required)"`
+	Validate validators.Validate `yaml:"validate" description:"Validation applied to the answer"`
 }
 
 func (q Question) Title() string {
@@ -53,13 +74,26 @@ func (q Question) Description() string {
 }
 
 type AnyPrompt struct {
-	Message     *string   `yaml:"message"`
-	Description *string   `yaml:"description"`
-	Default     any       `yaml:"default"`
-	Confirm     *string   `yaml:"confirm"`
-	Options     *[]string `yaml:"options"`
-	Loop        bool      `yaml:"loop"`
-	Multi       bool      `yaml:"multi"`
+	Message     *string   `yaml:"message" description:"Primary message/label shown to the user"`
+	Description *string   `yaml:"description" description:"Secondary message providing additional context"`
+	Default     any       `yaml:"default" description:"Default value if the user provides no input"`
+	Confirm     *string   `yaml:"confirm" description:"Message for boolean confirmation prompts"`
+	Options     *[]string `yaml:"options" description:"Options for select/multi-select prompts"`
+	Loop        bool      `yaml:"loop" description:"When true, keeps asking for input until empty string is provided"`
+	Multi       bool      `yaml:"multi" description:"When true, allows multiple selections or multiline text input"`
+	// Fields are the questions asked for every item of an object loop.
+	Fields []Question `yaml:"fields" description:"Questions asked for every item of a loop, producing a list of objects"`
+}
+
+// JSONSchemaExtend requires a message or confirm, the prompt variants are
+// selected by the other fields: options for a select, options and multi for a
+// multi select, loop for an input loop and loop with fields for an object
+// loop.
+func (AnyPrompt) JSONSchemaExtend(s *jsonschema.Schema) {
+	s.AnyOf = []*jsonschema.Schema{
+		{Required: []string{"message"}},
+		{Required: []string{"confirm"}},
+	}
 }
 
 func (p AnyPrompt) IsSelect() bool {
@@ -75,7 +109,13 @@ func (p AnyPrompt) IsInput() bool {
 }
 
 func (p AnyPrompt) IsInputLoop() bool {
-	return p.IsInput() && p.Loop
+	return p.IsInput() && p.Loop && len(p.Fields) == 0
+}
+
+// IsObjectLoop reports whether the prompt repeatedly asks its fields,
+// producing a list of objects.
+func (p AnyPrompt) IsObjectLoop() bool {
+	return p.IsInput() && p.Loop && len(p.Fields) > 0
 }
 
 func (p AnyPrompt) IsTextInput() bool {
@@ -199,6 +239,68 @@ func (q Question) ToAskable(def any) *Askable {
 	}
 }
 
+// objectLoopAskable returns an askable that, when run, asks the fields of an
+// object loop question once per item until the user declines to add another.
+// The answer is a []map[string]any with one object per item.
+func (q Question) objectLoopAskable(theme *huh.Theme) *Askable {
+	items := []map[string]any{}
+
+	askable := NewAskable(q.Title(), q.Name, nil, func(vars engine.Vars) error {
+		vars[q.Name] = items
+		return nil
+	})
+
+	askable.run = func() error {
+		for {
+			item := engine.Vars{}
+			askables := make([]*Askable, 0, len(q.Prompt.Fields))
+			fields := make([]huh.Field, 0, len(q.Prompt.Fields))
+
+			for _, f := range q.Prompt.Fields {
+				a := f.ToAskable(nil)
+				askables = append(askables, a)
+				fields = append(fields, a.Field)
+			}
+
+			more := false
+			confirm := huh.NewConfirm().
+				Title(fmt.Sprintf("Add another to %s?", q.Title())).
+				Value(&more)
+
+			form := huh.NewForm(
+				huh.NewGroup(fields...).
+					Title(fmt.Sprintf("%s (#%d)", q.Title(), len(items)+1)).
+					Description(q.Description()),
+				huh.NewGroup(confirm),
+			).WithTheme(theme)
+
+			err := runHuhForm(form)
+			if err != nil {
+				return err
+			}
+
+			for _, a := range askables {
+				err := a.Hook(item)
+				if err != nil {
+					return err
+				}
+			}
+
+			items = append(items, map[string]any(item))
+
+			if !more {
+				return nil
+			}
+		}
+	}
+
+	askable.value = func() any {
+		return items
+	}
+
+	return askable
+}
+
 func toHuhOptions(opts *[]string) []huh.Option[string] {
 	out := make([]huh.Option[string], len(*opts))
 	for i, opt := range *opts {
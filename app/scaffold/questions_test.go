@@ -2,7 +2,12 @@ package scaffold
 
 import (
 	"reflect"
+	"strings"
 	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
 )
 
 func Test_QuestionGroupBy(t *testing.T) {
@@ -71,3 +76,46 @@ func Test_QuestionGroupBy(t *testing.T) {
 		})
 	}
 }
+
+func Test_AnyPrompt_ObjectLoop(t *testing.T) {
+	input := `
+name: models
+prompt:
+  message: Models
+  loop: true
+  fields:
+    - name: name
+      prompt:
+        message: Model name
+    - name: has_timestamps
+      prompt:
+        confirm: Timestamps?
+`
+
+	var q Question
+	err := yaml.Unmarshal([]byte(input), &q)
+	require.NoError(t, err)
+
+	assert.True(t, q.Prompt.IsObjectLoop())
+	assert.False(t, q.Prompt.IsInputLoop())
+	require.Len(t, q.Prompt.Fields, 2)
+	assert.True(t, q.Prompt.Fields[1].Prompt.IsConfirm())
+
+	q.Prompt.Fields = nil
+	assert.False(t, q.Prompt.IsObjectLoop())
+	assert.True(t, q.Prompt.IsInputLoop())
+}
+
+func Test_Askable_String_Objects(t *testing.T) {
+	items := []map[string]any{
+		{"name": "user", "has_timestamps": true},
+	}
+
+	a := NewAskable("Models", "models", nil, nil)
+	a.value = func() any { return items }
+
+	out := a.String()
+	assert.True(t, strings.Contains(out, "- "), out)
+	assert.Less(t, strings.Index(out, "has_timestamps"), strings.Index(out, "name"), "keys are sorted")
+	assert.Contains(t, out, "user")
+}
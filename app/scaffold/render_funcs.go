@@ -10,12 +10,15 @@ import (
 	"os"
 	"path/filepath"
 	"regexp"
+	"slices"
 	"strconv"
 	"strings"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"github.com/hay-kot/scaffold/app/core/apperrors"
+	"github.com/hay-kot/scaffold/app/core/detect"
 	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/formatters"
 	"github.com/hay-kot/scaffold/app/core/rwfs"
 	"github.com/huandu/xstrings"
 	"github.com/rs/zerolog/log"
@@ -25,6 +28,55 @@ type RWFSArgs struct {
 	ReadFS  rwfs.ReadFS
 	WriteFS rwfs.WriteFS
 	Project *Project
+	// Report is optional, when set it records what happened to files that
+	// were not written.
+	Report *Report
+
+	// frontMatters caches the parsed front matter of source files.
+	frontMatters map[string]*FrontMatter
+	// conflictAll is the action chosen for all remaining conflicts.
+	conflictAll ConflictAction
+}
+
+// Report records the outcome of a render for files that were not written
+// as rendered.
+type Report struct {
+	// DroppedEmpty lists the output paths of files that rendered empty or
+	// whitespace-only output and were not written.
+	DroppedEmpty []string
+	// Conflicts lists the files that already existed and how they were
+	// resolved.
+	Conflicts []ResolvedConflict
+}
+
+// keepEmpty reports whether the source file or directory at path is kept
+// when it is empty. Paths matching a keep_empty glob are always kept,
+// otherwise the scaffold's empty mode applies.
+func keepEmpty(args *RWFSArgs, path string) (bool, error) {
+	conf := args.Project.Conf
+	if conf == nil {
+		return false, nil
+	}
+
+	relativePath := strings.TrimPrefix(path, args.Project.NameTemplate+"/")
+	for _, pattern := range conf.KeepEmpty {
+		match, err := doublestar.PathMatch(pattern, relativePath)
+		if err != nil {
+			return false, err
+		}
+
+		if match {
+			return true, nil
+		}
+	}
+
+	return conf.Empty == EmptyKeep, nil
+}
+
+// isEmptyDir reports whether the directory at path has no entries.
+func isEmptyDir(rfs rwfs.ReadFS, path string) bool {
+	entries, err := fs.ReadDir(rfs, path)
+	return err == nil && len(entries) == 0
 }
 
 // errSkipRender is used to skip rendering a file when a guard returns it.
@@ -35,19 +87,163 @@ var (
 	errSkipWrite  = errors.New("skip write")
 )
 
+// streamThreshold is the file size, in bytes, above which verbatim copies are
+// streamed to the output instead of being read into memory.
+var streamThreshold int64 = 10 << 20
+
+// sniff reads up to detect.SniffLen bytes from r and returns them along with a reader
+// that yields the complete contents, including the sniffed bytes.
+func sniff(r io.Reader) ([]byte, io.Reader, error) {
+	head := make([]byte, detect.SniffLen)
+
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return nil, nil, err
+	}
+
+	head = head[:n]
+	return head, io.MultiReader(bytes.NewReader(head), r), nil
+}
+
+// readLinkFS is implemented by file systems that can read symbolic links,
+// such as os.DirFS.
+type readLinkFS interface {
+	ReadLink(name string) (string, error)
+}
+
+// defaultFileMode is used when the source file system doesn't report
+// permissions.
+const defaultFileMode fs.FileMode = 0o644
+
+// fileMode returns the permission bits for the output of the source file at
+// name. Owner read/write is always granted so generated files stay editable,
+// e.g. files from an embed.FS are read-only.
+func fileMode(fsys fs.FS, name string) fs.FileMode {
+	info, err := fs.Stat(fsys, name)
+	if err != nil || info.Mode().Perm() == 0 {
+		return defaultFileMode
+	}
+
+	return info.Mode().Perm() | 0o600
+}
+
+func isSymlink(d fs.DirEntry) bool {
+	return d.Type()&fs.ModeSymlink != 0
+}
+
+// writeFile writes data to outpath with mode. The mode is applied explicitly
+// as it is not changed when the file already exists.
+func writeFile(wfs rwfs.WriteFS, outpath string, data []byte, mode fs.FileMode) error {
+	err := wfs.WriteFile(outpath, data, mode)
+	if err != nil {
+		return err
+	}
+
+	return wfs.Chmod(outpath, mode)
+}
+
+// writeSymlink reproduces the link at sourcePath as a link at outpath. When
+// vars is not nil the link target is rendered as a template.
+func writeSymlink(eng *engine.Engine, wfs rwfs.WriteFS, rfs readLinkFS, sourcePath, outpath string, vars engine.Vars) error {
+	target, err := rfs.ReadLink(sourcePath)
+	if err != nil {
+		return err
+	}
+
+	if vars != nil {
+		target, err = eng.TmplString(target, vars)
+		if err != nil {
+			return fmt.Errorf("rendering link target of %s: %w", sourcePath, err)
+		}
+
+		if target == "" {
+			return fmt.Errorf("link target of %s rendered empty string", sourcePath)
+		}
+	}
+
+	err = wfs.MkdirAll(filepath.Dir(outpath), os.ModePerm)
+	if err != nil {
+		if !os.IsExist(err) {
+			return err
+		}
+	}
+
+	log.Debug().Str("path", outpath).Str("target", target).Msg("creating symlink")
+	return wfs.Symlink(target, outpath)
+}
+
+// copyVerbatim writes the contents of r to outpath without rendering. Files
+// larger than streamThreshold, or of unknown size, are streamed.
+func copyVerbatim(wfs rwfs.WriteFS, outpath string, r io.Reader, d fs.DirEntry, mode fs.FileMode) error {
+	err := wfs.MkdirAll(filepath.Dir(outpath), os.ModePerm)
+	if err != nil {
+		if !os.IsExist(err) {
+			return err
+		}
+	}
+
+	size := int64(-1)
+	if info, err := d.Info(); err == nil {
+		size = info.Size()
+	}
+
+	if size >= 0 && size <= streamThreshold {
+		bits, err := io.ReadAll(r)
+		if err != nil {
+			return err
+		}
+
+		return writeFile(wfs, outpath, bits, mode)
+	}
+
+	log.Debug().Str("path", outpath).Int64("size", size).Msg("streaming file")
+
+	w, err := wfs.Create(outpath, mode)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(w, r)
+	if err != nil {
+		_ = w.Close()
+		return err
+	}
+
+	err = w.Close()
+	if err != nil {
+		return err
+	}
+
+	return wfs.Chmod(outpath, mode)
+}
+
 // eachPattern matches [varname] in path segments. The varname must be a valid
 // identifier (letters, digits, underscores).
 var eachPattern = regexp.MustCompile(`\[([a-zA-Z_]\w*)\]`)
 
-// detectEachPattern finds the first [varname] in a path and returns the variable
-// name and the bracket-wrapped token (e.g., "[services]"). Returns empty strings
-// if no pattern is found.
-func detectEachPattern(path string) (varName, token string) {
-	m := eachPattern.FindStringSubmatch(path)
-	if m == nil {
-		return "", ""
+// eachToken is a [varname] token found in a path.
+type eachToken struct {
+	Var   string
+	Token string
+}
+
+// detectEachPatterns finds every [varname] in a path, in order of appearance,
+// and returns the variable names with their bracket-wrapped tokens (e.g.,
+// "[services]"). Repeated tokens are only returned once.
+func detectEachPatterns(path string) []eachToken {
+	var out []eachToken
+	seen := map[string]bool{}
+
+	for _, m := range eachPattern.FindAllStringSubmatch(path, -1) {
+		if seen[m[1]] {
+			continue
+		}
+
+		seen[m[1]] = true
+		out = append(out, eachToken{Var: m[1], Token: m[0]})
 	}
-	return m[1], m[0]
+
+	return out
 }
 
 // isEachVar checks whether varName is declared in the each config.
@@ -60,9 +256,22 @@ func isEachVar(each []EachConfig, varName string) (EachConfig, bool) {
 	return EachConfig{}, false
 }
 
-// resolveListVar extracts a []string from vars["Scaffold"][varName].
-// If the value is a plain string, it's wrapped into a single-element slice.
-func resolveListVar(vars engine.Vars, varName string) ([]string, error) {
+// resolveListVar resolves the items of the each variable varName. Items of
+// the enclosing loops are searched first, from the innermost out, so a list
+// field of an object item expands as a nested loop. Otherwise the variable is
+// read from vars["Scaffold"][varName].
+//
+// Items are either strings or objects (map[string]any). A plain string is
+// wrapped into a single-element slice.
+func resolveListVar(vars engine.Vars, parent map[string]any, varName string) ([]any, error) {
+	for p := parent; p != nil; p, _ = p["Parent"].(map[string]any) {
+		if item, ok := asObject(p["Item"]); ok {
+			if val, ok := item[varName]; ok {
+				return toEachItems(varName, val)
+			}
+		}
+	}
+
 	scaffold, ok := vars["Scaffold"]
 	if !ok {
 		return nil, fmt.Errorf("each: Scaffold vars not found")
@@ -78,26 +287,167 @@ func resolveListVar(vars engine.Vars, varName string) ([]string, error) {
 		return nil, fmt.Errorf("each: variable %q not found in Scaffold vars", varName)
 	}
 
+	return toEachItems(varName, val)
+}
+
+func toEachItems(varName string, val any) ([]any, error) {
 	switch v := val.(type) {
 	case []string:
-		return v, nil
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = item
+		}
+		return out, nil
+	case []map[string]any:
+		out := make([]any, len(v))
+		for i, item := range v {
+			out[i] = item
+		}
+		return out, nil
 	case []any:
-		out := make([]string, len(v))
+		out := make([]any, len(v))
 		for i, item := range v {
-			s, ok := item.(string)
+			if s, ok := item.(string); ok {
+				out[i] = s
+				continue
+			}
+
+			obj, ok := asObject(item)
 			if !ok {
-				return nil, fmt.Errorf("each: variable %q item %d is not a string", varName, i)
+				return nil, fmt.Errorf("each: variable %q item %d is not a string or object", varName, i)
 			}
-			out[i] = s
+			out[i] = obj
 		}
 		return out, nil
 	case string:
-		return []string{v}, nil
+		return []any{v}, nil
 	default:
 		return nil, fmt.Errorf("each: variable %q has unsupported type %T", varName, val)
 	}
 }
 
+func asObject(v any) (map[string]any, bool) {
+	switch m := v.(type) {
+	case map[string]any:
+		return m, true
+	case engine.Vars:
+		return m, true
+	default:
+		return nil, false
+	}
+}
+
+// makeEachContext creates the .Each context for an item. Parent is the
+// context of the enclosing loop, or nil at the top level.
+func makeEachContext(item any, index int, parent map[string]any) map[string]any {
+	return map[string]any{
+		"Item":   item,
+		"Index":  index,
+		"Parent": parent,
+	}
+}
+
+// makeEachVars creates a copy of vars with .Each set to ctx.
+func makeEachVars(vars engine.Vars, ctx map[string]any) engine.Vars {
+	v := maps.Clone(vars)
+	v["Each"] = ctx
+	return v
+}
+
+// eachReplacement returns the value that replaces the [varname] token for an
+// item. String items are used as is and objects use their "name" key, unless
+// the 'as' template of the config is set.
+func eachReplacement(eng *engine.Engine, ec EachConfig, item any, vars engine.Vars) (string, error) {
+	var replacement string
+
+	switch {
+	case ec.As != "":
+		out, err := eng.TmplString(ec.As, vars)
+		if err != nil {
+			return "", fmt.Errorf("each: rendering 'as' template for %q: %w", ec.Var, err)
+		}
+		if out == "" {
+			return "", fmt.Errorf("each: 'as' template for %q rendered empty string", ec.Var)
+		}
+		replacement = out
+	default:
+		switch v := item.(type) {
+		case string:
+			replacement = v
+		default:
+			obj, _ := asObject(v)
+			name, ok := obj["name"]
+			if !ok {
+				return "", fmt.Errorf("each: items of %q are objects, set 'as' or add a 'name' key", ec.Var)
+			}
+			replacement = fmt.Sprint(name)
+		}
+	}
+
+	if strings.Contains(replacement, "/") {
+		return "", fmt.Errorf("each: path value for %q contains path separator: %q", ec.Var, replacement)
+	}
+
+	return replacement, nil
+}
+
+// eachIteration is one combination of items for the each tokens of a path.
+type eachIteration struct {
+	// pairs holds the token, replacement pairs for strings.NewReplacer.
+	pairs []string
+	vars  engine.Vars
+}
+
+// expandEach returns the product of the items of every token. Tokens are
+// nested in order, so .Each refers to the last token and .Each.Parent to the
+// one before it.
+func expandEach(eng *engine.Engine, configs []EachConfig, tokens []eachToken, vars engine.Vars) ([]eachIteration, error) {
+	var out []eachIteration
+
+	var walk func(level int, parent map[string]any, pairs []string) error
+	walk = func(level int, parent map[string]any, pairs []string) error {
+		if level == len(tokens) {
+			out = append(out, eachIteration{
+				pairs: slices.Clone(pairs),
+				vars:  makeEachVars(vars, parent),
+			})
+			return nil
+		}
+
+		tok := tokens[level]
+		ec, _ := isEachVar(configs, tok.Var)
+
+		items, err := resolveListVar(vars, parent, tok.Var)
+		if err != nil {
+			return err
+		}
+
+		if len(items) == 0 {
+			log.Warn().Str("var", tok.Var).Msg("each variable is empty, no files generated")
+			return nil
+		}
+
+		for i, item := range items {
+			ctx := makeEachContext(item, i, parent)
+
+			replacement, err := eachReplacement(eng, ec, item, makeEachVars(vars, ctx))
+			if err != nil {
+				return err
+			}
+
+			err = walk(level+1, ctx, append(pairs, tok.Token, replacement))
+			if err != nil {
+				return err
+			}
+		}
+
+		return nil
+	}
+
+	err := walk(0, nil, nil)
+	return out, err
+}
+
 // addFileContextToError reads the file content and adds context lines to the template error
 func addFileContextToError(terr *apperrors.TemplateError, rfs rwfs.ReadFS, path string) *apperrors.TemplateError {
 	if terr.LineNumber <= 0 {
@@ -109,7 +459,17 @@ func addFileContextToError(terr *apperrors.TemplateError, rfs rwfs.ReadFS, path
 		return terr
 	}
 
-	lines := strings.Split(string(content), "\n")
+	return addContextToError(terr, string(content))
+}
+
+// addContextToError adds the lines of content around the error to the
+// template error.
+func addContextToError(terr *apperrors.TemplateError, content string) *apperrors.TemplateError {
+	if terr.LineNumber <= 0 {
+		return terr
+	}
+
+	lines := strings.Split(content, "\n")
 	if terr.LineNumber > len(lines) {
 		return terr
 	}
@@ -132,9 +492,11 @@ func addFileContextToError(terr *apperrors.TemplateError, rfs rwfs.ReadFS, path
 	return terr.WithContext(contextLines)
 }
 
-type filepathGuard func(outpath string, f fs.DirEntry) (newOutpath string, err error)
+// filepathGuard transforms the output path of the source file at sourcePath.
+// Guards return errSkipRender or errSkipWrite to skip the file.
+type filepathGuard func(sourcePath, outpath string, f fs.DirEntry) (newOutpath string, err error)
 
-func guardNoOp(outpath string, f fs.DirEntry) (string, error) {
+func guardNoOp(sourcePath, outpath string, f fs.DirEntry) (string, error) {
 	return outpath, nil
 }
 
@@ -143,7 +505,7 @@ func guardRewrite(args *RWFSArgs) filepathGuard {
 		return guardNoOp
 	}
 
-	return func(path string, f fs.DirEntry) (string, error) {
+	return func(sourcePath, path string, f fs.DirEntry) (string, error) {
 		for _, rewrite := range args.Project.Conf.Rewrites {
 			match, err := doublestar.Match(rewrite.From, path)
 			if err != nil {
@@ -160,24 +522,35 @@ func guardRewrite(args *RWFSArgs) filepathGuard {
 	}
 }
 
+// guardRenderPath renders the output path. A path segment that renders
+// empty, e.g. "{{ if .Scaffold.docker }}Dockerfile{{ end }}", skips the file
+// or the directory with everything in it.
 func guardRenderPath(s *engine.Engine, vars any) filepathGuard {
-	return func(outpath string, f fs.DirEntry) (string, error) {
+	return func(sourcePath, outpath string, f fs.DirEntry) (string, error) {
 		outpath, err := s.TmplString(outpath, vars)
 		if err != nil {
 			log.Debug().Err(err).Str("path", outpath).Msg("failed to render project path")
 			return "", err
 		}
 
+		for _, segment := range strings.Split(outpath, "/") {
+			if strings.TrimSpace(segment) == "" {
+				log.Debug().Str("path", sourcePath).Str("outpath", outpath).Msg("path rendered empty segment, skipping")
+				return "", errSkipRender
+			}
+		}
+
 		return outpath, nil
 	}
 }
 
 func guardNoClobber(args *RWFSArgs) filepathGuard {
-	if !args.Project.Options.NoClobber {
+	// Conflicts are resolved when the file is written.
+	if !args.Project.Options.NoClobber || args.Project.Options.OnConflict != "" {
 		return guardNoOp
 	}
 
-	return func(outpath string, f fs.DirEntry) (string, error) {
+	return func(sourcePath, outpath string, f fs.DirEntry) (string, error) {
 		wf, err := args.WriteFS.Open(outpath)
 
 		if err == nil {
@@ -193,7 +566,7 @@ func guardNoClobber(args *RWFSArgs) filepathGuard {
 }
 
 func guardDirectories(args *RWFSArgs) filepathGuard {
-	return func(outpath string, f fs.DirEntry) (string, error) {
+	return func(sourcePath, outpath string, f fs.DirEntry) (string, error) {
 		if !f.IsDir() {
 			return outpath, nil
 		}
@@ -222,7 +595,7 @@ func guardFeatureFlag(e *engine.Engine, args *RWFSArgs, vars engine.Vars) filepa
 		return guardNoOp
 	}
 
-	return func(outpath string, f fs.DirEntry) (newOutpath string, err error) {
+	return func(sourcePath, outpath string, f fs.DirEntry) (newOutpath string, err error) {
 		for _, feature := range args.Project.Conf.Features {
 			render, err := e.TmplString(feature.Value, vars)
 			if err != nil {
@@ -290,6 +663,84 @@ func BuildVars(eng *engine.Engine, project *Project, vars engine.Vars) (engine.V
 	return iVars, nil
 }
 
+// Delims returns the template delimiters used to render the source file at
+// sourcePath, a path in the project's file system.
+func (p *Project) Delims(sourcePath string) (left, right string, err error) {
+	return fileDelims(&RWFSArgs{ReadFS: p.RootFS, Project: p}, sourcePath)
+}
+
+// fileDelims returns the template delimiters for the source file at
+// sourcePath, applying the last matching delimiter override and then the
+// file's front matter.
+func fileDelims(args *RWFSArgs, sourcePath string) (left, right string, err error) {
+	left, right = "{{", "}}"
+
+	relativePath := strings.TrimPrefix(sourcePath, args.Project.NameTemplate+"/")
+	for _, delimOverride := range args.Project.Conf.Delimiters {
+		match, err := doublestar.Match(delimOverride.Glob, relativePath)
+		if err != nil {
+			return "", "", err
+		}
+
+		if !match {
+			continue
+		}
+
+		log.Debug().Str("path", sourcePath).Str("glob", delimOverride.Glob).Msg("matched delimiter override")
+
+		if delimOverride.Left == "" || delimOverride.Right == "" {
+			log.Error().
+				Str("left", delimOverride.Left).
+				Str("right", delimOverride.Right).
+				Msg("override delimiters must not be empty")
+		}
+
+		left = delimOverride.Left
+		right = delimOverride.Right
+	}
+
+	fm, err := args.frontMatter(sourcePath)
+	if err != nil {
+		return "", "", err
+	}
+
+	if fm != nil && fm.Delimiters != nil {
+		left, right = fm.Delimiters.Left, fm.Delimiters.Right
+	}
+
+	return left, right, nil
+}
+
+// formatOutput applies the formatters matching the source file at sourcePath
+// to the rendered data, in the order they are declared.
+func formatOutput(args *RWFSArgs, sourcePath string, data []byte) ([]byte, error) {
+	relativePath := strings.TrimPrefix(sourcePath, args.Project.NameTemplate+"/")
+	for _, f := range args.Project.Conf.Format {
+		match, err := doublestar.Match(f.Glob, relativePath)
+		if err != nil {
+			return nil, err
+		}
+
+		if !match {
+			continue
+		}
+
+		format, ok := formatters.Get(f.Formatter)
+		if !ok {
+			return nil, fmt.Errorf("%s: unknown formatter %q", sourcePath, f.Formatter)
+		}
+
+		log.Debug().Str("path", sourcePath).Str("formatter", f.Formatter).Msg("formatting output")
+
+		data, err = format(data)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %s formatter: %w", sourcePath, f.Formatter, err)
+		}
+	}
+
+	return data, nil
+}
+
 type processFileArgs struct {
 	sourcePath string
 	outpath    string
@@ -298,51 +749,153 @@ type processFileArgs struct {
 }
 
 func processFile(eng *engine.Engine, args *RWFSArgs, pf processFileArgs) error {
+	if isSymlink(pf.d) {
+		// File systems that can't read links fall through and render the
+		// link target's contents.
+		if rl, ok := args.ReadFS.(readLinkFS); ok {
+			outpath, _, ok, err := args.resolveConflict(conflictAction(args.Project.Options, nil), pf.outpath, nil, true)
+			if err != nil || !ok {
+				return err
+			}
+
+			return writeSymlink(eng, args.WriteFS, rl, pf.sourcePath, outpath, pf.vars)
+		}
+	}
+
+	mode := fileMode(args.ReadFS, pf.sourcePath)
+
+	fm, err := args.frontMatter(pf.sourcePath)
+	if err != nil {
+		return err
+	}
+
+	action := conflictAction(args.Project.Options, fm)
+
+	// write resolves conflicts with existing files before writing data.
+	write := func(data []byte) error {
+		outpath, data, ok, err := args.resolveConflict(action, pf.outpath, data, false)
+		if err != nil || !ok {
+			return err
+		}
+
+		return writeOutput(args.WriteFS, outpath, data, mode)
+	}
+
 	f, err := args.ReadFS.Open(pf.sourcePath)
 	if err != nil {
 		log.Debug().Err(err).Str("path", pf.sourcePath).Msg("failed to open file")
 		return err
 	}
 
-	delimLeft := "{{"
-	delimRight := "}}"
+	delimLeft, delimRight, err := fileDelims(args, pf.sourcePath)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
 
-	for _, delimOverride := range args.Project.Conf.Delimiters {
-		relativePath := strings.TrimPrefix(pf.sourcePath, args.Project.NameTemplate+"/")
-		match, err := doublestar.Match(delimOverride.Glob, relativePath)
+	head, r, err := sniff(f)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	if detect.IsBinary(head) {
+		log.Debug().Str("path", pf.sourcePath).Msg("binary file detected, copying verbatim")
+
+		outpath, _, ok, err := args.resolveConflict(action, pf.outpath, nil, true)
+		if err != nil || !ok {
+			_ = f.Close()
+			return err
+		}
+
+		err = copyVerbatim(args.WriteFS, outpath, r, pf.d, mode)
 		if err != nil {
 			_ = f.Close()
 			return err
 		}
 
-		if !match {
-			continue
+		return f.Close()
+	}
+
+	// dropEmpty is called when the output is empty or whitespace-only, it
+	// reports whether the file should be dropped.
+	dropEmpty := func() (bool, error) {
+		keep, err := keepEmpty(args, pf.sourcePath)
+		if err != nil || keep {
+			return false, err
 		}
 
-		log.Debug().Str("outputh", pf.outpath).Str("glob", delimOverride.Glob).Msg("matched delimiter override")
+		log.Debug().Str("path", pf.outpath).Msg("dropping empty file")
+		if args.Report != nil {
+			args.Report.DroppedEmpty = append(args.Report.DroppedEmpty, pf.outpath)
+		}
 
-		if delimOverride.Left == "" || delimOverride.Right == "" {
-			log.Error().
-				Str("left", delimOverride.Left).
-				Str("right", delimOverride.Right).
-				Msg("override delimiters must not be empty")
+		return true, nil
+	}
+
+	data, err := io.ReadAll(r)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	// Front matter and the when directive are evaluated by guards, they are
+	// removed from the output and error line numbers are shifted to match
+	// the source.
+	lineOffset := 0
+	switch {
+	case fm != nil:
+		data = data[fm.size:]
+		lineOffset = fm.lines
+
+		if m, ok := fm.FileMode(); ok {
+			mode = m
 		}
 
-		delimLeft = delimOverride.Left
-		delimRight = delimOverride.Right
+		if !fm.Renders() {
+			_ = f.Close()
+			return write(data)
+		}
+	default:
+		if _, n, ok := parseWhenDirective(data, delimLeft, delimRight); ok {
+			data = data[n:]
+			lineOffset = 1
+		}
 	}
 
-	tmpl, err := eng.Factory(f, engine.WithDelims(delimLeft, delimRight))
+	templateError := func(err error) error {
+		terr := apperrors.WrapTemplateError(err, pf.sourcePath).WithDelimiters(delimLeft, delimRight)
+		if terr.LineNumber > 0 {
+			terr.LineNumber += lineOffset
+		}
+		return addFileContextToError(terr, args.ReadFS, pf.sourcePath)
+	}
+
+	ws, err := fileWhitespace(args, pf.sourcePath)
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	tmpl, err := eng.Factory(
+		bytes.NewReader(data),
+		engine.WithDelims(delimLeft, delimRight),
+		engine.WithTrimBlocks(ws.trimBlocks),
+		engine.WithLStripBlocks(ws.lstripBlocks),
+	)
 	if err != nil {
 		_ = f.Close()
 
 		if errors.Is(err, engine.ErrTemplateIsEmpty) {
-			return nil
+			drop, err := dropEmpty()
+			if err != nil || drop {
+				return err
+			}
+
+			return write(nil)
 		}
 
-		terr := apperrors.WrapTemplateError(err, pf.sourcePath).WithDelimiters(delimLeft, delimRight)
-		terr = addFileContextToError(terr, args.ReadFS, pf.sourcePath)
-		return terr
+		return templateError(err)
 	}
 
 	buff := bytes.NewBuffer(nil)
@@ -350,30 +903,37 @@ func processFile(eng *engine.Engine, args *RWFSArgs, pf processFileArgs) error {
 	err = tmpl.Execute(buff, pf.vars)
 	if err != nil {
 		_ = f.Close()
-		terr := apperrors.WrapTemplateError(err, pf.sourcePath).WithDelimiters(delimLeft, delimRight)
-		terr = addFileContextToError(terr, args.ReadFS, pf.sourcePath)
-		return terr
+		return templateError(err)
 	}
 
-	if buff.Len() == 0 {
-		_ = f.Close()
-		return nil
+	if len(strings.TrimSpace(buff.String())) == 0 {
+		drop, err := dropEmpty()
+		if err != nil || drop {
+			_ = f.Close()
+			return err
+		}
 	}
 
-	if len(strings.TrimSpace(buff.String())) == 0 {
-		_ = f.Close()
-		return nil
+	out := buff.Bytes()
+	if ws.collapseBlankLines {
+		out = collapseBlankLines(out)
 	}
 
-	err = args.WriteFS.MkdirAll(filepath.Dir(pf.outpath), os.ModePerm)
+	out, err = formatOutput(args, pf.sourcePath, out)
 	if err != nil {
-		if !os.IsExist(err) {
+		_ = f.Close()
+		return err
+	}
+
+	if fm != nil {
+		out, err = mergeOutput(args, fm.Merge, pf.outpath, out)
+		if err != nil {
 			_ = f.Close()
 			return err
 		}
 	}
 
-	err = args.WriteFS.WriteFile(pf.outpath, buff.Bytes(), os.ModePerm)
+	err = write(out)
 	if err != nil {
 		_ = f.Close()
 		return err
@@ -382,51 +942,64 @@ func processFile(eng *engine.Engine, args *RWFSArgs, pf processFileArgs) error {
 	return f.Close()
 }
 
-// expandEachDir walks a source directory tree and calls processFile for each
-// non-directory entry, replacing [token] in paths with the replacement value.
-func expandEachDir(eng *engine.Engine, args *RWFSArgs, guards []filepathGuard, sourceDirPath string, token string, replacement string, vars engine.Vars) error {
-	return fs.WalkDir(args.ReadFS, sourceDirPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
+// writeOutput creates the parent directories of outpath and writes data.
+func writeOutput(wfs rwfs.WriteFS, outpath string, data []byte, mode fs.FileMode) error {
+	err := wfs.MkdirAll(filepath.Dir(outpath), os.ModePerm)
+	if err != nil {
+		if !os.IsExist(err) {
 			return err
 		}
-		if d.IsDir() {
-			return nil
-		}
+	}
 
-		outpath := strings.ReplaceAll(path, token, replacement)
+	return writeFile(wfs, outpath, data, mode)
+}
 
-		for i, guard := range guards {
-			outpath, err = guard(outpath, d)
-			if err != nil {
-				if errors.Is(err, errSkipRender) || errors.Is(err, errSkipWrite) {
-					return nil
-				}
-				log.Debug().Err(err).Str("outpath", outpath).Int("guard", i).Msg("guard failed")
-				return err
+// applyGuards runs outpath through guards. ok is false when a guard skipped
+// the path.
+func applyGuards(guards []filepathGuard, sourcePath, outpath string, d fs.DirEntry) (newOutpath string, ok bool, err error) {
+	for i, guard := range guards {
+		outpath, err = guard(sourcePath, outpath, d)
+		if err != nil {
+			if errors.Is(err, errSkipRender) || errors.Is(err, errSkipWrite) {
+				return "", false, nil
 			}
-		}
 
-		if args.Project.NameTemplate == TemplateDirName {
-			outpath = strings.TrimPrefix(outpath, TemplateDirName+"/")
+			log.Debug().Err(err).Str("outpath", outpath).Int("guard", i).Msg("guard failed")
+			return "", false, err
 		}
 
-		return processFile(eng, args, processFileArgs{
-			sourcePath: path,
-			outpath:    outpath,
-			d:          d,
-			vars:       vars,
-		})
-	})
+		log.Debug().Str("outpath", outpath).Int("guard", i).Msg("guard")
+	}
+
+	return outpath, true, nil
 }
 
-// makeEachVars creates a copy of vars with .Each set for the current iteration item.
-func makeEachVars(vars engine.Vars, item string, index int) engine.Vars {
-	v := maps.Clone(vars)
-	v["Each"] = map[string]any{
-		"Item":  item,
-		"Index": index,
+// renderEmptyDir creates the empty source directory at path in the output when
+// it is kept by the empty settings. outpath is the path before guards are
+// applied.
+func renderEmptyDir(args *RWFSArgs, guards []filepathGuard, path, outpath string, d fs.DirEntry) error {
+	keep, err := keepEmpty(args, path)
+	if err != nil || !keep {
+		return err
 	}
-	return v
+
+	outpath, ok, err := applyGuards(guards, path, outpath, d)
+	if err != nil || !ok {
+		return err
+	}
+
+	if args.Project.NameTemplate == TemplateDirName {
+		outpath = strings.TrimPrefix(outpath, TemplateDirName+"/")
+	}
+
+	log.Debug().Str("path", outpath).Msg("creating empty directory")
+
+	err = args.WriteFS.MkdirAll(outpath, os.ModePerm)
+	if err != nil && !os.IsExist(err) {
+		return err
+	}
+
+	return nil
 }
 
 // RenderRWFS renders a rwfs.RFS to a rwfs.WriteFS by compiling all files in the rwfs.ReadFS
@@ -438,6 +1011,8 @@ func RenderRWFS(eng *engine.Engine, args *RWFSArgs, vars engine.Vars) error {
 	rewriteGuard := guardRewrite(args)
 	renderPathGuard := guardRenderPath(eng, vars)
 	noClobberGuard := guardNoClobber(args)
+	overwriteGuard := guardOverwrite(args)
+	featureFlagGuard := guardFeatureFlag(eng, args, vars)
 
 	pathGuards := []filepathGuard{
 		rewriteGuard,
@@ -449,9 +1024,11 @@ func RenderRWFS(eng *engine.Engine, args *RWFSArgs, vars engine.Vars) error {
 	guards := []filepathGuard{
 		rewriteGuard,
 		renderPathGuard,
-		noClobberGuard,
+		guardFrontMatter(eng, args, vars),
+		overwriteGuard,
 		guardDirectories(args),
-		guardFeatureFlag(eng, args, vars),
+		featureFlagGuard,
+		guardWhenDirective(eng, args, vars),
 	}
 
 	_, err := args.ReadFS.Open(PartialsDir)
@@ -467,142 +1044,93 @@ func RenderRWFS(eng *engine.Engine, args *RWFSArgs, vars engine.Vars) error {
 		}
 	}
 
-	// Build a set of each-declared variable names for fast lookup.
 	eachConfigs := args.Project.Conf.Each
 
-	// Track directories that are being expanded so we skip their children
-	// during the normal walk (they're handled by expandEachDir).
-	expandedDirs := map[string]bool{}
+	// guardsFor returns the full guard chain for rendering with vars, used
+	// for each expansion where vars differ per iteration.
+	guardsFor := func(vars engine.Vars) []filepathGuard {
+		return []filepathGuard{
+			rewriteGuard,
+			guardRenderPath(eng, vars),
+			guardFrontMatter(eng, args, vars),
+			overwriteGuard,
+			guardDirectories(args),
+			guardFeatureFlag(eng, args, vars),
+			guardWhenDirective(eng, args, vars),
+		}
+	}
 
 	err = fs.WalkDir(args.ReadFS, args.Project.NameTemplate, func(path string, d fs.DirEntry, err error) error {
 		if err != nil {
 			return err
 		}
 
-		// If this path is under an already-expanded directory, skip it.
-		for dir := range expandedDirs {
-			if strings.HasPrefix(path, dir+"/") || path == dir {
-				return nil
+		// Detect declared [var] patterns in the path. Files are expanded
+		// individually, over every token in their path, so directories are
+		// only handled when they are empty.
+		var tokens []eachToken
+		for _, tok := range detectEachPatterns(path) {
+			if _, ok := isEachVar(eachConfigs, tok.Var); ok {
+				tokens = append(tokens, tok)
 			}
 		}
 
-		// Detect [var] pattern in the path
-		varName, token := detectEachPattern(path)
-		if varName != "" {
-			if ec, ok := isEachVar(eachConfigs, varName); ok {
-				items, err := resolveListVar(vars, varName)
-				if err != nil {
-					return err
-				}
-
-				if len(items) == 0 {
-					log.Warn().Str("var", varName).Msg("each variable is empty, no files generated")
-					if d.IsDir() {
-						return fs.SkipDir
-					}
-					return nil
-				}
+		if len(tokens) > 0 {
+			emptyDir := d.IsDir() && isEmptyDir(args.ReadFS, path)
+			if d.IsDir() && !emptyDir {
+				return nil
+			}
 
-				if d.IsDir() {
-					expandedDirs[path] = true
-
-					for i, item := range items {
-						iterVars := makeEachVars(vars, item, i)
-
-						replacement := item
-						if ec.As != "" {
-							replacement, err = eng.TmplString(ec.As, iterVars)
-							if err != nil {
-								return fmt.Errorf("each: rendering 'as' template for %q: %w", varName, err)
-							}
-							if replacement == "" {
-								return fmt.Errorf("each: 'as' template for %q rendered empty string", varName)
-							}
-							if strings.Contains(replacement, "/") {
-								return fmt.Errorf("each: 'as' template for %q rendered value containing path separator: %q", varName, replacement)
-							}
-						}
+			iterations, err := expandEach(eng, eachConfigs, tokens, vars)
+			if err != nil {
+				return err
+			}
 
-						// Need per-iteration guards that use iterVars for path rendering
-						iterRenderPathGuard := guardRenderPath(eng, iterVars)
-						iterGuards := []filepathGuard{
-							rewriteGuard,
-							iterRenderPathGuard,
-							noClobberGuard,
-							guardDirectories(args),
-							guardFeatureFlag(eng, args, iterVars),
-						}
+			for _, it := range iterations {
+				outpath := strings.NewReplacer(it.pairs...).Replace(path)
 
-						if err := expandEachDir(eng, args, iterGuards, path, token, replacement, iterVars); err != nil {
-							return err
-						}
+				if emptyDir {
+					dirGuards := []filepathGuard{rewriteGuard, guardRenderPath(eng, it.vars), guardFeatureFlag(eng, args, it.vars)}
+					err = renderEmptyDir(args, dirGuards, path, outpath, d)
+					if err != nil {
+						return err
 					}
-
-					return fs.SkipDir
+					continue
 				}
 
-				// File-level expansion
-				for i, item := range items {
-					iterVars := makeEachVars(vars, item, i)
-
-					replacement := item
-					if ec.As != "" {
-						replacement, err = eng.TmplString(ec.As, iterVars)
-						if err != nil {
-							return fmt.Errorf("each: rendering 'as' template for %q: %w", varName, err)
-						}
-						if replacement == "" {
-							return fmt.Errorf("each: 'as' template for %q rendered empty string", varName)
-						}
-						if strings.Contains(replacement, "/") {
-							return fmt.Errorf("each: 'as' template for %q rendered value containing path separator: %q", varName, replacement)
-						}
-					}
-
-					outpath := strings.ReplaceAll(path, token, replacement)
-
-					iterRenderPathGuard := guardRenderPath(eng, iterVars)
-					iterGuards := []filepathGuard{
-						rewriteGuard,
-						iterRenderPathGuard,
-						noClobberGuard,
-						guardDirectories(args),
-						guardFeatureFlag(eng, args, iterVars),
-					}
-
-					for gi, guard := range iterGuards {
-						outpath, err = guard(outpath, d)
-						if err != nil {
-							if errors.Is(err, errSkipRender) || errors.Is(err, errSkipWrite) {
-								goto nextFileItem
-							}
-							log.Debug().Err(err).Str("outpath", outpath).Int("guard", gi).Msg("guard failed")
-							return err
-						}
-					}
-
-					if args.Project.NameTemplate == TemplateDirName {
-						outpath = strings.TrimPrefix(outpath, TemplateDirName+"/")
-					}
+				outpath, ok, err := applyGuards(guardsFor(it.vars), path, outpath, d)
+				if err != nil {
+					return err
+				}
 
-					if err := processFile(eng, args, processFileArgs{
-						sourcePath: path,
-						outpath:    outpath,
-						d:          d,
-						vars:       iterVars,
-					}); err != nil {
-						return err
-					}
+				if !ok {
+					continue
+				}
 
-				nextFileItem:
+				if args.Project.NameTemplate == TemplateDirName {
+					outpath = strings.TrimPrefix(outpath, TemplateDirName+"/")
 				}
 
-				return nil
+				err = processFile(eng, args, processFileArgs{
+					sourcePath: path,
+					outpath:    outpath,
+					d:          d,
+					vars:       it.vars,
+				})
+				if err != nil {
+					return err
+				}
 			}
+
+			return nil
 		}
 
 		// --- Normal (non-expanded) path ---
 
+		if d.IsDir() && path != args.Project.NameTemplate && isEmptyDir(args.ReadFS, path) {
+			return renderEmptyDir(args, []filepathGuard{rewriteGuard, renderPathGuard, featureFlagGuard}, path, path, d)
+		}
+
 		if args.Project.Conf != nil && len(args.Project.Conf.Skip) > 0 {
 			relativePath := strings.TrimPrefix(path, args.Project.NameTemplate+"/")
 
@@ -617,16 +1145,11 @@ func RenderRWFS(eng *engine.Engine, args *RWFSArgs, vars engine.Vars) error {
 					continue
 				}
 
-				rf, err := args.ReadFS.Open(path)
-				if err != nil {
-					return err
-				}
-
 				outpath := path
 				for _, guard := range pathGuards {
-					outpath, err = guard(outpath, d)
+					outpath, err = guard(path, outpath, d)
 					if err != nil {
-						if errors.Is(err, errFileExists) {
+						if errors.Is(err, errFileExists) || errors.Is(err, errSkipRender) {
 							return nil
 						}
 						return err
@@ -637,39 +1160,37 @@ func RenderRWFS(eng *engine.Engine, args *RWFSArgs, vars engine.Vars) error {
 					outpath = strings.TrimPrefix(outpath, TemplateDirName+"/")
 				}
 
-				err = args.WriteFS.MkdirAll(filepath.Dir(outpath), os.ModePerm)
-				if err != nil {
+				// Skipped files are copied verbatim and never have front
+				// matter.
+				outpath, _, ok, err := args.resolveConflict(conflictAction(args.Project.Options, nil), outpath, nil, true)
+				if err != nil || !ok {
 					return err
 				}
 
-				bits, err := io.ReadAll(rf)
+				if isSymlink(d) {
+					if rl, ok := args.ReadFS.(readLinkFS); ok {
+						return writeSymlink(eng, args.WriteFS, rl, path, outpath, nil)
+					}
+				}
+
+				rf, err := args.ReadFS.Open(path)
 				if err != nil {
 					return err
 				}
 
-				err = args.WriteFS.WriteFile(outpath, bits, os.ModePerm)
+				err = copyVerbatim(args.WriteFS, outpath, rf, d, fileMode(args.ReadFS, path))
 				if err != nil {
+					_ = rf.Close()
 					return err
 				}
 
-				return nil
+				return rf.Close()
 			}
 		}
 
-		outpath := path
-
-		for i, guard := range guards {
-			outpath, err = guard(outpath, d)
-			if err != nil {
-				if errors.Is(err, errSkipRender) || errors.Is(err, errSkipWrite) {
-					return nil
-				}
-
-				log.Debug().Err(err).Str("outpath", outpath).Int("guard", i).Msg("guard failed")
-				return err
-			}
-
-			log.Debug().Str("outpath", outpath).Int("guard", i).Msg("guard")
+		outpath, ok, err := applyGuards(guards, path, path, d)
+		if err != nil || !ok {
+			return err
 		}
 
 		if args.Project.NameTemplate == TemplateDirName {
@@ -709,12 +1230,14 @@ func RenderRWFS(eng *engine.Engine, args *RWFSArgs, vars engine.Vars) error {
 			continue
 		}
 
+		mode := fileMode(args.WriteFS, path)
+
 		outbytes, err := Inject(f, out, injection.At, injection.Mode)
 		if err != nil {
 			return err
 		}
 
-		err = args.WriteFS.WriteFile(path, outbytes, os.ModePerm)
+		err = writeFile(args.WriteFS, path, outbytes, mode)
 		if err != nil {
 			return err
 		}
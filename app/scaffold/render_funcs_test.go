@@ -1,9 +1,15 @@
 package scaffold
 
 import (
+	"io/fs"
+	"os"
+	"path/filepath"
 	"testing"
+	"testing/fstest"
 
 	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/fsast"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"gopkg.in/yaml.v3"
@@ -81,26 +87,24 @@ func Test_BuildVars(t *testing.T) {
 	assert.Equal(t, "Computed: value", computed["UsesVars"])
 }
 
-func Test_detectEachPattern(t *testing.T) {
+func Test_detectEachPatterns(t *testing.T) {
 	tests := []struct {
-		path      string
-		wantVar   string
-		wantToken string
+		path string
+		want []eachToken
 	}{
-		{"{{ .Project }}/[services]/handler.go", "services", "[services]"},
-		{"{{ .Project }}/[models].go", "models", "[models]"},
-		{"{{ .Project }}/normal/file.go", "", ""},
-		{"[items].txt", "items", "[items]"},
-		{"[_private]/file.go", "_private", "[_private]"},
-		{"path/[var1]/[var2]/file.go", "var1", "[var1]"},
-		{"no-brackets/here.txt", "", ""},
+		{"{{ .Project }}/[services]/handler.go", []eachToken{{"services", "[services]"}}},
+		{"{{ .Project }}/[models].go", []eachToken{{"models", "[models]"}}},
+		{"{{ .Project }}/normal/file.go", nil},
+		{"[items].txt", []eachToken{{"items", "[items]"}}},
+		{"[_private]/file.go", []eachToken{{"_private", "[_private]"}}},
+		{"path/[var1]/[var2]/file.go", []eachToken{{"var1", "[var1]"}, {"var2", "[var2]"}}},
+		{"[a]/[b]/[a]-[b].go", []eachToken{{"a", "[a]"}, {"b", "[b]"}}},
+		{"no-brackets/here.txt", nil},
 	}
 
 	for _, tt := range tests {
 		t.Run(tt.path, func(t *testing.T) {
-			gotVar, gotToken := detectEachPattern(tt.path)
-			assert.Equal(t, tt.wantVar, gotVar)
-			assert.Equal(t, tt.wantToken, gotToken)
+			assert.Equal(t, tt.want, detectEachPatterns(tt.path))
 		})
 	}
 }
@@ -110,7 +114,7 @@ func Test_resolveListVar(t *testing.T) {
 		name    string
 		vars    engine.Vars
 		varName string
-		want    []string
+		want    []any
 		wantErr bool
 	}{
 		{
@@ -121,7 +125,7 @@ func Test_resolveListVar(t *testing.T) {
 				},
 			},
 			varName: "services",
-			want:    []string{"auth", "users"},
+			want:    []any{"auth", "users"},
 		},
 		{
 			name: "any slice of strings",
@@ -131,7 +135,27 @@ func Test_resolveListVar(t *testing.T) {
 				},
 			},
 			varName: "items",
-			want:    []string{"foo", "bar"},
+			want:    []any{"foo", "bar"},
+		},
+		{
+			name: "any slice of objects",
+			vars: engine.Vars{
+				"Scaffold": engine.Vars{
+					"services": []any{map[string]any{"name": "auth", "port": 8080}},
+				},
+			},
+			varName: "services",
+			want:    []any{map[string]any{"name": "auth", "port": 8080}},
+		},
+		{
+			name: "any slice of unsupported items",
+			vars: engine.Vars{
+				"Scaffold": engine.Vars{
+					"items": []any{1, 2},
+				},
+			},
+			varName: "items",
+			wantErr: true,
 		},
 		{
 			name: "plain string wraps to single-element slice",
@@ -141,7 +165,7 @@ func Test_resolveListVar(t *testing.T) {
 				},
 			},
 			varName: "name",
-			want:    []string{"single"},
+			want:    []any{"single"},
 		},
 		{
 			name: "missing variable",
@@ -171,7 +195,7 @@ func Test_resolveListVar(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := resolveListVar(tt.vars, tt.varName)
+			got, err := resolveListVar(tt.vars, nil, tt.varName)
 			if tt.wantErr {
 				require.Error(t, err)
 				return
@@ -241,3 +265,393 @@ func Test_isEachVar(t *testing.T) {
 	_, ok = isEachVar(configs, "notfound")
 	assert.False(t, ok)
 }
+
+func Test_RenderRWFS_Binary(t *testing.T) {
+	png := []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR{{ .Project }}")
+	large := []byte("{{ not a template\n")
+	for len(large) < 4096 {
+		large = append(large, large...)
+	}
+
+	readFS := fstest.MapFS{
+		"templates/logo.png":      {Data: png},
+		"templates/README.md":     {Data: []byte("# {{ .Project }}\n")},
+		"templates/BMP.md":        {Data: []byte("BM is the magic number of {{ .Project }} bitmaps\n")},
+		"templates/raw/large.txt": {Data: large},
+	}
+
+	// Lower the threshold so the skipped file is streamed.
+	defer func(v int64) { streamThreshold = v }(streamThreshold)
+	streamThreshold = 1024
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         "demo",
+		Conf: &ProjectScaffoldFile{
+			Skip: []string{"raw/*"},
+		},
+	}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	got, err := fs.ReadFile(memFS, "logo.png")
+	require.NoError(t, err)
+	assert.Equal(t, png, got, "binary files are copied byte-for-byte")
+
+	got, err = fs.ReadFile(memFS, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "# demo\n", string(got))
+
+	got, err = fs.ReadFile(memFS, "BMP.md")
+	require.NoError(t, err)
+	assert.Equal(t, "BM is the magic number of demo bitmaps\n", string(got), "text with a binary magic number is rendered")
+
+	got, err = fs.ReadFile(memFS, "raw/large.txt")
+	require.NoError(t, err)
+	assert.Equal(t, large, got)
+}
+
+// linkFS adds ReadLink support to a fstest.MapFS, entries with
+// fs.ModeSymlink store the link target as their data.
+type linkFS struct {
+	fstest.MapFS
+}
+
+func (l linkFS) ReadLink(name string) (string, error) {
+	f, ok := l.MapFS[name]
+	if !ok || f.Mode&fs.ModeSymlink == 0 {
+		return "", &fs.PathError{Op: "readlink", Path: name, Err: fs.ErrInvalid}
+	}
+
+	return string(f.Data), nil
+}
+
+func Test_RenderRWFS_ModesAndSymlinks(t *testing.T) {
+	readFS := linkFS{fstest.MapFS{
+		"templates/run.sh":      {Data: []byte("#!/bin/sh\necho {{ .Project }}\n"), Mode: 0o755},
+		"templates/config.yaml": {Data: []byte("name: {{ .Project }}\n"), Mode: 0o644},
+		"templates/readonly.md": {Data: []byte("# {{ .Project }}\n"), Mode: 0o444},
+		"templates/unknown.txt": {Data: []byte("{{ .Project }}\n")},
+		"templates/current":     {Data: []byte("releases/{{ .Project }}"), Mode: fs.ModeSymlink | 0o777},
+		"templates/raw/link":    {Data: []byte("{{ .Project }}"), Mode: fs.ModeSymlink | 0o777},
+	}}
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         "demo",
+		Conf: &ProjectScaffoldFile{
+			Skip: []string{"raw/*"},
+		},
+	}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	modes := map[string]fs.FileMode{
+		"run.sh":      0o755,
+		"config.yaml": 0o644,
+		"readonly.md": 0o644,
+		"unknown.txt": 0o644,
+	}
+
+	for name, want := range modes {
+		info, err := fs.Stat(memFS, name)
+		require.NoError(t, err)
+		assert.Equal(t, want, info.Mode().Perm(), name)
+	}
+
+	target, err := memFS.ReadLink("current")
+	require.NoError(t, err)
+	assert.Equal(t, "releases/demo", target, "link targets are rendered")
+
+	target, err = memFS.ReadLink("raw/link")
+	require.NoError(t, err)
+	assert.Equal(t, "{{ .Project }}", target, "skipped link targets are not rendered")
+}
+
+func Test_RenderRWFS_SymlinksOnDisk(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(dir, "templates", "releases"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "templates", "README.md"), []byte("# {{ .Project }}\n"), 0o644))
+	require.NoError(t, os.Symlink("releases/{{ .Project }}", filepath.Join(dir, "templates", "current")))
+
+	project := &Project{NameTemplate: TemplateDirName, Name: "demo", Conf: &ProjectScaffoldFile{}}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  rwfs.NewOsRFS(dir),
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	target, err := memFS.ReadLink("current")
+	require.NoError(t, err)
+	assert.Equal(t, "releases/demo", target)
+
+	// Links written to memory are listed like any other file.
+	entries, err := fs.ReadDir(memFS, ".")
+	require.NoError(t, err)
+
+	types := map[string]fs.FileMode{}
+	for _, e := range entries {
+		types[e.Name()] = e.Type()
+	}
+	assert.Equal(t, map[string]fs.FileMode{"README.md": 0, "current": fs.ModeSymlink}, types)
+
+	ast, err := fsast.New(memFS)
+	require.NoError(t, err)
+	assert.Contains(t, ast.String(), "current:  (type=symlink)\n\t-> releases/demo\n")
+}
+
+func Test_RenderRWFS_Empty(t *testing.T) {
+	readFS := fstest.MapFS{
+		"templates/pkg/__init__.py":  {Data: []byte("")},
+		"templates/pkg/py.typed":     {Data: []byte("{{ if false }}x{{ end }}\n")},
+		"templates/.gitkeep":         {Data: []byte("")},
+		"templates/optional.txt":     {Data: []byte("{{ if false }}content{{ end }}")},
+		"templates/main.py":          {Data: []byte("print('{{ .Project }}')\n")},
+		"templates/data/cache":       {Mode: fs.ModeDir},
+		"templates/data/other/empty": {Mode: fs.ModeDir},
+	}
+
+	tests := []struct {
+		name      string
+		empty     EmptyMode
+		keepEmpty []string
+		want      []string
+		dropped   []string
+	}{
+		{
+			name:    "default drops empty files",
+			want:    []string{"main.py"},
+			dropped: []string{".gitkeep", "optional.txt", "pkg/__init__.py", "pkg/py.typed"},
+		},
+		{
+			name:      "keep_empty globs",
+			keepEmpty: []string{"**/__init__.py", "**/py.typed", "data/cache"},
+			want:      []string{"main.py", "pkg/__init__.py", "pkg/py.typed", "data/cache"},
+			dropped:   []string{".gitkeep", "optional.txt"},
+		},
+		{
+			name:  "empty keep",
+			empty: EmptyKeep,
+			want:  []string{"main.py", "pkg/__init__.py", "pkg/py.typed", ".gitkeep", "optional.txt", "data/cache", "data/other/empty"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			project := &Project{
+				NameTemplate: TemplateDirName,
+				Name:         "demo",
+				Conf: &ProjectScaffoldFile{
+					Empty:     tt.empty,
+					KeepEmpty: tt.keepEmpty,
+				},
+			}
+
+			vars, err := BuildVars(tEngine, project, engine.Vars{})
+			require.NoError(t, err)
+
+			memFS := rwfs.NewMemoryWFS()
+			report := &Report{}
+			err = RenderRWFS(tEngine, &RWFSArgs{
+				ReadFS:  readFS,
+				WriteFS: memFS,
+				Project: project,
+				Report:  report,
+			}, vars)
+			require.NoError(t, err)
+
+			got := []string{}
+			err = fs.WalkDir(memFS, ".", func(path string, d fs.DirEntry, err error) error {
+				if err != nil {
+					return err
+				}
+
+				if !d.IsDir() || isEmptyDir(memFS, path) {
+					got = append(got, path)
+				}
+				return nil
+			})
+			require.NoError(t, err)
+
+			assert.ElementsMatch(t, tt.want, got)
+			assert.ElementsMatch(t, tt.dropped, report.DroppedEmpty)
+		})
+	}
+}
+
+func Test_resolveListVar_Parent(t *testing.T) {
+	vars := engine.Vars{
+		"Scaffold": engine.Vars{
+			"handlers": []string{"top"},
+		},
+	}
+
+	parent := makeEachContext(map[string]any{
+		"name":     "auth",
+		"handlers": []any{"login", "logout"},
+	}, 0, nil)
+
+	got, err := resolveListVar(vars, parent, "handlers")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"login", "logout"}, got, "fields of the parent item take precedence")
+
+	got, err = resolveListVar(vars, makeEachContext("auth", 0, nil), "handlers")
+	require.NoError(t, err)
+	assert.Equal(t, []any{"top"}, got)
+}
+
+func Test_RenderRWFS_EachNested(t *testing.T) {
+	readFS := fstest.MapFS{
+		"{{ .Project }}/[services]/[handlers].go": {Data: []byte(
+			"package {{ .Each.Parent.Item.name }} // port {{ .Each.Parent.Item.port }}\n" +
+				"// {{ .Each.Item }} {{ .Each.Parent.Index }}.{{ .Each.Index }}\n",
+		)},
+		"{{ .Project }}/[envs]/[regions].txt": {Data: []byte("{{ .Each.Parent.Item }}-{{ .Each.Item }}\n")},
+		"{{ .Project }}/[services]/README.md": {Data: []byte("# {{ .Each.Item.name }}\n")},
+	}
+
+	project := &Project{
+		NameTemplate: "{{ .Project }}",
+		Name:         "demo",
+		Conf: &ProjectScaffoldFile{
+			Each: []EachConfig{
+				{Var: "services"},
+				{Var: "handlers", As: "{{ .Each.Item | toPascalCase }}"},
+				{Var: "envs"},
+				{Var: "regions"},
+			},
+		},
+	}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{
+		"services": []any{
+			map[string]any{"name": "auth", "port": 8080, "handlers": []any{"login", "logout"}},
+			map[string]any{"name": "users", "port": 8081, "handlers": []any{"list_users"}},
+		},
+		"envs":    []string{"dev", "prod"},
+		"regions": []string{"eu", "us"},
+	})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	want := map[string]string{
+		"demo/auth/Login.go":      "package auth // port 8080\n// login 0.0\n",
+		"demo/auth/Logout.go":     "package auth // port 8080\n// logout 0.1\n",
+		"demo/users/ListUsers.go": "package users // port 8081\n// list_users 1.0\n",
+		"demo/auth/README.md":     "# auth\n",
+		"demo/users/README.md":    "# users\n",
+		"demo/dev/eu.txt":         "dev-eu\n",
+		"demo/dev/us.txt":         "dev-us\n",
+		"demo/prod/eu.txt":        "prod-eu\n",
+		"demo/prod/us.txt":        "prod-us\n",
+	}
+
+	got := map[string]string{}
+	err = fs.WalkDir(memFS, ".", func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+
+		data, err := fs.ReadFile(memFS, path)
+		got[path] = string(data)
+		return err
+	})
+	require.NoError(t, err)
+	assert.Equal(t, want, got)
+}
+
+func Test_eachReplacement(t *testing.T) {
+	vars := engine.Vars{}
+
+	got, err := eachReplacement(tEngine, EachConfig{Var: "svc"}, map[string]any{"name": "auth"}, vars)
+	require.NoError(t, err)
+	assert.Equal(t, "auth", got)
+
+	_, err = eachReplacement(tEngine, EachConfig{Var: "svc"}, map[string]any{"port": 1}, vars)
+	require.Error(t, err, "objects without a name key require 'as'")
+
+	_, err = eachReplacement(tEngine, EachConfig{Var: "svc"}, "a/b", vars)
+	require.Error(t, err)
+}
+
+func Test_RenderRWFS_Format(t *testing.T) {
+	readFS := fstest.MapFS{
+		"templates/main.go":       {Data: []byte("package main\n\nimport (\n\t\"github.com/acme/{{ .Project }}\"\n\t\"fmt\"\n)\n\n\n{{ if true }}\nfunc main()  { fmt.Println({{ .Project }}.Name) }\n{{ end }}\n")},
+		"templates/package.json":  {Data: []byte("{\"name\": \"{{ .Project }}\",\n{{- if true }}\n      \"private\": true{{ end }}}")},
+		"templates/raw/skip.json": {Data: []byte("{ \"a\":1 }")},
+	}
+
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         "demo",
+		Conf: &ProjectScaffoldFile{
+			Skip: []string{"raw/*"},
+			Format: []Format{
+				{Glob: "**/*.go", Formatter: "go"},
+				{Glob: "**/*.json", Formatter: "json"},
+			},
+		},
+	}
+
+	vars, err := BuildVars(tEngine, project, engine.Vars{})
+	require.NoError(t, err)
+
+	memFS := rwfs.NewMemoryWFS()
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, vars)
+	require.NoError(t, err)
+
+	got, err := fs.ReadFile(memFS, "main.go")
+	require.NoError(t, err)
+	assert.Equal(t, "package main\n\nimport (\n\t\"fmt\"\n\n\t\"github.com/acme/demo\"\n)\n\nfunc main() { fmt.Println(demo.Name) }\n", string(got))
+
+	got, err = fs.ReadFile(memFS, "package.json")
+	require.NoError(t, err)
+	assert.Equal(t, "{\n  \"name\": \"demo\",\n  \"private\": true\n}\n", string(got))
+
+	got, err = fs.ReadFile(memFS, "raw/skip.json")
+	require.NoError(t, err)
+	assert.Equal(t, "{ \"a\":1 }", string(got), "skipped files are not formatted")
+
+	readFS["templates/broken.go"] = &fstest.MapFile{Data: []byte("package main\n\nfunc {{ .Project }}( {\n")}
+	err = RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: rwfs.NewMemoryWFS(),
+		Project: project,
+	}, vars)
+	require.ErrorContains(t, err, "templates/broken.go: go formatter")
+}
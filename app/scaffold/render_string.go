@@ -0,0 +1,31 @@
+package scaffold
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/hay-kot/scaffold/app/core/apperrors"
+	"github.com/hay-kot/scaffold/app/core/engine"
+)
+
+// RenderString renders the template tmpl with vars. name identifies the
+// template in errors, which are returned as an *apperrors.TemplateError with
+// the lines around the error.
+func RenderString(eng *engine.Engine, name, tmpl string, vars engine.Vars) (string, error) {
+	t, err := eng.Factory(strings.NewReader(tmpl))
+	if err != nil {
+		if errors.Is(err, engine.ErrTemplateIsEmpty) {
+			return "", nil
+		}
+
+		return "", addContextToError(apperrors.WrapTemplateError(err, name), tmpl)
+	}
+
+	out := &strings.Builder{}
+	err = eng.Render(out, t, vars)
+	if err != nil {
+		return "", addContextToError(apperrors.WrapTemplateError(err, name), tmpl)
+	}
+
+	return out.String(), nil
+}
@@ -0,0 +1,55 @@
+package scaffold
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/hay-kot/scaffold/app/core/apperrors"
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderString(t *testing.T) {
+	eng := engine.New()
+
+	p := &Project{
+		Name: "My App",
+		Conf: &ProjectScaffoldFile{
+			Computed: map[string]string{"shout": "{{ .Scaffold.name | toUpper }}"},
+		},
+	}
+
+	vars, err := BuildVars(eng, p, engine.Vars{"name": "api"})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		tmpl    string
+		want    string
+		wantErr int // line of the error, 0 for no error
+	}{
+		{name: "empty", tmpl: "", want: ""},
+		{name: "text", tmpl: "plain text", want: "plain text"},
+		{name: "vars", tmpl: "{{ .Scaffold.name }} {{ .ProjectKebab }} {{ .Computed.shout }}", want: "api my-app API"},
+		{name: "parse error", tmpl: "line one\n{{ .Scaffold.name | nope }}\nline three", wantErr: 2},
+		{name: "exec error", tmpl: "{{ index .Scaffold.name 10 }}", wantErr: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderString(eng, "<string>", tt.tmpl, vars)
+			if tt.wantErr == 0 {
+				require.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+				return
+			}
+
+			var terr *apperrors.TemplateError
+			require.True(t, errors.As(err, &terr), "expected a template error, got %v", err)
+			assert.Equal(t, "<string>", terr.FilePath)
+			assert.Equal(t, tt.wantErr, terr.LineNumber)
+			assert.NotEmpty(t, terr.Context)
+		})
+	}
+}
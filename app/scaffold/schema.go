@@ -0,0 +1,28 @@
+package scaffold
+
+import (
+	"fmt"
+
+	"github.com/hay-kot/scaffold/internal/jsonschema"
+)
+
+// SchemaURL is the URL the scaffold file schema of the latest release is
+// published at.
+const SchemaURL = "https://hay-kot.github.io/scaffold/schema.json"
+
+// JSONSchema returns the schema of the scaffold files read by the given
+// version of scaffold. It's generated from ProjectScaffoldFile, so keys only
+// read by newer releases are reported as unknown properties. Scaffolds using
+// them should set metadata.minimum_version.
+func JSONSchema(version string) (*jsonschema.Schema, error) {
+	s, err := jsonschema.Reflect(ProjectScaffoldFile{})
+	if err != nil {
+		return nil, err
+	}
+
+	s.ID = SchemaURL
+	s.Title = "Scaffold Configuration"
+	s.Description = "Configuration schema for scaffold.yaml files"
+	s.Comment = fmt.Sprintf("Generated by scaffold %s", version)
+	return s, nil
+}
@@ -0,0 +1,135 @@
+package scaffold
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONSchema(t *testing.T) {
+	// Fails when a field of the scaffold file has no description.
+	s, err := JSONSchema("v1.2.3")
+	require.NoError(t, err)
+	assert.Equal(t, SchemaURL, s.ID)
+	assert.Contains(t, s.Comment, "v1.2.3")
+
+	valid := `
+metadata:
+  minimum_version: "0.4.0"
+skip: ["*.md"]
+questions:
+  - name: name
+    prompt:
+      message: Project name
+    validate:
+      required: true
+      min: 1
+      match:
+        regex: ^[a-z]+$
+  - name: license
+    group: meta
+    when: "{{ .name }}"
+    prompt:
+      message: License
+      options: [MIT, Apache-2.0]
+      default: MIT
+  - name: docker
+    prompt:
+      confirm: Use docker?
+  - name: models
+    prompt:
+      message: Models
+      loop: true
+      fields:
+        - name: name
+          prompt:
+            message: Name
+rewrites:
+  - from: templates/a.go
+    to: "{{ .Project }}/a.go"
+computed:
+  snake: "{{ snakecase .Scaffold.name }}"
+messages:
+  pre: hello
+  post: bye
+inject:
+  - name: route
+    path: main.go
+    at: "// routes"
+    mode: after
+    template: "r()"
+features:
+  - value: "{{ .Scaffold.docker }}"
+    globs: ["Dockerfile"]
+presets:
+  default:
+    name: api
+    models: [{name: user}]
+delimiters:
+  - glob: "*.tmpl"
+    left: "[["
+    right: "]]"
+each:
+  - services
+  - var: models
+    as: "{{ .Each.Item.name }}"
+empty: keep
+keep_empty: [".gitkeep"]
+format:
+  - glob: "*.go"
+    formatter: go
+whitespace:
+  - trim_blocks: true
+checks:
+  - name: compiles
+    files: "**/*.go"
+    parse: go
+lint:
+  disable: [unused-question]
+`
+
+	errs, err := s.ValidateYAML([]byte(valid))
+	require.NoError(t, err)
+	assert.Empty(t, errs)
+
+	_, err = ReadScaffoldFile(strings.NewReader(valid))
+	require.NoError(t, err)
+
+	invalid := `
+questions:
+  - name: a
+    prompt:
+      options: [x]
+each:
+  - as: x
+checks:
+  - parse: toml
+lint:
+  disable: [nope]
+`
+
+	errs, err = s.ValidateYAML([]byte(invalid))
+	require.NoError(t, err)
+
+	got := make([]string, len(errs))
+	for i, e := range errs {
+		got[i] = e.Error()
+	}
+
+	assert.Equal(t, []string{
+		`5:7: questions[0].prompt: missing property "message" or missing property "confirm"`,
+		`7:5: each[0]: missing property "var"`,
+		"9:12: checks[0].parse: must be one of go, json, yaml",
+		"11:13: lint.disable[0]: must be one of " + strings.Join(lintRuleIDs(), ", "),
+	}, got)
+}
+
+func lintRuleIDs() []string {
+	ids := make([]string, len(LintRules))
+	for i, r := range LintRules {
+		ids[i] = r.ID
+	}
+	return ids
+}
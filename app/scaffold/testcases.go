@@ -0,0 +1,110 @@
+package scaffold
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// TestsDir is the directory in a scaffold holding test cases.
+	TestsDir = "tests"
+	// SnapshotsDir is the directory in TestsDir holding golden files.
+	SnapshotsDir = "snapshots"
+	// DefaultTestProject is the project name of test cases that don't set
+	// one.
+	DefaultTestProject = "scaffold-test"
+)
+
+// TestCase is an input for `scaffold test`. Every preset is a test case, and
+// so is every YAML file in the scaffold's tests directory.
+type TestCase struct {
+	Name string
+	// Vars are the values for the scaffold's questions.
+	Vars map[string]any
+	// Snapshot is the path of the golden file, relative to the scaffold.
+	Snapshot string
+}
+
+// testCaseFile is the format of a test case file.
+type testCaseFile struct {
+	// Preset is merged into Vars, with Vars taking precedence.
+	Preset string         `yaml:"preset"`
+	Vars   map[string]any `yaml:"vars"`
+}
+
+// LoadTestCases returns the test cases of the scaffold in fsys, sorted by
+// name.
+func LoadTestCases(fsys fs.FS, conf *ProjectScaffoldFile) ([]TestCase, error) {
+	cases := map[string]TestCase{}
+
+	add := func(name string, vars map[string]any) {
+		cases[name] = TestCase{
+			Name:     name,
+			Vars:     vars,
+			Snapshot: path.Join(TestsDir, SnapshotsDir, name+".snapshot"),
+		}
+	}
+
+	for name, vars := range conf.Presets {
+		add(name, MergeMaps(vars))
+	}
+
+	entries, err := fs.ReadDir(fsys, TestsDir)
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		ext := path.Ext(entry.Name())
+		if entry.IsDir() || (ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+
+		name := strings.TrimSuffix(entry.Name(), ext)
+		if _, ok := cases[name]; ok {
+			return nil, fmt.Errorf("%s/%s: test case %q is already defined by a preset", TestsDir, entry.Name(), name)
+		}
+
+		data, err := fs.ReadFile(fsys, path.Join(TestsDir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+
+		var tc testCaseFile
+		err = yaml.Unmarshal(data, &tc)
+		if err != nil {
+			return nil, fmt.Errorf("%s/%s: %w", TestsDir, entry.Name(), err)
+		}
+
+		vars := map[string]any{}
+		if tc.Preset != "" {
+			preset, ok := conf.Presets[tc.Preset]
+			if !ok {
+				return nil, fmt.Errorf("%s/%s: preset %q not found", TestsDir, entry.Name(), tc.Preset)
+			}
+			vars = MergeMaps(vars, preset)
+		}
+
+		add(name, MergeMaps(vars, tc.Vars))
+	}
+
+	out := make([]TestCase, 0, len(cases))
+	for _, tc := range cases {
+		if _, ok := tc.Vars["Project"]; !ok {
+			tc.Vars["Project"] = DefaultTestProject
+		}
+		out = append(out, tc)
+	}
+
+	sort.Slice(out, func(i, j int) bool {
+		return out[i].Name < out[j].Name
+	})
+
+	return out, nil
+}
@@ -0,0 +1,69 @@
+package scaffold
+
+import (
+	"testing"
+	"testing/fstest"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTestCases(t *testing.T) {
+	conf := &ProjectScaffoldFile{
+		Presets: map[string]map[string]any{
+			"default": {"docker": false, "db": "postgres"},
+		},
+	}
+
+	fsys := fstest.MapFS{
+		"tests/docker.yaml":               {Data: []byte("preset: default\nvars:\n  Project: app\n  docker: true\n")},
+		"tests/minimal.yml":               {Data: []byte("vars:\n  db: sqlite\n")},
+		"tests/README.md":                 {Data: []byte("# tests\n")},
+		"tests/snapshots/docker.snapshot": {Data: []byte("")},
+	}
+
+	got, err := LoadTestCases(fsys, conf)
+	require.NoError(t, err)
+
+	assert.Equal(t, []TestCase{
+		{
+			Name:     "default",
+			Vars:     map[string]any{"Project": DefaultTestProject, "docker": false, "db": "postgres"},
+			Snapshot: "tests/snapshots/default.snapshot",
+		},
+		{
+			Name:     "docker",
+			Vars:     map[string]any{"Project": "app", "docker": true, "db": "postgres"},
+			Snapshot: "tests/snapshots/docker.snapshot",
+		},
+		{
+			Name:     "minimal",
+			Vars:     map[string]any{"Project": DefaultTestProject, "db": "sqlite"},
+			Snapshot: "tests/snapshots/minimal.snapshot",
+		},
+	}, got)
+
+	assert.Equal(t, map[string]any{"docker": false, "db": "postgres"}, conf.Presets["default"], "presets are not modified")
+}
+
+func TestLoadTestCases_Errors(t *testing.T) {
+	conf := &ProjectScaffoldFile{
+		Presets: map[string]map[string]any{"default": {}},
+	}
+
+	tests := []struct {
+		name string
+		fsys fstest.MapFS
+	}{
+		{name: "duplicate preset", fsys: fstest.MapFS{"tests/default.yaml": {Data: []byte("vars: {}\n")}}},
+		{name: "unknown preset", fsys: fstest.MapFS{"tests/x.yaml": {Data: []byte("preset: missing\n")}}},
+		{name: "invalid yaml", fsys: fstest.MapFS{"tests/x.yaml": {Data: []byte("vars: [\n")}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := LoadTestCases(tt.fsys, conf)
+			require.Error(t, err)
+		})
+	}
+}
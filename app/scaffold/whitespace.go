@@ -0,0 +1,76 @@
+package scaffold
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/bmatcuk/doublestar/v4"
+)
+
+// whitespaceOptions are the effective whitespace settings of a file.
+type whitespaceOptions struct {
+	trimBlocks         bool
+	lstripBlocks       bool
+	collapseBlankLines bool
+}
+
+// fileWhitespace returns the whitespace settings for the source file at
+// sourcePath, applying every matching entry in order.
+func fileWhitespace(args *RWFSArgs, sourcePath string) (whitespaceOptions, error) {
+	var ws whitespaceOptions
+
+	relativePath := strings.TrimPrefix(sourcePath, args.Project.NameTemplate+"/")
+	for _, w := range args.Project.Conf.Whitespace {
+		if w.Glob != "" {
+			match, err := doublestar.Match(w.Glob, relativePath)
+			if err != nil {
+				return ws, err
+			}
+
+			if !match {
+				continue
+			}
+		}
+
+		if w.TrimBlocks != nil {
+			ws.trimBlocks = *w.TrimBlocks
+		}
+		if w.LStripBlocks != nil {
+			ws.lstripBlocks = *w.LStripBlocks
+		}
+		if w.CollapseBlankLines != nil {
+			ws.collapseBlankLines = *w.CollapseBlankLines
+		}
+	}
+
+	return ws, nil
+}
+
+// collapseBlankLines replaces runs of blank, or whitespace-only, lines with a
+// single empty line.
+func collapseBlankLines(data []byte) []byte {
+	lines := bytes.SplitAfter(data, []byte("\n"))
+
+	out := make([]byte, 0, len(data))
+	blank := false
+	for _, line := range lines {
+		if len(bytes.TrimSpace(line)) == 0 && bytes.HasSuffix(line, []byte("\n")) {
+			if blank {
+				continue
+			}
+
+			blank = true
+			if bytes.HasSuffix(line, []byte("\r\n")) {
+				out = append(out, '\r', '\n')
+			} else {
+				out = append(out, '\n')
+			}
+			continue
+		}
+
+		blank = false
+		out = append(out, line...)
+	}
+
+	return out
+}
@@ -0,0 +1,69 @@
+package scaffold
+
+import (
+	"io/fs"
+	"testing"
+	"testing/fstest"
+
+	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/core/rwfs"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func Test_collapseBlankLines(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "no blank lines", in: "a\nb\n", want: "a\nb\n"},
+		{name: "single blank line", in: "a\n\nb\n", want: "a\n\nb\n"},
+		{name: "run of blank lines", in: "a\n\n\n\nb\n", want: "a\n\nb\n"},
+		{name: "whitespace only lines", in: "a\n  \n\t\n\nb", want: "a\n\nb"},
+		{name: "crlf", in: "a\r\n\r\n\r\nb\r\n", want: "a\r\n\r\nb\r\n"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, string(collapseBlankLines([]byte(tt.in))))
+		})
+	}
+}
+
+func Test_RenderRWFS_Whitespace(t *testing.T) {
+	tmpl := []byte("items:\n  {{ range .Scaffold.items }}\n  - {{ . }}\n  {{ end }}\n\n\n\nend: true\n")
+
+	readFS := fstest.MapFS{
+		"templates/config.yml": {Data: tmpl},
+		"templates/README.md":  {Data: tmpl},
+	}
+
+	enabled, disabled := true, false
+	project := &Project{
+		NameTemplate: TemplateDirName,
+		Name:         TemplateDirName,
+		Conf: &ProjectScaffoldFile{
+			Whitespace: []Whitespace{
+				{TrimBlocks: &enabled, LStripBlocks: &enabled, CollapseBlankLines: &enabled},
+				{Glob: "**/*.md", TrimBlocks: &disabled},
+			},
+		},
+	}
+
+	memFS := rwfs.NewMemoryWFS()
+	err := RenderRWFS(tEngine, &RWFSArgs{
+		ReadFS:  readFS,
+		WriteFS: memFS,
+		Project: project,
+	}, engine.Vars{"Scaffold": engine.Vars{"items": []string{"a", "b"}}})
+	require.NoError(t, err)
+
+	got, err := fs.ReadFile(memFS, "config.yml")
+	require.NoError(t, err)
+	assert.Equal(t, "items:\n  - a\n  - b\n\nend: true\n", string(got))
+
+	got, err = fs.ReadFile(memFS, "README.md")
+	require.NoError(t, err)
+	assert.Equal(t, "items:\n\n  - a\n\n  - b\n\nend: true\n", string(got), "later entries override earlier ones")
+}
@@ -0,0 +1,202 @@
+package jsonschema
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode"
+)
+
+// Extender is implemented by types that adjust the schema generated for
+// them, for example to add an enum or to describe a custom YAML format.
+type Extender interface {
+	JSONSchemaExtend(s *Schema)
+}
+
+var extenderType = reflect.TypeOf((*Extender)(nil)).Elem()
+
+// Reflect returns the schema of the type of v. Struct fields are named by
+// their yaml tag and configured with the following tags:
+//
+//	description:"..."  the description of the field
+//	required:"true"    the field must be set
+//	enum:"a,b"         the allowed values of a string field
+//	default:"a"        the default value of a string field
+//
+// Struct types other than the root are added to $defs, objects don't allow
+// properties that aren't fields. Reflect returns an error when a field has no
+// description so the schema stays documented as types change.
+func Reflect(v any) (*Schema, error) {
+	r := &reflector{defs: map[string]*Schema{}, names: map[reflect.Type]string{}}
+
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	root := r.structSchema(t)
+	root.Schema = Draft
+	if len(r.defs) > 0 {
+		root.Defs = r.defs
+	}
+
+	if len(r.errs) > 0 {
+		return nil, errors.Join(r.errs...)
+	}
+
+	return root, nil
+}
+
+type reflector struct {
+	defs  map[string]*Schema
+	names map[reflect.Type]string
+	errs  []error
+}
+
+func (r *reflector) errorf(format string, args ...any) {
+	r.errs = append(r.errs, fmt.Errorf("jsonschema: "+format, args...))
+}
+
+func (r *reflector) schema(t reflect.Type) *Schema {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	var s *Schema
+	switch t.Kind() {
+	case reflect.Struct:
+		return r.ref(t)
+	case reflect.Interface:
+		s = &Schema{}
+	case reflect.String:
+		s = &Schema{Type: "string"}
+	case reflect.Bool:
+		s = &Schema{Type: "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		s = &Schema{Type: "integer"}
+	case reflect.Float32, reflect.Float64:
+		s = &Schema{Type: "number"}
+	case reflect.Slice, reflect.Array:
+		s = &Schema{Type: "array", Items: r.schema(t.Elem())}
+	case reflect.Map:
+		s = &Schema{Type: "object", AdditionalProperties: r.schema(t.Elem())}
+	default:
+		r.errorf("unsupported type %s", t)
+		return &Schema{}
+	}
+
+	extend(t, s)
+	return s
+}
+
+// ref adds the struct type t to $defs and returns a reference to it.
+func (r *reflector) ref(t reflect.Type) *Schema {
+	name, ok := r.names[t]
+	if !ok {
+		name = defName(t)
+		if name == "" {
+			r.errorf("anonymous struct %s", t)
+			return &Schema{}
+		}
+
+		if _, taken := r.defs[name]; taken {
+			r.errorf("duplicate definition %q for %s", name, t)
+			return &Schema{}
+		}
+
+		// Register the name first so recursive types refer to themselves.
+		r.names[t] = name
+		r.defs[name] = &Schema{}
+		*r.defs[name] = *r.structSchema(t)
+	}
+
+	return &Schema{Ref: defsPrefix + name}
+}
+
+func (r *reflector) structSchema(t reflect.Type) *Schema {
+	s := &Schema{Type: "object", Properties: &Properties{}, AdditionalProperties: False}
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if !f.IsExported() {
+			continue
+		}
+
+		name := fieldName(f)
+		if name == "" {
+			continue
+		}
+
+		desc, ok := f.Tag.Lookup("description")
+		if !ok {
+			r.errorf("field %s.%s has no description", t.Name(), f.Name)
+		}
+
+		prop := r.schema(f.Type)
+		if prop.Ref != "" {
+			// Copy references so the description isn't shared.
+			prop = &Schema{Ref: prop.Ref}
+		}
+		prop.Description = desc
+
+		if enum, ok := f.Tag.Lookup("enum"); ok {
+			prop.Enum = nil
+			for _, v := range strings.Split(enum, ",") {
+				prop.Enum = append(prop.Enum, v)
+			}
+		}
+
+		if def, ok := f.Tag.Lookup("default"); ok {
+			prop.Default = def
+		}
+
+		if f.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, name)
+		}
+
+		s.Properties.Set(name, prop)
+	}
+
+	extend(t, s)
+	return s
+}
+
+// extend calls the Extender implementation of t, if any.
+func extend(t reflect.Type, s *Schema) {
+	switch {
+	case t.Implements(extenderType):
+		reflect.Zero(t).Interface().(Extender).JSONSchemaExtend(s)
+	case reflect.PointerTo(t).Implements(extenderType):
+		reflect.New(t).Interface().(Extender).JSONSchemaExtend(s)
+	}
+}
+
+// fieldName returns the YAML name of a field, or an empty string when the
+// field isn't encoded.
+func fieldName(f reflect.StructField) string {
+	tag := f.Tag.Get("yaml")
+	name, _, _ := strings.Cut(tag, ",")
+
+	switch name {
+	case "-":
+		return ""
+	case "":
+		return strings.ToLower(f.Name)
+	default:
+		return name
+	}
+}
+
+// defName returns the name of the definition of t, the type name starting
+// with a lower case letter, or an empty string for anonymous structs.
+func defName(t reflect.Type) string {
+	name := []rune(t.Name())
+	if len(name) == 0 {
+		return ""
+	}
+
+	name[0] = unicode.ToLower(name[0])
+	return string(name)
+}
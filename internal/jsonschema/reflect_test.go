@@ -0,0 +1,76 @@
+package jsonschema
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testLevel string
+
+func (testLevel) JSONSchemaExtend(s *Schema) {
+	s.Enum = []any{"low", "high"}
+}
+
+type testNode struct {
+	Name     string         `yaml:"name" required:"true" description:"name of the node"`
+	Level    testLevel      `yaml:"level" description:"level of the node"`
+	Children []testNode     `yaml:"children" description:"child nodes"`
+	Labels   map[string]int `yaml:"labels" description:"labels of the node"`
+}
+
+type testConfig struct {
+	Root    testNode `yaml:"root" description:"the root node"`
+	Mode    string   `yaml:"mode" enum:"a,b" default:"a" description:"the mode"`
+	Enabled *bool    `description:"whether it's enabled"`
+	Ignored string   `yaml:"-"`
+	ignored string
+}
+
+func TestReflect(t *testing.T) {
+	s, err := Reflect(&testConfig{})
+	require.NoError(t, err)
+
+	data, err := json.Marshal(s)
+	require.NoError(t, err)
+
+	want := `{
+		"$schema": "https://json-schema.org/draft/2020-12/schema",
+		"type": "object",
+		"properties": {
+			"root": {"$ref": "#/$defs/testNode", "description": "the root node"},
+			"mode": {"description": "the mode", "type": "string", "enum": ["a", "b"], "default": "a"},
+			"enabled": {"description": "whether it's enabled", "type": "boolean"}
+		},
+		"additionalProperties": false,
+		"$defs": {
+			"testNode": {
+				"type": "object",
+				"properties": {
+					"name": {"description": "name of the node", "type": "string"},
+					"level": {"description": "level of the node", "type": "string", "enum": ["low", "high"]},
+					"children": {"description": "child nodes", "type": "array", "items": {"$ref": "#/$defs/testNode"}},
+					"labels": {"description": "labels of the node", "type": "object", "additionalProperties": {"type": "integer"}}
+				},
+				"required": ["name"],
+				"additionalProperties": false
+			}
+		}
+	}`
+
+	assert.JSONEq(t, want, string(data))
+	assert.Equal(t, []string{"root", "mode", "enabled"}, s.Properties.Keys())
+}
+
+func TestReflect_MissingDescription(t *testing.T) {
+	type undocumented struct {
+		Name string `yaml:"name"`
+		Kind string `yaml:"kind"`
+	}
+
+	_, err := Reflect(undocumented{})
+	require.EqualError(t, err, "jsonschema: field undocumented.Name has no description\n"+
+		"jsonschema: field undocumented.Kind has no description")
+}
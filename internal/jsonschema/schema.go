@@ -0,0 +1,136 @@
+// Package jsonschema generates JSON schemas from Go types and validates YAML
+// documents against them. Only the subset of JSON Schema used by scaffold's
+// configuration files is supported.
+package jsonschema
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+)
+
+// Draft is the JSON Schema dialect of generated schemas.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON schema. The zero value accepts any value.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	ID          string `json:"$id,omitempty"`
+	Comment     string `json:"$comment,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	Type        string `json:"type,omitempty"`
+	Enum        []any  `json:"enum,omitempty"`
+	Default     any    `json:"default,omitempty"`
+
+	Properties           *Properties `json:"properties,omitempty"`
+	Required             []string    `json:"required,omitempty"`
+	AdditionalProperties *Schema     `json:"additionalProperties,omitempty"`
+	Items                *Schema     `json:"items,omitempty"`
+
+	OneOf []*Schema `json:"oneOf,omitempty"`
+	AnyOf []*Schema `json:"anyOf,omitempty"`
+
+	Defs map[string]*Schema `json:"$defs,omitempty"`
+
+	// never marks the false schema, which no value is valid against.
+	never bool
+}
+
+// True accepts any value, False accepts none. They are used for
+// additionalProperties.
+var (
+	True  = &Schema{}
+	False = &Schema{never: true}
+)
+
+func (s *Schema) MarshalJSON() ([]byte, error) {
+	if s.never {
+		return []byte("false"), nil
+	}
+
+	type schema Schema
+	return json.Marshal((*schema)(s))
+}
+
+// Properties are the properties of an object schema, in declaration order.
+type Properties struct {
+	keys   []string
+	values map[string]*Schema
+}
+
+// Set adds or replaces the property called name.
+func (p *Properties) Set(name string, s *Schema) {
+	if p.values == nil {
+		p.values = map[string]*Schema{}
+	}
+
+	if _, ok := p.values[name]; !ok {
+		p.keys = append(p.keys, name)
+	}
+	p.values[name] = s
+}
+
+// Get returns the property called name.
+func (p *Properties) Get(name string) (*Schema, bool) {
+	if p == nil {
+		return nil, false
+	}
+
+	s, ok := p.values[name]
+	return s, ok
+}
+
+// Keys returns the property names in declaration order.
+func (p *Properties) Keys() []string {
+	if p == nil {
+		return nil
+	}
+
+	return p.keys
+}
+
+func (p *Properties) MarshalJSON() ([]byte, error) {
+	buf := &bytes.Buffer{}
+	buf.WriteByte('{')
+
+	for i, k := range p.keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+
+		key, err := json.Marshal(k)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(p.values[k])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// resolve follows $ref to a definition of root.
+func (s *Schema) resolve(root *Schema) *Schema {
+	for s.Ref != "" {
+		def, ok := root.Defs[strings.TrimPrefix(s.Ref, defsPrefix)]
+		if !ok {
+			return True
+		}
+		s = def
+	}
+
+	return s
+}
+
+// defsPrefix is the prefix of references to definitions of the root schema.
+const defsPrefix = "#/$defs/"
@@ -0,0 +1,267 @@
+package jsonschema
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// ValidationError is a value of a document that doesn't match the schema.
+// Line and Column are 1-based.
+type ValidationError struct {
+	Line    int
+	Column  int
+	Path    string
+	Message string
+}
+
+func (e ValidationError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("%d:%d: %s", e.Line, e.Column, e.Message)
+	}
+
+	return fmt.Sprintf("%d:%d: %s: %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// ValidateYAML validates the YAML document in data against s. The returned
+// error is only set when data isn't valid YAML, problems are returned as
+// validation errors sorted by position.
+func (s *Schema) ValidateYAML(data []byte) ([]ValidationError, error) {
+	var doc yaml.Node
+
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+
+	v := &validator{root: s}
+	v.validate(s, doc.Content[0], "")
+
+	sort.SliceStable(v.errs, func(i, j int) bool {
+		if v.errs[i].Line != v.errs[j].Line {
+			return v.errs[i].Line < v.errs[j].Line
+		}
+		return v.errs[i].Column < v.errs[j].Column
+	})
+
+	return v.errs, nil
+}
+
+type validator struct {
+	root *Schema
+	errs []ValidationError
+}
+
+func (v *validator) report(n *yaml.Node, path, format string, args ...any) {
+	v.errs = append(v.errs, ValidationError{
+		Line:    n.Line,
+		Column:  n.Column,
+		Path:    path,
+		Message: fmt.Sprintf(format, args...),
+	})
+}
+
+func (v *validator) validate(s *Schema, n *yaml.Node, path string) {
+	s = s.resolve(v.root)
+
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	if s.never {
+		v.report(n, path, "not allowed")
+		return
+	}
+
+	if s.Type != "" && !matchesType(s.Type, n) {
+		v.report(n, path, "expected %s, got %s", s.Type, nodeType(n))
+		return
+	}
+
+	if len(s.Enum) > 0 {
+		v.validateEnum(s, n, path)
+	}
+
+	if len(s.OneOf) > 0 {
+		v.validateOneOf(s, n, path)
+	}
+
+	if len(s.AnyOf) > 0 {
+		v.validateAnyOf(s, n, path)
+	}
+
+	switch n.Kind {
+	case yaml.MappingNode:
+		v.validateObject(s, n, path)
+	case yaml.SequenceNode:
+		if s.Items != nil {
+			for i, item := range n.Content {
+				v.validate(s.Items, item, fmt.Sprintf("%s[%d]", path, i))
+			}
+		}
+	}
+}
+
+func (v *validator) validateEnum(s *Schema, n *yaml.Node, path string) {
+	allowed := make([]string, len(s.Enum))
+	for i, e := range s.Enum {
+		allowed[i] = fmt.Sprint(e)
+		if n.Kind == yaml.ScalarNode && n.Value == allowed[i] {
+			return
+		}
+	}
+
+	v.report(n, path, "must be one of %s", strings.Join(allowed, ", "))
+}
+
+// validateOneOf validates n against the alternative matching its type,
+// which is enough for the unions of scaffold's schemas.
+func (v *validator) validateOneOf(s *Schema, n *yaml.Node, path string) {
+	types := []string{}
+	for _, alt := range s.OneOf {
+		alt = alt.resolve(v.root)
+		if alt.Type == "" || matchesType(alt.Type, n) {
+			v.validate(alt, n, path)
+			return
+		}
+		types = append(types, alt.Type)
+	}
+
+	v.report(n, path, "expected %s, got %s", strings.Join(types, " or "), nodeType(n))
+}
+
+func (v *validator) validateAnyOf(s *Schema, n *yaml.Node, path string) {
+	messages := []string{}
+	for _, alt := range s.AnyOf {
+		sub := &validator{root: v.root}
+		sub.validate(alt, n, path)
+		if len(sub.errs) == 0 {
+			return
+		}
+		messages = append(messages, sub.errs[0].Message)
+	}
+
+	v.report(n, path, "%s", strings.Join(messages, " or "))
+}
+
+func (v *validator) validateObject(s *Schema, n *yaml.Node, path string) {
+	seen := map[string]bool{}
+
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		key, value := n.Content[i], n.Content[i+1]
+
+		// Merge keys are resolved by the YAML decoder.
+		if key.ShortTag() == "!!merge" {
+			continue
+		}
+
+		seen[key.Value] = true
+		child := key.Value
+		if path != "" {
+			child = path + "." + key.Value
+		}
+
+		if prop, ok := s.Properties.Get(key.Value); ok {
+			// An empty optional field decodes to its zero value, like a
+			// missing one.
+			if isNull(value) && !slices.Contains(s.Required, key.Value) {
+				continue
+			}
+
+			v.validate(prop, value, child)
+			continue
+		}
+
+		switch {
+		case s.AdditionalProperties == nil:
+		case s.AdditionalProperties.never:
+			v.report(key, path, "unknown property %q", key.Value)
+		default:
+			v.validate(s.AdditionalProperties, value, child)
+		}
+	}
+
+	for _, name := range s.Required {
+		if !seen[name] && !mergedKey(n, name) {
+			v.report(n, path, "missing property %q", name)
+		}
+	}
+}
+
+// mergedKey reports whether name is set by a merge key of the mapping n.
+func mergedKey(n *yaml.Node, name string) bool {
+	for i := 0; i+1 < len(n.Content); i += 2 {
+		if n.Content[i].ShortTag() != "!!merge" {
+			continue
+		}
+
+		var m map[string]any
+		if err := n.Content[i+1].Decode(&m); err == nil {
+			if _, ok := m[name]; ok {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func matchesType(typ string, n *yaml.Node) bool {
+	switch typ {
+	case "object":
+		return n.Kind == yaml.MappingNode
+	case "array":
+		return n.Kind == yaml.SequenceNode
+	case "string":
+		// Scalars such as 0.5 or true decode into string fields as written.
+		return n.Kind == yaml.ScalarNode && !isNull(n)
+	case "boolean":
+		return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!bool"
+	case "integer":
+		return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!int"
+	case "number":
+		return n.Kind == yaml.ScalarNode && (n.ShortTag() == "!!int" || n.ShortTag() == "!!float")
+	default:
+		return true
+	}
+}
+
+func isNull(n *yaml.Node) bool {
+	for n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+
+	return n.Kind == yaml.ScalarNode && n.ShortTag() == "!!null"
+}
+
+func nodeType(n *yaml.Node) string {
+	switch n.Kind {
+	case yaml.MappingNode:
+		return "object"
+	case yaml.SequenceNode:
+		return "array"
+	}
+
+	switch n.ShortTag() {
+	case "!!str":
+		return "string"
+	case "!!bool":
+		return "boolean"
+	case "!!int":
+		return "integer"
+	case "!!float":
+		return "number"
+	case "!!null":
+		return "null"
+	default:
+		return strconv.Quote(n.ShortTag())
+	}
+}
@@ -0,0 +1,147 @@
+package jsonschema
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testItem struct {
+	Name string `yaml:"name" required:"true" description:"name of the item"`
+	Kind string `yaml:"kind" description:"kind of the item"`
+}
+
+// JSONSchemaExtend allows items to be written as their name.
+func (testItem) JSONSchemaExtend(s *Schema) {
+	object := *s
+	*s = Schema{OneOf: []*Schema{{Type: "string"}, &object}}
+}
+
+type testPrompt struct {
+	Message string `yaml:"message" description:"message"`
+	Confirm string `yaml:"confirm" description:"confirm"`
+}
+
+func (testPrompt) JSONSchemaExtend(s *Schema) {
+	s.AnyOf = []*Schema{{Required: []string{"message"}}, {Required: []string{"confirm"}}}
+}
+
+type testDoc struct {
+	Items  []testItem        `yaml:"items" description:"items"`
+	Count  int               `yaml:"count" description:"count"`
+	Ratio  float64           `yaml:"ratio" description:"ratio"`
+	Mode   string            `yaml:"mode" enum:"a,b" description:"mode"`
+	Prompt testPrompt        `yaml:"prompt" description:"prompt"`
+	Vars   map[string]string `yaml:"vars" description:"vars"`
+}
+
+func TestValidateYAML(t *testing.T) {
+	s, err := Reflect(testDoc{})
+	require.NoError(t, err)
+
+	tests := []struct {
+		name string
+		doc  string
+		want []string
+	}{
+		{
+			name: "empty",
+			doc:  "",
+		},
+		{
+			name: "valid",
+			doc: `
+items:
+  - one
+  - name: two
+    kind: x
+count: 1
+ratio: 1
+mode: b
+prompt:
+  confirm: ok?
+vars:
+  a: b
+`,
+		},
+		{
+			name: "unknown property",
+			doc:  "count: 1\ncolor: red\n",
+			want: []string{`2:1: unknown property "color"`},
+		},
+		{
+			name: "types",
+			doc:  "count: one\nratio: true\nvars:\n  a: [b]\n",
+			want: []string{
+				"1:8: count: expected integer, got string",
+				"2:8: ratio: expected number, got boolean",
+				"4:6: vars.a: expected string, got array",
+			},
+		},
+		{
+			name: "scalars as strings",
+			doc:  "mode: a\nvars:\n  version: 0.5\n  debug: true\n  port: 8080\n",
+		},
+		{
+			name: "null optional fields",
+			doc:  "items:\ncount: ~\nvars:\n  a: null\n",
+			want: []string{"4:6: vars.a: expected string, got null"},
+		},
+		{
+			name: "null required field",
+			doc:  "items:\n  - name:\n",
+			want: []string{"2:10: items[0].name: expected string, got null"},
+		},
+		{
+			name: "enum",
+			doc:  "mode: c\n",
+			want: []string{"1:7: mode: must be one of a, b"},
+		},
+		{
+			name: "one of",
+			doc:  "items:\n  - kind: x\n  - [x]\n  - name: y\n    size: 2\n",
+			want: []string{
+				`2:5: items[0]: missing property "name"`,
+				"3:5: items[1]: expected string or object, got array",
+				`5:5: items[2]: unknown property "size"`,
+			},
+		},
+		{
+			name: "any of",
+			doc:  "prompt: {}\n",
+			want: []string{`1:9: prompt: missing property "message" or missing property "confirm"`},
+		},
+		{
+			name: "merge keys",
+			doc:  "vars: &vars\n  a: b\nitems:\n  - <<: {name: x}\n    kind: y\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			errs, err := s.ValidateYAML([]byte(tt.doc))
+			require.NoError(t, err)
+
+			got := make([]string, len(errs))
+			for i, e := range errs {
+				got[i] = e.Error()
+			}
+
+			if len(tt.want) == 0 {
+				assert.Empty(t, got)
+				return
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestValidateYAML_SyntaxError(t *testing.T) {
+	s, err := Reflect(testDoc{})
+	require.NoError(t, err)
+
+	_, err = s.ValidateYAML([]byte("count: [1\n"))
+	require.Error(t, err)
+}
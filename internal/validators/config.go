@@ -2,15 +2,15 @@ package validators
 
 // Validate is a struct the holds the configuration for a validator.
 type Validate struct {
-	Required  bool          `yaml:"required"`
-	MinLength int           `yaml:"min"`
-	MaxLength int           `yaml:"max"`
-	Match     ValidateMatch `yaml:"match"`
+	Required  bool          `yaml:"required" description:"When true, ensures the user provides a value"`
+	MinLength int           `yaml:"min" description:"Minimum value (string length, selection count, or loop items)"`
+	MaxLength int           `yaml:"max" description:"Maximum value (string length, selection count, or loop items)"`
+	Match     ValidateMatch `yaml:"match" description:"Regular expression the input must match"`
 }
 
 type ValidateMatch struct {
-	Regex   string `yaml:"regex"`
-	Message string `yaml:"message"`
+	Regex   string `yaml:"regex" required:"true" description:"Regular expression the input must match"`
+	Message string `yaml:"message" description:"Error message shown if input doesn't match"`
 }
 
 // GetValidatorFuncs converts a Validate struct into a slice of validator functions.
@@ -11,6 +11,7 @@ import (
 	"github.com/charmbracelet/glamour"
 	"github.com/hay-kot/scaffold/app/commands"
 	"github.com/hay-kot/scaffold/app/core/engine"
+	"github.com/hay-kot/scaffold/app/scaffold"
 	"github.com/hay-kot/scaffold/app/scaffold/scaffoldrc"
 	"github.com/hay-kot/scaffold/internal/appdirs"
 	"github.com/hay-kot/scaffold/internal/printer"
@@ -27,8 +28,6 @@ var (
 	date    = "now"
 )
 
-var ErrLinterErrors = errors.New("scaffold errors found")
-
 func build() string {
 	short := commit
 	if len(commit) > 7 {
@@ -251,6 +250,11 @@ Note: CLI variables override preset values.`,
 						Usage:   "overwrite existing files",
 						Sources: cli.EnvVars("SCAFFOLD_OVERWRITE"),
 					},
+					&cli.StringFlag{
+						Name:    "on-conflict",
+						Usage:   "action for existing files: keep, overwrite, merge, sidecar or prompt",
+						Sources: cli.EnvVars("SCAFFOLD_ON_CONFLICT"),
+					},
 					&cli.BoolFlag{
 						Name:    "force",
 						Usage:   "allow scaffolding when git working tree is dirty",
@@ -263,11 +267,25 @@ Note: CLI variables override preset values.`,
 						Value:   ".",
 						Sources: cli.EnvVars("SCAFFOLD_OUT"),
 					},
+					&cli.BoolFlag{
+						Name:  "verify",
+						Usage: "run the scaffold's checks against the output",
+						Value: false,
+					},
 					&cli.BoolFlag{
 						Name:  "dry-run",
 						Usage: "validate and show what files would be created without writing (outputs JSON)",
 						Value: false,
 					},
+					&cli.BoolFlag{
+						Name:  "watch",
+						Usage: "render again with the same answers when the scaffold changes (output to :memory: or an empty directory)",
+						Value: false,
+					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "only offer scaffolds with this tag or category in the picker (repeatable)",
+					},
 				},
 				Action: func(ctx context.Context, c *cli.Command) error {
 					return ctrl.New(c.Args().Slice(), commands.FlagsNew{
@@ -275,9 +293,13 @@ Note: CLI variables override preset values.`,
 						Preset:     c.String("preset"),
 						Snapshot:   c.String("snapshot"),
 						Overwrite:  c.Bool("overwrite"),
+						OnConflict: c.String("on-conflict"),
 						ForceApply: c.Bool("force"),
 						OutputDir:  c.String("output-dir"),
 						DryRun:     c.Bool("dry-run"),
+						Verify:     c.Bool("verify"),
+						Watch:      c.Bool("watch"),
+						Tags:       c.StringSlice("tag"),
 					})
 				},
 			},
@@ -294,6 +316,10 @@ Note: CLI variables override preset values.`,
 						Usage: "output in JSON format for programmatic use",
 						Value: false,
 					},
+					&cli.StringSliceFlag{
+						Name:  "tag",
+						Usage: "only list scaffolds with this tag or category (repeatable)",
+					},
 				},
 				Aliases: []string{"ls"},
 				Usage:   "list available scaffolds",
@@ -301,6 +327,7 @@ Note: CLI variables override preset values.`,
 					return ctrl.List(commands.FlagsList{
 						OutputDir: c.String("cwd"),
 						JSON:      c.Bool("json"),
+						Tags:      c.StringSlice("tag"),
 					})
 				},
 			},
@@ -312,16 +339,33 @@ Note: CLI variables override preset values.`,
 			{
 				Name:      "inspect",
 				Usage:     "inspect a scaffold and output its structure as JSON",
-				UsageText: "scaffold inspect [scaffold (url | path)]",
+				UsageText: "scaffold inspect [flags] [scaffold (url | path)]",
 				Description: `Inspect a scaffold and output its structure as JSON.
 
 This command is useful for programmatic access to scaffold metadata,
 including questions, presets, computed values, and features.
 
+With --plan the output includes what the scaffold produces: the output
+paths after rewrites, features and each expansion, injections, hooks,
+partials, delimiter overrides and each configs. The scaffold is rendered
+into memory with the answers of --preset, or the question defaults, and
+no hooks are run.
+
 Examples:
   scaffold inspect mytemplate
   scaffold inspect github.com/hay-kot/scaffold-go-cli
-  scaffold inspect ./path/to/scaffold`,
+  scaffold inspect ./path/to/scaffold
+  scaffold inspect --plan --preset default ./path/to/scaffold`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "plan",
+						Usage: "include the files the scaffold produces",
+					},
+					&cli.StringFlag{
+						Name:  "preset",
+						Usage: "preset to answer the questions with for --plan",
+					},
+				},
 				Action: func(ctx context.Context, c *cli.Command) error {
 					path := c.Args().First()
 					if path == "" {
@@ -329,38 +373,210 @@ Examples:
 					}
 
 					return ctrl.Inspect(commands.FlagsInspect{
-						Path: path,
+						Path:   path,
+						Plan:   c.Bool("plan"),
+						Preset: c.String("preset"),
+					})
+				},
+			},
+			{
+				Name:      "test",
+				Usage:     "render a scaffold's test cases and compare them with snapshots",
+				UsageText: "scaffold test [flags] [scaffold]",
+				Description: `Renders every preset and every test case in the scaffold's tests/ directory
+into memory and compares the output with the snapshots in tests/snapshots/.
+The scaffold defaults to the current directory.
+
+Test case files set the question values and optionally start from a preset:
+
+  # tests/with-docker.yaml
+  preset: default
+  vars:
+    Project: my-app
+    docker: true`,
+				Flags: []cli.Flag{
+					&cli.BoolFlag{
+						Name:  "update",
+						Usage: "write the snapshots instead of comparing them",
+					},
+					&cli.StringSliceFlag{
+						Name:  "run",
+						Usage: "only run the named test cases",
+					},
+					&cli.BoolFlag{
+						Name:  "matrix",
+						Usage: "render combinations of the confirm and select answers instead of the test cases",
+					},
+					&cli.IntFlag{
+						Name:  "max",
+						Usage: "maximum number of matrix combinations, larger matrices are sampled",
+						Value: scaffold.DefaultMatrixMax,
+					},
+					&cli.Int64Flag{
+						Name:  "seed",
+						Usage: "seed for sampling matrix combinations",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctrl.Test(c.Args().Slice(), commands.FlagsTest{
+						Update: c.Bool("update"),
+						Run:    c.StringSlice("run"),
+						Matrix: c.Bool("matrix"),
+						Max:    int(c.Int("max")),
+						Seed:   c.Int64("seed"),
 					})
 				},
 			},
 			{
 				Name:      "lint",
-				Usage:     "lint a scaffoldrc file",
-				UsageText: "scaffold lint [scaffold file]",
+				Usage:     "lint a scaffold file and its templates",
+				UsageText: "scaffold lint [flags] [scaffold]",
+				Description: `Lints a scaffold file and its templates. The scaffold is a scaffold file,
+a scaffold directory or any reference accepted by 'scaffold new', and defaults
+to the current directory.`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:  "format",
+						Usage: "output format: text, json, sarif or github",
+						Value: commands.LintFormatText,
+					},
+					&cli.BoolFlag{
+						Name:  "all",
+						Usage: "lint every local and cached scaffold",
+					},
+				},
 				Action: func(ctx context.Context, c *cli.Command) error {
-					pfpath := c.Args().First()
-					if pfpath == "" {
-						return errors.New("no file provided")
-					}
+					return ctrl.Lint(c.Args().Slice(), commands.FlagsLint{
+						Format: c.String("format"),
+						All:    c.Bool("all"),
+					})
+				},
+			},
+			{
+				Name:      "render-string",
+				Usage:     "render a template string or file with variables",
+				UsageText: "scaffold render-string [flags] [template] [key=value...]",
+				Description: `Renders a template with the scaffold template engine and prints the output,
+useful for debugging expressions and authoring partials.
 
-					err := ctrl.Lint(pfpath)
-					if err != nil {
-						errlist, ok := err.(commands.ErrList) // nolint: errorlint
-						if !ok {
-							return err
-						}
+Variables are read from a scaffold preset, a JSON file and key=value arguments,
+later sources taking precedence. Arguments accept the same key:type=value
+syntax as 'scaffold new'. .Project, its case variants and the scaffold's
+.Computed values are available, and the scaffold's partials can be used.
 
-						items := make([]printer.StatusListItem, 0, len(errlist))
-						for _, e := range errlist {
-							items = append(items, printer.StatusListItem{Ok: false, Status: e.Error()})
-						}
+Examples:
+  scaffold render-string '{{ .Scaffold.name | toUpper }}' name=scaffold
+  scaffold render-string --scaffold ./my-scaffold --preset default '{{ .Computed.slug }}'
+  scaffold render-string --file partials/header.tmpl --vars vars.json --watch`,
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name:    "file",
+						Aliases: []string{"f"},
+						Usage:   "render the template file instead of a template string",
+					},
+					&cli.StringFlag{
+						Name:  "vars",
+						Usage: "JSON file of variables",
+					},
+					&cli.StringFlag{
+						Name:  "scaffold",
+						Usage: "scaffold to load computed values, partials and presets from",
+					},
+					&cli.StringFlag{
+						Name:  "preset",
+						Usage: "preset of the scaffold to use as variables",
+					},
+					&cli.BoolFlag{
+						Name:  "watch",
+						Usage: "re-render when the template, vars file or scaffold changes",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctrl.RenderString(ctx, c.Args().Slice(), commands.FlagsRenderString{
+						File:     c.String("file"),
+						VarsFile: c.String("vars"),
+						Scaffold: c.String("scaffold"),
+						Preset:   c.String("preset"),
+						Watch:    c.Bool("watch"),
+					})
+				},
+			},
+			{
+				Name:  "lsp",
+				Usage: "run the language server for scaffold files and templates",
+				Description: `Runs a language server over stdin and stdout. It publishes lint diagnostics
+and provides completion, hover and go to definition for scaffold.yaml and
+template files. Configure your editor to start 'scaffold lsp'.`,
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctrl.LSP()
+				},
+			},
+			{
+				Name:      "extract",
+				Usage:     "create a scaffold from an existing project",
+				UsageText: "scaffold extract [flags] [project directory] [output directory] [literal=variable...]",
+				Description: `Create a scaffold from an existing project by replacing literal values
+with template variables in file contents and paths.
+
+Map literals to variables using literal=variable:
+  • acme-billing=.Project     - the project name
+  • 8080=.Scaffold.port       - a question (also accepts 'port')
 
-						console.StatusList("Scaffold Errors", items)
+Case variants of each literal (snake, kebab, camel and pascal) are replaced
+as well. Every .Scaffold variable becomes a question with the literal as
+its default.
 
-						return ErrLinterErrors
+Examples:
+  scaffold extract ./acme-billing .scaffold/service acme-billing=.Project 8080=port
+  scaffold extract --ignore 'node_modules/**' ./app ./app-scaffold app=.Project`,
+				Flags: []cli.Flag{
+					&cli.StringSliceFlag{
+						Name:  "ignore",
+						Usage: "glob of paths to exclude, relative to the project directory (.git is always excluded)",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					if c.Args().Len() < 3 {
+						return errors.New("project directory, output directory and at least one mapping are required")
 					}
 
-					return nil
+					return ctrl.Extract(commands.FlagsExtract{
+						Source:   c.Args().Get(0),
+						Dest:     c.Args().Get(1),
+						Mappings: c.Args().Slice()[2:],
+						Ignore:   c.StringSlice("ignore"),
+					})
+				},
+			},
+			{
+				Name:  "import",
+				Usage: "convert templates from other tools into scaffolds",
+				Commands: []*cli.Command{
+					{
+						Name:      "cookiecutter",
+						Usage:     "convert a cookiecutter template into a scaffold",
+						UsageText: "scaffold import cookiecutter [template (url | path)] [output directory]",
+						Description: `Convert a cookiecutter template into a native scaffold.
+
+cookiecutter.json variables become questions, the {{cookiecutter.<slug>}}
+directory becomes {{ .Project }} and Jinja expressions are translated to Go
+templates where possible. Anything that can't be translated is listed for
+manual review.
+
+Examples:
+  scaffold import cookiecutter https://github.com/audreyfeldroy/cookiecutter-pypackage .scaffold/pypackage
+  scaffold import cookiecutter ./my-cookiecutter ./my-scaffold`,
+						Action: func(ctx context.Context, c *cli.Command) error {
+							if c.Args().Len() != 2 {
+								return errors.New("template and output directory are required")
+							}
+
+							return ctrl.ImportCookiecutter(commands.FlagsImport{
+								Source: c.Args().Get(0),
+								Dest:   c.Args().Get(1),
+							})
+						},
+					},
 				},
 			},
 			{
@@ -376,16 +592,36 @@ Examples:
 				Action: ctrl.Init,
 			},
 			{
-				Name:  "schema",
-				Usage: "output JSON schema for scaffold configuration files",
+				Name:      "schema",
+				Usage:     "output JSON schema for scaffold configuration files",
+				UsageText: "scaffold schema [--type scaffold | scaffoldrc] [--validate scaffold.yaml]",
+				Description: `Output the JSON schema for scaffold configuration files.
+
+The scaffold.yaml schema is generated from the configuration types of
+this version of scaffold. With --validate a scaffold file is checked
+against it and each problem is printed as file:line:column: message.
+
+Examples:
+  scaffold schema > schema.json
+  scaffold schema --type scaffoldrc
+  scaffold schema --validate .scaffold/cli/scaffold.yaml`,
 				Flags: []cli.Flag{
 					&cli.StringFlag{
 						Name:  "type",
 						Usage: "schema type: 'scaffold' for scaffold.yaml or 'scaffoldrc' for scaffoldrc.yml",
 						Value: "scaffold",
 					},
+					&cli.StringFlag{
+						Name:  "validate",
+						Usage: "validate a scaffold file against the schema instead of printing it",
+					},
+				},
+				Action: func(ctx context.Context, c *cli.Command) error {
+					return ctrl.Schema(commands.FlagsSchema{
+						Type:     c.String("type"),
+						Validate: c.String("validate"),
+					})
 				},
-				Action: ctrl.Schema,
 			},
 			{
 				Name:   "dev",
@@ -471,7 +707,7 @@ Examples:
 		switch {
 		// ignore these errors, urfave/cli does not provide any way to hanldle them
 		// without direct string comparison :(
-		case strings.HasPrefix(errstr, "flag provided but not defined"), errors.Is(err, ErrLinterErrors):
+		case strings.HasPrefix(errstr, "flag provided but not defined"), errors.Is(err, commands.ErrLinterErrors):
 			// ignore
 		default:
 			console.FatalError(err)